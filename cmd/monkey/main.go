@@ -1,17 +1,75 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
 
+	"github.com/donovandicks/gomonkey/internal/ast"
+	"github.com/donovandicks/gomonkey/internal/compiler"
+	"github.com/donovandicks/gomonkey/internal/diagnostic"
 	"github.com/donovandicks/gomonkey/internal/interpreter"
 	"github.com/donovandicks/gomonkey/internal/lexer"
 	"github.com/donovandicks/gomonkey/internal/object"
 	"github.com/donovandicks/gomonkey/internal/parser"
+	"github.com/donovandicks/gomonkey/internal/vm"
 )
 
+var (
+	engine      = flag.String("engine", "interpreter", "execution engine to use: interpreter or vm")
+	diagnostics = flag.String("diagnostics", "text", "format of syntax errors: text or json")
+	timeout     = flag.Duration("timeout", 0, "stop the program after this long, if set")
+	maxSteps    = flag.Int("max-steps", 0, "stop the program after this many steps, if set")
+	maxDepth    = flag.Int("max-depth", object.MAX_CALL_DEPTH, "maximum depth of nested calls")
+)
+
+func report(diags []diagnostic.Diagnostic, source string) {
+	if *diagnostics == "json" {
+		out, err := json.MarshalIndent(diags, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Println(string(out))
+		return
+	}
+
+	for _, diag := range diags {
+		fmt.Print(diag.Render(source))
+	}
+}
+
+func run(prog *ast.Program) (object.Object, error) {
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	budget := object.NewBudget(ctx, *maxSteps).WithMaxDepth(*maxDepth)
+
+	switch *engine {
+	case "interpreter":
+		return interpreter.Eval(prog, object.NewEnv().SetBudget(budget)), nil
+	case "vm":
+		c := compiler.NewCompiler()
+		if err := c.Compile(prog); err != nil {
+			return nil, err
+		}
+
+		return vm.New(c.Bytecode()).SetBudget(budget).Run(), nil
+	default:
+		return nil, fmt.Errorf("unknown engine '%s'", *engine)
+	}
+}
+
 func main() {
-	fileName := os.Args[1]
+	flag.Parse()
+
+	fileName := flag.Arg(0)
 	if fileName == "" {
 		panic("must pass file name")
 	}
@@ -21,18 +79,25 @@ func main() {
 		panic(err)
 	}
 
-	l := lexer.NewLexer(string(input))
+	l := lexer.NewFileLexer(fileName, string(input))
 	p := parser.NewParser(l)
 	prog := p.ParseProgram()
 	if errs := p.Errors(); len(errs) != 0 {
-		for _, msg := range errs {
-			fmt.Printf("ERROR: %s", msg)
-		}
+		report(errs, string(input))
+		os.Exit(1)
+	}
+
+	evaled, err := run(prog)
+	if err != nil {
+		fmt.Printf("ERROR: %s\n", err)
 		return
 	}
 
-	env := object.NewEnv()
-	evaled := interpreter.Eval(prog, env)
+	if err, ok := evaled.(*object.Err); ok {
+		fmt.Println(err.Traceback())
+		os.Exit(1)
+	}
+
 	if evaled != nil {
 		fmt.Printf("%s\n", evaled.Inspect())
 	}
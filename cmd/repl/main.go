@@ -33,13 +33,19 @@ func Start(in io.Reader, out io.Writer) {
 
 		program := p.ParseProgram()
 		if errs := p.Errors(); len(errs) != 0 {
-			for _, msg := range errs {
-				io.WriteString(out, "\t"+msg+"\n")
+			for _, diag := range errs {
+				io.WriteString(out, diag.Render(line))
 			}
 			continue
 		}
 
 		evaled := interpreter.Eval(program, env)
+		if err, ok := evaled.(*object.Err); ok {
+			io.WriteString(out, err.Traceback())
+			io.WriteString(out, "\n")
+			continue
+		}
+
 		if evaled != nil {
 			io.WriteString(out, evaled.Inspect())
 			io.WriteString(out, "\n")
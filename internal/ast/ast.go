@@ -2,11 +2,15 @@ package ast
 
 import (
 	"strings"
+
+	"github.com/donovandicks/gomonkey/internal/token"
 )
 
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position // position of the first byte belonging to the node
+	End() token.Position // position immediately after the node
 }
 
 type Program struct {
@@ -25,6 +29,22 @@ func (p *Program) TokenLiteral() string {
 	}
 }
 
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+
+	return token.Position{}
+}
+
+func (p *Program) End() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[len(p.Statements)-1].End()
+	}
+
+	return token.Position{}
+}
+
 func (p *Program) String() string {
 	var out strings.Builder
 
@@ -34,3 +54,23 @@ func (p *Program) String() string {
 
 	return out.String()
 }
+
+// endOf returns the end of the node, or the fallback position when the node
+// is missing, as happens in trees produced from invalid input.
+func endOf(n Node, fallback token.Position) token.Position {
+	if n == nil {
+		return fallback
+	}
+
+	return n.End()
+}
+
+// posOf returns the start of the node, or the fallback position when the node
+// is missing.
+func posOf(n Node, fallback token.Position) token.Position {
+	if n == nil {
+		return fallback
+	}
+
+	return n.Pos()
+}
@@ -20,6 +20,8 @@ type Identifier struct {
 func (i *Identifier) expressionNode()      {}
 func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
 func (i *Identifier) String() string       { return i.Value }
+func (i *Identifier) Pos() token.Position  { return i.Token.Pos }
+func (i *Identifier) End() token.Position  { return i.Token.End }
 func NewIdentifier(val string) *Identifier {
 	return &Identifier{
 		Token: token.Token{Type: token.IDENT, Literal: val},
@@ -35,6 +37,8 @@ type IntegerLiteral struct {
 func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
+func (il *IntegerLiteral) Pos() token.Position  { return il.Token.Pos }
+func (il *IntegerLiteral) End() token.Position  { return il.Token.End }
 func NewIntegerLiteral(value int64) *IntegerLiteral {
 	return &IntegerLiteral{
 		Token: token.Token{Type: token.INT, Literal: fmt.Sprint(value)},
@@ -42,6 +46,23 @@ func NewIntegerLiteral(value int64) *IntegerLiteral {
 	}
 }
 
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode()      {}
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
+func (fl *FloatLiteral) Pos() token.Position  { return fl.Token.Pos }
+func (fl *FloatLiteral) End() token.Position  { return fl.Token.End }
+func NewFloatLiteral(value float64) *FloatLiteral {
+	return &FloatLiteral{
+		Token: token.Token{Type: token.FLOAT, Literal: fmt.Sprint(value)},
+		Value: value,
+	}
+}
+
 type StringLiteral struct {
 	Token token.Token
 	Value string
@@ -50,6 +71,8 @@ type StringLiteral struct {
 func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
 func (sl *StringLiteral) String() string       { return sl.Token.Literal }
+func (sl *StringLiteral) Pos() token.Position  { return sl.Token.Pos }
+func (sl *StringLiteral) End() token.Position  { return sl.Token.End }
 func NewStringLiteral(value string) *StringLiteral {
 	return &StringLiteral{
 		Token: token.Token{Type: token.STRING, Literal: value},
@@ -65,6 +88,8 @@ type PrefixExpression struct {
 
 func (pe *PrefixExpression) expressionNode()      {}
 func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PrefixExpression) Pos() token.Position  { return pe.Token.Pos }
+func (pe *PrefixExpression) End() token.Position  { return endOf(pe.Right, pe.Token.End) }
 func (pe *PrefixExpression) String() string {
 	var out strings.Builder
 
@@ -85,6 +110,8 @@ type InfixExpression struct {
 
 func (ie *InfixExpression) expressionNode()      {}
 func (ie *InfixExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *InfixExpression) Pos() token.Position  { return posOf(ie.Left, ie.Token.Pos) }
+func (ie *InfixExpression) End() token.Position  { return endOf(ie.Right, ie.Token.End) }
 func (ie *InfixExpression) String() string {
 	var out strings.Builder
 
@@ -97,6 +124,16 @@ func (ie *InfixExpression) String() string {
 	return out.String()
 }
 
+type NullLiteral struct {
+	Token token.Token
+}
+
+func (n *NullLiteral) expressionNode()      {}
+func (n *NullLiteral) TokenLiteral() string { return n.Token.Literal }
+func (n *NullLiteral) String() string       { return n.Token.Literal }
+func (n *NullLiteral) Pos() token.Position  { return n.Token.Pos }
+func (n *NullLiteral) End() token.Position  { return n.Token.End }
+
 type Boolean struct {
 	Token token.Token
 	Value bool
@@ -105,6 +142,8 @@ type Boolean struct {
 func (b *Boolean) expressionNode()      {}
 func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
 func (b *Boolean) String() string       { return b.Token.Literal }
+func (b *Boolean) Pos() token.Position  { return b.Token.Pos }
+func (b *Boolean) End() token.Position  { return b.Token.End }
 func NewBoolean(value bool) *Boolean {
 	var tt token.TokenType
 	if value {
@@ -128,6 +167,18 @@ type IfExpression struct {
 
 func (ie *IfExpression) expressionNode()      {}
 func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IfExpression) Pos() token.Position  { return ie.Token.Pos }
+func (ie *IfExpression) End() token.Position {
+	if ie.Alternative != nil {
+		return ie.Alternative.End()
+	}
+
+	if ie.Consequence != nil {
+		return ie.Consequence.End()
+	}
+
+	return endOf(ie.Condition, ie.Token.End)
+}
 func (ie *IfExpression) String() string {
 	var out strings.Builder
 
@@ -144,6 +195,48 @@ func (ie *IfExpression) String() string {
 	return out.String()
 }
 
+// MatchExpression evaluates the body of the first arm whose pattern matches the
+// subject, and whose guard, if any, is truthy.
+type MatchExpression struct {
+	Token   token.Token // the 'match' token
+	Subject Expression
+	Arms    []*MatchArm
+	Rbrace  token.Position // position of the closing '}'
+}
+
+func (me *MatchExpression) expressionNode()      {}
+func (me *MatchExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MatchExpression) Pos() token.Position  { return me.Token.Pos }
+func (me *MatchExpression) End() token.Position  { return me.Rbrace.Add(1) }
+func (me *MatchExpression) String() string {
+	arms := make([]string, 0, len(me.Arms))
+	for _, arm := range me.Arms {
+		arms = append(arms, arm.String())
+	}
+
+	return fmt.Sprintf("match (%s) { %s }", me.Subject.String(), strings.Join(arms, ", "))
+}
+
+type MatchArm struct {
+	Pattern Pattern
+	Guard   Expression // nil if the arm has no guard
+	Body    Statement  // a block, or the statement of a single expression
+}
+
+func (ma *MatchArm) String() string {
+	var out strings.Builder
+
+	out.WriteString(ma.Pattern.String())
+	if ma.Guard != nil {
+		out.WriteString(" if ")
+		out.WriteString(ma.Guard.String())
+	}
+	out.WriteString(" => ")
+	out.WriteString(ma.Body.String())
+
+	return out.String()
+}
+
 type FunctionLiteral struct {
 	Token      token.Token
 	Parameters []*Identifier
@@ -152,6 +245,14 @@ type FunctionLiteral struct {
 
 func (fl *FunctionLiteral) expressionNode()      {}
 func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FunctionLiteral) Pos() token.Position  { return fl.Token.Pos }
+func (fl *FunctionLiteral) End() token.Position {
+	if fl.Body != nil {
+		return fl.Body.End()
+	}
+
+	return fl.Token.End
+}
 func (fl *FunctionLiteral) String() string {
 	var out strings.Builder
 
@@ -173,10 +274,13 @@ type CallExpression struct {
 	Token     token.Token
 	Function  Expression
 	Arguments []Expression
+	Rparen    token.Position // position of the closing ')'
 }
 
 func (ce *CallExpression) expressionNode()      {}
 func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CallExpression) Pos() token.Position  { return posOf(ce.Function, ce.Token.Pos) }
+func (ce *CallExpression) End() token.Position  { return ce.Rparen.Add(1) }
 func (ce *CallExpression) String() string {
 	var out strings.Builder
 
@@ -193,25 +297,38 @@ func (ce *CallExpression) String() string {
 	return out.String()
 }
 
+// AssignmentExpression assigns to an identifier, a property or an index. It
+// also represents compound assignments such as `x += 1`.
 type AssignmentExpression struct {
-	Token token.Token // identifier token
+	Token token.Token // the assignment operator
 	Left  Expression
 	Right Expression
 }
 
 func (ae *AssignmentExpression) expressionNode()      {}
 func (ae *AssignmentExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae *AssignmentExpression) Pos() token.Position  { return posOf(ae.Left, ae.Token.Pos) }
+func (ae *AssignmentExpression) End() token.Position  { return endOf(ae.Right, ae.Token.End) }
 func (ae *AssignmentExpression) String() string {
-	return fmt.Sprintf("(%s = %s)", ae.Left.String(), ae.Right.String())
+	return fmt.Sprintf("(%s %s %s)", ae.Left.String(), ae.Token.Literal, ae.Right.String())
+}
+
+// Operator returns the operator a compound assignment applies to the target
+// and the right operand, or "" for a plain assignment.
+func (ae *AssignmentExpression) Operator() string {
+	return strings.TrimSuffix(ae.Token.Literal, "=")
 }
 
 type ListLiteral struct {
-	Token token.Token // the '[' token
-	Elems []Expression
+	Token  token.Token // the '[' token
+	Elems  []Expression
+	Rbrack token.Position // position of the closing ']'
 }
 
 func (ll *ListLiteral) expressionNode()      {}
 func (ll *ListLiteral) TokenLiteral() string { return ll.Token.Literal }
+func (ll *ListLiteral) Pos() token.Position  { return ll.Token.Pos }
+func (ll *ListLiteral) End() token.Position  { return ll.Rbrack.Add(1) }
 func (ll *ListLiteral) String() string {
 	var out strings.Builder
 
@@ -230,10 +347,13 @@ func (ll *ListLiteral) String() string {
 type MapLiteral struct {
 	Token   token.Token // the '{' token
 	Entries map[Expression]Expression
+	Rbrace  token.Position // position of the closing '}'
 }
 
 func (ml *MapLiteral) expressionNode()      {}
 func (ml *MapLiteral) TokenLiteral() string { return ml.Token.Literal }
+func (ml *MapLiteral) Pos() token.Position  { return ml.Token.Pos }
+func (ml *MapLiteral) End() token.Position  { return ml.Rbrace.Add(1) }
 func (ml *MapLiteral) String() string {
 	var out strings.Builder
 
@@ -247,18 +367,24 @@ func (ml *MapLiteral) String() string {
 }
 
 type IndexExpression struct {
-	Token token.Token // the '[' token
-	Left  Expression
-	Index Expression
+	Token  token.Token // the '[' token, or the '?.' token of an optional index
+	Left   Expression
+	Index  Expression
+	Rbrack token.Position // position of the closing ']'
 }
 
 func (ie *IndexExpression) expressionNode()      {}
 func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) Pos() token.Position  { return posOf(ie.Left, ie.Token.Pos) }
+func (ie *IndexExpression) End() token.Position  { return ie.Rbrack.Add(1) }
 func (ie *IndexExpression) String() string {
 	var out strings.Builder
 
 	out.WriteString("(")
 	out.WriteString(ie.Left.String())
+	if ie.Optional() {
+		out.WriteString("?.")
+	}
 	out.WriteString("[")
 	out.WriteString(ie.Index.String())
 	out.WriteString("])")
@@ -266,17 +392,27 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+// Optional reports whether the expression is written `left?.[index]`, giving
+// null if the left operand is null or has no such element.
+func (ie *IndexExpression) Optional() bool { return ie.Token.Type == token.QUESTION_DOT }
+
 type GetExpression struct {
-	Token token.Token // the '.' token
+	Token token.Token // the '.' or '?.' token
 	Left  Expression
 	Right Expression // the property to access
 }
 
 func (ge *GetExpression) expressionNode()      {}
 func (ge *GetExpression) TokenLiteral() string { return ge.Token.Literal }
+func (ge *GetExpression) Pos() token.Position  { return posOf(ge.Left, ge.Token.Pos) }
+func (ge *GetExpression) End() token.Position  { return endOf(ge.Right, ge.Token.End) }
 func (ge *GetExpression) String() string {
-	return fmt.Sprintf("(%s.%s)", ge.Left.String(), ge.Right.String())
+	return fmt.Sprintf("(%s%s%s)", ge.Left.String(), ge.Token.Literal, ge.Right.String())
 }
+
+// Optional reports whether the expression is written `left?.right`, giving
+// null if the left operand is null or has no such property.
+func (ge *GetExpression) Optional() bool { return ge.Token.Type == token.QUESTION_DOT }
 func NewGetExpression(left, right Expression) *GetExpression {
 	return &GetExpression{
 		Token: token.TokenDot,
@@ -0,0 +1,135 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/donovandicks/gomonkey/internal/token"
+)
+
+// Pattern describes the shape of a value, binding names to the parts of the
+// value that it matches.
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+// LiteralPattern matches values equal to a literal: a number, which may be
+// negated, a string, a boolean or null.
+type LiteralPattern struct {
+	Value Expression
+}
+
+func (lp *LiteralPattern) patternNode()         {}
+func (lp *LiteralPattern) TokenLiteral() string { return lp.Value.TokenLiteral() }
+func (lp *LiteralPattern) String() string       { return lp.Value.String() }
+func (lp *LiteralPattern) Pos() token.Position  { return lp.Value.Pos() }
+func (lp *LiteralPattern) End() token.Position  { return lp.Value.End() }
+
+// BindingPattern matches any value, binding it to the name. The name `_`
+// matches without binding anything.
+type BindingPattern struct {
+	Name *Identifier
+}
+
+func (bp *BindingPattern) patternNode()         {}
+func (bp *BindingPattern) TokenLiteral() string { return bp.Name.TokenLiteral() }
+func (bp *BindingPattern) String() string       { return bp.Name.String() }
+func (bp *BindingPattern) Pos() token.Position  { return bp.Name.Pos() }
+func (bp *BindingPattern) End() token.Position  { return bp.Name.End() }
+func (bp *BindingPattern) IsWildcard() bool     { return bp.Name.Value == "_" }
+
+// ListPattern matches lists whose elements match the patterns, written
+// `[a, b]`. A list with a rest, written `[a, b, ...rest]`, matches lists with
+// at least as many elements, binding the rest of them as a list.
+type ListPattern struct {
+	Token  token.Token // the '[' token
+	Elems  []Pattern
+	Rest   *Identifier // nil if the pattern has no rest
+	Rbrack token.Position
+}
+
+func (lp *ListPattern) patternNode()         {}
+func (lp *ListPattern) TokenLiteral() string { return lp.Token.Literal }
+func (lp *ListPattern) Pos() token.Position  { return lp.Token.Pos }
+func (lp *ListPattern) End() token.Position  { return lp.Rbrack.Add(1) }
+func (lp *ListPattern) String() string {
+	elems := make([]string, 0, len(lp.Elems)+1)
+	for _, elem := range lp.Elems {
+		elems = append(elems, elem.String())
+	}
+
+	if lp.Rest != nil {
+		elems = append(elems, "..."+lp.Rest.String())
+	}
+
+	return fmt.Sprintf("[%s]", strings.Join(elems, ", "))
+}
+
+// MapPattern matches maps, and objects with properties, that have each of the
+// keys with a value matching its pattern. The entry `name` is short for
+// `"name": name`.
+type MapPattern struct {
+	Token  token.Token // the '{' token
+	Keys   []Expression
+	Values []Pattern
+	Rbrace token.Position
+}
+
+func (mp *MapPattern) patternNode()         {}
+func (mp *MapPattern) TokenLiteral() string { return mp.Token.Literal }
+func (mp *MapPattern) Pos() token.Position  { return mp.Token.Pos }
+func (mp *MapPattern) End() token.Position  { return mp.Rbrace.Add(1) }
+func (mp *MapPattern) String() string {
+	kvs := make([]string, 0, len(mp.Keys))
+	for i, key := range mp.Keys {
+		kvs = append(kvs, fmt.Sprintf("%s:%s", key.String(), mp.Values[i].String()))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(kvs, ", "))
+}
+
+// ClassPattern matches instances of the class, or of its subclasses, whose
+// fields match the map pattern, written `Name{field, other: pattern}`.
+type ClassPattern struct {
+	Class  *Identifier
+	Fields *MapPattern
+}
+
+func (cp *ClassPattern) patternNode()         {}
+func (cp *ClassPattern) TokenLiteral() string { return cp.Class.TokenLiteral() }
+func (cp *ClassPattern) String() string       { return cp.Class.String() + cp.Fields.String() }
+func (cp *ClassPattern) Pos() token.Position  { return cp.Class.Pos() }
+func (cp *ClassPattern) End() token.Position  { return cp.Fields.End() }
+
+// Bindings returns the names bound by the pattern, in the order they appear.
+func Bindings(pat Pattern) []*Identifier {
+	var names []*Identifier
+
+	var walk func(Pattern)
+	walk = func(pat Pattern) {
+		switch pat := pat.(type) {
+		case *BindingPattern:
+			if !pat.IsWildcard() {
+				names = append(names, pat.Name)
+			}
+		case *ListPattern:
+			for _, elem := range pat.Elems {
+				walk(elem)
+			}
+
+			if pat.Rest != nil && pat.Rest.Value != "_" {
+				names = append(names, pat.Rest)
+			}
+		case *MapPattern:
+			for _, val := range pat.Values {
+				walk(val)
+			}
+		case *ClassPattern:
+			walk(pat.Fields)
+		}
+	}
+
+	walk(pat)
+	return names
+}
@@ -13,18 +13,48 @@ type Statement interface {
 }
 
 type LetStatement struct {
-	Token token.Token // LET token
-	Name  *Identifier
-	Value Expression
+	Token   token.Token // LET token
+	Name    *Identifier
+	Pattern Pattern // destructures the value in place of a name, if set
+	Value   Expression
 }
 
 func (ls *LetStatement) statementNode()       {}
 func (ls *LetStatement) TokenLiteral() string { return ls.Token.Literal }
+func (ls *LetStatement) Pos() token.Position  { return ls.Token.Pos }
+func (ls *LetStatement) End() token.Position {
+	if ls.Value != nil {
+		return ls.Value.End()
+	}
+
+	if ls.Name != nil {
+		return ls.Name.End()
+	}
+
+	if ls.Pattern != nil {
+		return ls.Pattern.End()
+	}
+
+	return ls.Token.End
+}
+
+// Bindings returns the names bound by the statement.
+func (ls *LetStatement) Bindings() []*Identifier {
+	if ls.Pattern != nil {
+		return Bindings(ls.Pattern)
+	}
+
+	return []*Identifier{ls.Name}
+}
 func (ls *LetStatement) String() string {
 	var out strings.Builder
 
 	out.WriteString(ls.TokenLiteral() + " ")
-	out.WriteString(ls.Name.String())
+	if ls.Pattern != nil {
+		out.WriteString(ls.Pattern.String())
+	} else {
+		out.WriteString(ls.Name.String())
+	}
 	out.WriteString(" = ")
 
 	if ls.Value != nil {
@@ -43,6 +73,8 @@ type ReturnStatement struct {
 
 func (rs *ReturnStatement) statementNode()       {}
 func (rs *ReturnStatement) TokenLiteral() string { return rs.Token.Literal }
+func (rs *ReturnStatement) Pos() token.Position  { return rs.Token.Pos }
+func (rs *ReturnStatement) End() token.Position  { return endOf(rs.Value, rs.Token.End) }
 func (rs *ReturnStatement) String() string {
 	var out strings.Builder
 
@@ -64,6 +96,8 @@ type ExpressionStatement struct {
 
 func (es *ExpressionStatement) statementNode()       {}
 func (es *ExpressionStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *ExpressionStatement) Pos() token.Position  { return posOf(es.Expression, es.Token.Pos) }
+func (es *ExpressionStatement) End() token.Position  { return endOf(es.Expression, es.Token.End) }
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
 		return es.Expression.String()
@@ -80,6 +114,14 @@ type WhileStatement struct {
 
 func (ws *WhileStatement) statementNode()       {}
 func (ws *WhileStatement) TokenLiteral() string { return ws.Token.Literal }
+func (ws *WhileStatement) Pos() token.Position  { return ws.Token.Pos }
+func (ws *WhileStatement) End() token.Position {
+	if ws.Block != nil {
+		return ws.Block.End()
+	}
+
+	return endOf(ws.Condition, ws.Token.End)
+}
 func (ws *WhileStatement) String() string {
 	var out strings.Builder
 
@@ -96,6 +138,86 @@ func (ws *WhileStatement) String() string {
 	return out.String()
 }
 
+type ForStatement struct {
+	Token     token.Token // the 'for' token
+	Init      Statement   // optional statement run once before the loop
+	Condition Expression  // optional condition checked before each iteration
+	Post      Expression  // optional expression run after each iteration
+	Block     *BlockStatement
+}
+
+func (fs *ForStatement) statementNode()       {}
+func (fs *ForStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *ForStatement) Pos() token.Position  { return fs.Token.Pos }
+func (fs *ForStatement) End() token.Position {
+	if fs.Block != nil {
+		return fs.Block.End()
+	}
+
+	return fs.Token.End
+}
+func (fs *ForStatement) String() string {
+	var out strings.Builder
+
+	out.WriteString("for(")
+	if fs.Init != nil {
+		out.WriteString(strings.TrimSuffix(fs.Init.String(), ";"))
+	}
+	out.WriteString("; ")
+	if fs.Condition != nil {
+		out.WriteString(fs.Condition.String())
+	}
+	out.WriteString("; ")
+	if fs.Post != nil {
+		out.WriteString(fs.Post.String())
+	}
+	out.WriteString(") ")
+	out.WriteString(fs.Block.String())
+
+	return out.String()
+}
+
+type ForInStatement struct {
+	Token    token.Token // the 'for' token
+	Var      *Identifier // the name bound to each element
+	Iterable Expression
+	Block    *BlockStatement
+}
+
+func (fs *ForInStatement) statementNode()       {}
+func (fs *ForInStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *ForInStatement) Pos() token.Position  { return fs.Token.Pos }
+func (fs *ForInStatement) End() token.Position {
+	if fs.Block != nil {
+		return fs.Block.End()
+	}
+
+	return endOf(fs.Iterable, fs.Token.End)
+}
+func (fs *ForInStatement) String() string {
+	return fmt.Sprintf("for(%s in %s) %s", fs.Var.String(), fs.Iterable.String(), fs.Block.String())
+}
+
+type BreakStatement struct {
+	Token token.Token // the 'break' token
+}
+
+func (bs *BreakStatement) statementNode()       {}
+func (bs *BreakStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BreakStatement) Pos() token.Position  { return bs.Token.Pos }
+func (bs *BreakStatement) End() token.Position  { return bs.Token.End }
+func (bs *BreakStatement) String() string       { return bs.TokenLiteral() + ";" }
+
+type ContinueStatement struct {
+	Token token.Token // the 'continue' token
+}
+
+func (cs *ContinueStatement) statementNode()       {}
+func (cs *ContinueStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs *ContinueStatement) Pos() token.Position  { return cs.Token.Pos }
+func (cs *ContinueStatement) End() token.Position  { return cs.Token.End }
+func (cs *ContinueStatement) String() string       { return cs.TokenLiteral() + ";" }
+
 type FunctionStatement struct {
 	Token      token.Token // the `fn` token
 	Name       *Identifier // the function name identifier
@@ -105,6 +227,25 @@ type FunctionStatement struct {
 
 func (fs *FunctionStatement) statementNode()       {}
 func (fs *FunctionStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *FunctionStatement) Pos() token.Position {
+	// methods declared in a class body have no 'fn' keyword
+	if !fs.Token.Pos.IsValid() && fs.Name != nil {
+		return fs.Name.Pos()
+	}
+
+	return fs.Token.Pos
+}
+func (fs *FunctionStatement) End() token.Position {
+	if fs.Body != nil {
+		return fs.Body.End()
+	}
+
+	if fs.Name != nil {
+		return fs.Name.End()
+	}
+
+	return fs.Token.End
+}
 func (fs *FunctionStatement) String() string {
 	var out strings.Builder
 
@@ -127,8 +268,10 @@ func (fs *FunctionStatement) String() string {
 }
 
 type BlockStatement struct {
-	Token      token.Token
+	Token      token.Token // the first token of the block
 	Statements []Statement
+	Lbrace     token.Position // position of the opening '{'
+	Rbrace     token.Position // position of the closing '}'
 }
 
 func NewBlock(currToken token.Token) *BlockStatement {
@@ -137,6 +280,8 @@ func NewBlock(currToken token.Token) *BlockStatement {
 
 func (bs *BlockStatement) statementNode()       {}
 func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BlockStatement) Pos() token.Position  { return bs.Lbrace }
+func (bs *BlockStatement) End() token.Position  { return bs.Rbrace.Add(1) }
 func (bs *BlockStatement) String() string {
 	var out strings.Builder
 
@@ -150,11 +295,15 @@ func (bs *BlockStatement) String() string {
 type ClassStatement struct {
 	Token   token.Token // the `class` token
 	Name    *Identifier // the name of the class
+	Parent  *Identifier // the name of the class it extends, if any
 	Methods []*FunctionStatement
+	Rbrace  token.Position // position of the closing '}'
 }
 
 func (cs *ClassStatement) statementNode()       {}
 func (cs *ClassStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs *ClassStatement) Pos() token.Position  { return cs.Token.Pos }
+func (cs *ClassStatement) End() token.Position  { return cs.Rbrace.Add(1) }
 func (cs *ClassStatement) String() string {
 	var out strings.Builder
 
@@ -163,7 +312,163 @@ func (cs *ClassStatement) String() string {
 		methods = append(methods, m.String())
 	}
 
-	out.WriteString(fmt.Sprintf("class %s {\n%s\n}", cs.Name.String(), strings.Join(methods, "\n")))
+	out.WriteString("class " + cs.Name.String())
+	if cs.Parent != nil {
+		out.WriteString(" extends " + cs.Parent.String())
+	}
+	out.WriteString(fmt.Sprintf(" {\n%s\n}", strings.Join(methods, "\n")))
+
+	return out.String()
+}
+
+type ThrowStatement struct {
+	Token token.Token // the 'throw' token
+	Value Expression
+}
+
+func (ts *ThrowStatement) statementNode()       {}
+func (ts *ThrowStatement) TokenLiteral() string { return ts.Token.Literal }
+func (ts *ThrowStatement) Pos() token.Position  { return ts.Token.Pos }
+func (ts *ThrowStatement) End() token.Position  { return endOf(ts.Value, ts.Token.End) }
+func (ts *ThrowStatement) String() string {
+	var out strings.Builder
+
+	out.WriteString(ts.TokenLiteral() + " ")
+
+	if ts.Value != nil {
+		out.WriteString(ts.Value.String())
+	}
+
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// TryStatement runs its block, handing any error raised to the catch block and
+// then running the finally block however the others finished. At least one of
+// the catch and finally blocks is present.
+type TryStatement struct {
+	Token   token.Token // the 'try' token
+	Block   *BlockStatement
+	Param   *Identifier // the name the caught error is bound to
+	Catch   *BlockStatement
+	Finally *BlockStatement
+}
+
+func (ts *TryStatement) statementNode()       {}
+func (ts *TryStatement) TokenLiteral() string { return ts.Token.Literal }
+func (ts *TryStatement) Pos() token.Position  { return ts.Token.Pos }
+func (ts *TryStatement) End() token.Position {
+	switch {
+	case ts.Finally != nil:
+		return ts.Finally.End()
+	case ts.Catch != nil:
+		return ts.Catch.End()
+	case ts.Block != nil:
+		return ts.Block.End()
+	default:
+		return ts.Token.End
+	}
+}
+func (ts *TryStatement) String() string {
+	var out strings.Builder
+
+	out.WriteString("try { ")
+	out.WriteString(ts.Block.String())
+	out.WriteString(" }")
+
+	if ts.Catch != nil {
+		out.WriteString(fmt.Sprintf(" catch (%s) { %s }", ts.Param.String(), ts.Catch.String()))
+	}
+
+	if ts.Finally != nil {
+		out.WriteString(" finally { ")
+		out.WriteString(ts.Finally.String())
+		out.WriteString(" }")
+	}
 
 	return out.String()
 }
+
+// ImportStatement binds a module, or some of the names it exports, in the
+// importing module.
+type ImportStatement struct {
+	Token token.Token // the 'import' token
+	Path  *StringLiteral
+	Alias *Identifier   // the name bound to the whole module, if any
+	Names []*ImportName // the exports bound individually, if any
+}
+
+// ImportName is an export bound individually by an import statement, along
+// with the name it is bound to when that differs.
+type ImportName struct {
+	Name  *Identifier
+	Alias *Identifier
+}
+
+// Binding returns the name the export is bound to in the importing module.
+func (in *ImportName) Binding() *Identifier {
+	if in.Alias != nil {
+		return in.Alias
+	}
+
+	return in.Name
+}
+
+func (in *ImportName) String() string {
+	if in.Alias != nil {
+		return fmt.Sprintf("%s as %s", in.Name.String(), in.Alias.String())
+	}
+
+	return in.Name.String()
+}
+
+func (is *ImportStatement) statementNode()       {}
+func (is *ImportStatement) TokenLiteral() string { return is.Token.Literal }
+func (is *ImportStatement) Pos() token.Position  { return is.Token.Pos }
+func (is *ImportStatement) End() token.Position {
+	if is.Alias != nil {
+		return is.Alias.End()
+	}
+
+	return is.Path.End()
+}
+func (is *ImportStatement) String() string {
+	if is.Alias != nil {
+		return fmt.Sprintf("import \"%s\" as %s;", is.Path.Value, is.Alias.String())
+	}
+
+	names := make([]string, 0, len(is.Names))
+	for _, name := range is.Names {
+		names = append(names, name.String())
+	}
+
+	return fmt.Sprintf("import { %s } from \"%s\";", strings.Join(names, ", "), is.Path.Value)
+}
+
+// ExportStatement makes the name declared by a let, function or class
+// statement visible to modules that import the module it appears in.
+type ExportStatement struct {
+	Token     token.Token // the 'export' token
+	Statement Statement
+}
+
+// Names returns the names declared by the exported statement.
+func (es *ExportStatement) Names() []*Identifier {
+	switch stmt := es.Statement.(type) {
+	case *LetStatement:
+		return stmt.Bindings()
+	case *FunctionStatement:
+		return []*Identifier{stmt.Name}
+	case *ClassStatement:
+		return []*Identifier{stmt.Name}
+	default:
+		return nil
+	}
+}
+
+func (es *ExportStatement) statementNode()       {}
+func (es *ExportStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *ExportStatement) Pos() token.Position  { return es.Token.Pos }
+func (es *ExportStatement) End() token.Position  { return es.Statement.End() }
+func (es *ExportStatement) String() string       { return "export " + es.Statement.String() }
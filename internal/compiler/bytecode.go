@@ -8,4 +8,9 @@ import (
 type Bytecode struct {
 	Instrs opcode.Instructions
 	Consts []object.Object
+
+	// NumLocals is the number of locals of the main function
+	NumLocals int
+
+	SourceMap opcode.SourceMap
 }
@@ -1,30 +1,1194 @@
 package compiler
 
 import (
+	"sort"
+
 	"github.com/donovandicks/gomonkey/internal/ast"
+	"github.com/donovandicks/gomonkey/internal/module"
 	"github.com/donovandicks/gomonkey/internal/object"
 	"github.com/donovandicks/gomonkey/internal/opcode"
+	"github.com/donovandicks/gomonkey/internal/token"
 )
 
+// placeholder is the operand emitted for jumps whose target is not yet known.
+const placeholder = 9999
+
+type EmittedInstruction struct {
+	OpCode   opcode.OpCode
+	Position int
+}
+
+// loop records the jumps emitted for `break` and `continue` statements so they
+// can be patched once the targets of the enclosing loop are known.
+type loop struct {
+	breaks    []int
+	continues []int
+
+	// mark is the hidden local that records the height of the stack inside
+	// the loop, which the jumps unwind to
+	mark Symbol
+}
+
+// CompilationScope holds the instructions being emitted for a single function
+// body, or for the top level of the program.
+type CompilationScope struct {
+	instrs    opcode.Instructions
+	sourceMap opcode.SourceMap
+	last      EmittedInstruction
+	previous  EmittedInstruction
+	loops     []*loop
+}
+
+// moduleScope describes the module whose code is being compiled: either the
+// program itself or a module it imports.
+type moduleScope struct {
+	symbols  *SymbolTable // the names defined at the top level of the module
+	depth    int          // the number of compilation scopes at the top level
+	imported bool
+}
+
+// compiledModule is a module compiled into the program. Modules are compiled
+// once, where they are first imported, and stored in a hidden global.
+type compiledModule struct {
+	slot    Symbol
+	exports map[string]bool
+}
+
 type Compiler struct {
-	instrs opcode.Instructions
-	consts []object.Object
+	consts  []object.Object
+	symbols *SymbolTable
+	scopes  []CompilationScope
+
+	loader  *module.Loader
+	module  *moduleScope
+	modules map[string]compiledModule // keyed by file
+
+	// pos is the position of the node being compiled, which emitted
+	// instructions are attributed to
+	pos token.Position
+
+	// err is the first operand found too large to emit, which Compile returns
+	err error
+}
+
+func defineBuiltins(symbols *SymbolTable) {
+	for idx, def := range object.Builtins {
+		symbols.DefineBuiltin(idx, def.Name)
+	}
 }
 
 func NewCompiler() *Compiler {
+	symbols := NewSymbolTable()
+	defineBuiltins(symbols)
+
 	return &Compiler{
-		instrs: opcode.Instructions{},
-		consts: []object.Object{},
+		consts:  []object.Object{},
+		symbols: symbols,
+		scopes:  []CompilationScope{{instrs: opcode.Instructions{}}},
+		loader:  module.NewLoader(module.SearchPath()...),
+		module:  &moduleScope{symbols: symbols, depth: 1},
+		modules: make(map[string]compiledModule),
 	}
 }
 
-func (c *Compiler) Compile(node ast.Node) error {
+// DefineGlobal defines a global that the host sets before the program runs,
+// returning its index among the globals of the VM.
+func (c *Compiler) DefineGlobal(name string) int {
+	return c.symbols.Define(name).Index
+}
+
+// WithLoader sets the loader used to find imported modules.
+func (c *Compiler) WithLoader(loader *module.Loader) *Compiler {
+	c.loader = loader
+	return c
+}
+
+func (c *Compiler) scope() *CompilationScope {
+	return &c.scopes[len(c.scopes)-1]
+}
+
+func (c *Compiler) currentInstrs() opcode.Instructions {
+	return c.scope().instrs
+}
+
+func (c *Compiler) enterScope() {
+	c.scopes = append(c.scopes, CompilationScope{instrs: opcode.Instructions{}})
+	c.symbols = NewEnclosedSymbolTable(c.symbols)
+}
+
+// enterBlock opens a new scope for the names defined in a block.
+func (c *Compiler) enterBlock() {
+	c.symbols = NewBlockSymbolTable(c.symbols)
+}
+
+func (c *Compiler) leaveBlock() {
+	c.symbols = c.symbols.Outer
+}
+
+func (c *Compiler) leaveScope() (opcode.Instructions, opcode.SourceMap) {
+	scope := c.scope()
+
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.symbols = c.symbols.Outer
+
+	return scope.instrs, scope.sourceMap
+}
+
+func (c *Compiler) addConst(obj object.Object) int {
+	c.consts = append(c.consts, obj)
+	return len(c.consts) - 1
+}
+
+// emit appends an instruction to the current scope and returns its position.
+func (c *Compiler) emit(code opcode.OpCode, operands ...int) int {
+	operands = c.checkOperands(code, operands)
+	instr := opcode.NewInstruction(code, operands)
+
+	scope := c.scope()
+	pos := len(scope.instrs)
+	scope.instrs = append(scope.instrs, instr...)
+
+	if n := len(scope.sourceMap); n == 0 || scope.sourceMap[n-1].Pos != c.pos {
+		scope.sourceMap = append(scope.sourceMap, opcode.SourceMapping{Offset: pos, Pos: c.pos})
+	}
+
+	scope.previous = scope.last
+	scope.last = EmittedInstruction{OpCode: code, Position: pos}
+
+	return pos
+}
+
+func (c *Compiler) lastInstructionIs(code opcode.OpCode) bool {
+	scope := c.scope()
+	if len(scope.instrs) == 0 {
+		return false
+	}
+
+	return scope.last.OpCode == code
+}
+
+func (c *Compiler) removeLastPop() {
+	scope := c.scope()
+	scope.instrs = scope.instrs[:scope.last.Position]
+	scope.last = scope.previous
+
+	n := len(scope.sourceMap)
+	for n > 0 && scope.sourceMap[n-1].Offset >= len(scope.instrs) {
+		n -= 1
+	}
+	scope.sourceMap = scope.sourceMap[:n]
+}
+
+func (c *Compiler) replaceLastPopWithReturn() {
+	scope := c.scope()
+	pos := scope.last.Position
+	copy(scope.instrs[pos:], opcode.NewInstruction(opcode.OpReturnValue, nil))
+	scope.last.OpCode = opcode.OpReturnValue
+}
+
+// changeOperand rewrites the operand of the instruction at the position, which
+// is how jumps are pointed at targets emitted after them.
+func (c *Compiler) changeOperand(pos int, operand int) {
+	code := opcode.OpCode(c.currentInstrs()[pos])
+	operands := c.checkOperands(code, []int{operand})
+	copy(c.currentInstrs()[pos:], opcode.NewInstruction(code, operands))
+}
+
+// checkOperands records an error for the first operand too large for its
+// width, replacing the operands with zeros so that they can still be encoded.
+func (c *Compiler) checkOperands(code opcode.OpCode, operands []int) []int {
+	for i, width := range code.OperandWidth() {
+		if i < len(operands) && operands[i] > opcode.MaxOperand(width) {
+			if c.err == nil {
+				c.err = ErrOperandTooLarge{pos: c.pos, code: code, index: i, operand: operands[i]}
+			}
+
+			return make([]int, len(operands))
+		}
+	}
+
+	return operands
+}
+
+func (c *Compiler) loadSymbol(sym Symbol) {
+	switch sym.Scope {
+	case SCOPE_GLOBAL:
+		c.emit(opcode.OpGetGlobal, sym.Index)
+	case SCOPE_LOCAL:
+		c.emit(opcode.OpGetLocal, sym.Index)
+	case SCOPE_BUILTIN:
+		c.emit(opcode.OpGetBuiltin, sym.Index)
+	case SCOPE_FREE:
+		c.emit(opcode.OpGetFree, sym.Index)
+	case SCOPE_FUNCTION:
+		c.emit(opcode.OpCurrentClosure)
+	}
+}
+
+func (c *Compiler) storeSymbol(sym Symbol) {
+	switch sym.Scope {
+	case SCOPE_GLOBAL:
+		c.emit(opcode.OpSetGlobal, sym.Index)
+	case SCOPE_LOCAL:
+		c.emit(opcode.OpSetLocal, sym.Index)
+	case SCOPE_FREE:
+		c.emit(opcode.OpSetFree, sym.Index)
+	}
+}
+
+// captureSymbol loads the variable that a closure captures, sharing it with
+// the closure rather than copying its value so that either can assign it.
+func (c *Compiler) captureSymbol(sym Symbol) {
+	switch sym.Scope {
+	case SCOPE_LOCAL:
+		c.emit(opcode.OpCaptureLocal, sym.Index)
+	case SCOPE_FREE:
+		c.emit(opcode.OpCaptureFree, sym.Index)
+	default:
+		c.loadSymbol(sym)
+	}
+}
+
+// binding is a name defined by a statement.
+type binding struct {
+	sym   Symbol
+	fresh bool // whether the name is new to its scope
+}
+
+// define defines the name in the current scope. A name new to the scope gets
+// fresh storage each time its definition runs, so that closures keep the
+// variable they captured, such as one from an earlier iteration of a loop,
+// while redefining a name assigns its existing storage.
+func (c *Compiler) define(name string) binding {
+	fresh := !c.symbols.Defines(name)
+	return binding{sym: c.symbols.Define(name), fresh: fresh}
+}
+
+// bind stores the value on top of the stack in the binding.
+func (c *Compiler) bind(b binding) {
+	if b.fresh && b.sym.Scope == SCOPE_LOCAL {
+		c.emit(opcode.OpDefineLocal, b.sym.Index)
+		return
+	}
+
+	c.storeSymbol(b.sym)
+}
+
+// compileScopedBlock compiles the block in a scope of its own.
+func (c *Compiler) compileScopedBlock(block *ast.BlockStatement) error {
+	c.enterBlock()
+	defer c.leaveBlock()
+
+	return c.Compile(block)
+}
+
+// compileBranch compiles the block of a conditional so that it leaves exactly
+// one value on the stack.
+func (c *Compiler) compileBranch(block *ast.BlockStatement) error {
+	if err := c.compileScopedBlock(block); err != nil {
+		return err
+	}
+
+	if c.lastInstructionIs(opcode.OpPop) {
+		c.removeLastPop()
+	} else {
+		c.emit(opcode.OpNull)
+	}
+
+	return nil
+}
+
+// infixOpcodes maps the binary operators to the instructions that apply them.
+var infixOpcodes = map[string]opcode.OpCode{
+	"+":  opcode.OpAdd,
+	"-":  opcode.OpSub,
+	"*":  opcode.OpMul,
+	"/":  opcode.OpDiv,
+	"%":  opcode.OpMod,
+	"**": opcode.OpPow,
+	"&":  opcode.OpBitAnd,
+	"|":  opcode.OpBitOr,
+	"^":  opcode.OpBitXor,
+	"<<": opcode.OpShiftLeft,
+	">>": opcode.OpShiftRight,
+	">":  opcode.OpGreaterThan,
+	">=": opcode.OpGreaterEqual,
+	"<":  opcode.OpLessThan,
+	"<=": opcode.OpLessEqual,
+	"==": opcode.OpEqual,
+	"!=": opcode.OpNotEqual,
+}
+
+func (c *Compiler) compileInfix(node *ast.InfixExpression) error {
+	switch node.Operator {
+	case "&&", "||":
+		return c.compileLogical(node)
+	case "??":
+		return c.compileNullish(node)
+	}
+
+	if err := c.Compile(node.Left); err != nil {
+		return err
+	}
+
+	if err := c.Compile(node.Right); err != nil {
+		return err
+	}
+
+	code, ok := infixOpcodes[node.Operator]
+	if !ok {
+		return ErrUnknownOperator{node: node, operator: node.Operator}
+	}
+
+	c.emit(code)
+	return nil
+}
+
+// compileLogical compiles `&&` and `||`, which only evaluate their right
+// operand if the left one does not decide the result.
+func (c *Compiler) compileLogical(node *ast.InfixExpression) error {
+	if err := c.Compile(node.Left); err != nil {
+		return err
+	}
+
+	// a && b jumps to false if a is falsy, while a || b jumps on to b
+	leftFalsy := c.emit(opcode.OpJumpNotTruthy, placeholder)
+
+	var shortCircuit int
+	if node.Operator == "||" {
+		c.emit(opcode.OpTrue)
+		shortCircuit = c.emit(opcode.OpJump, placeholder)
+		c.changeOperand(leftFalsy, len(c.currentInstrs()))
+	}
+
+	if err := c.Compile(node.Right); err != nil {
+		return err
+	}
+
+	rightFalsy := c.emit(opcode.OpJumpNotTruthy, placeholder)
+	c.emit(opcode.OpTrue)
+	end := c.emit(opcode.OpJump, placeholder)
+
+	falsy := len(c.currentInstrs())
+	c.emit(opcode.OpFalse)
+
+	if node.Operator == "&&" {
+		c.changeOperand(leftFalsy, falsy)
+	} else {
+		c.changeOperand(shortCircuit, len(c.currentInstrs()))
+	}
+	c.changeOperand(rightFalsy, falsy)
+	c.changeOperand(end, len(c.currentInstrs()))
+
+	return nil
+}
+
+// compileChain compiles a chain of property lookups, indexes and calls, such
+// as a?.b[c](d). An optional link that finds null jumps to the end of the
+// chain, skipping the links after it, so that the whole chain gives null.
+func (c *Compiler) compileChain(node ast.Expression) error {
+	var nulls []int
+	if err := c.compileLink(node, &nulls); err != nil {
+		return err
+	}
+
+	for _, null := range nulls {
+		c.changeOperand(null, len(c.currentInstrs()))
+	}
+
+	return nil
+}
+
+// compileLink compiles a link of a chain, adding the jumps its optional links
+// take on null to nulls.
+func (c *Compiler) compileLink(node ast.Expression, nulls *[]int) error {
+	switch node.(type) {
+	case *ast.GetExpression, *ast.IndexExpression, *ast.CallExpression:
+	default:
+		return c.Compile(node)
+	}
+
+	if pos := node.Pos(); pos.IsValid() {
+		prev := c.pos
+		c.pos = pos
+		defer func() { c.pos = prev }()
+	}
+
+	switch node := node.(type) {
+	case *ast.GetExpression:
+		prop, ok := node.Right.(*ast.Identifier)
+		if !ok {
+			return ErrUnsupportedNode{node: node.Right}
+		}
+
+		if err := c.compileLink(node.Left, nulls); err != nil {
+			return err
+		}
+
+		name := c.addConst(object.NewStringObject(prop.Value))
+		if !node.Optional() {
+			c.emit(opcode.OpGetProperty, name)
+			break
+		}
+
+		*nulls = append(*nulls, c.emit(opcode.OpJumpNull, placeholder))
+		c.emit(opcode.OpOptionalProperty, name)
+	case *ast.IndexExpression:
+		if err := c.compileLink(node.Left, nulls); err != nil {
+			return err
+		}
+
+		if !node.Optional() {
+			if err := c.Compile(node.Index); err != nil {
+				return err
+			}
+
+			c.emit(opcode.OpIndex)
+			break
+		}
+
+		// the index is not evaluated if the left operand is null
+		*nulls = append(*nulls, c.emit(opcode.OpJumpNull, placeholder))
+		if err := c.Compile(node.Index); err != nil {
+			return err
+		}
+
+		c.emit(opcode.OpOptionalIndex)
+	case *ast.CallExpression:
+		if err := c.compileLink(node.Function, nulls); err != nil {
+			return err
+		}
+
+		for _, arg := range node.Arguments {
+			if err := c.Compile(arg); err != nil {
+				return err
+			}
+		}
+
+		c.emit(opcode.OpCall, len(node.Arguments))
+	}
+
+	return nil
+}
+
+// compileNullish compiles a ?? b, which only evaluates b if a is null.
+func (c *Compiler) compileNullish(node *ast.InfixExpression) error {
+	if err := c.Compile(node.Left); err != nil {
+		return err
+	}
+
+	null := c.emit(opcode.OpJumpNull, placeholder)
+	end := c.emit(opcode.OpJump, placeholder)
+
+	c.changeOperand(null, len(c.currentInstrs()))
+	c.emit(opcode.OpPop)
+
+	if err := c.Compile(node.Right); err != nil {
+		return err
+	}
+
+	c.changeOperand(end, len(c.currentInstrs()))
+	return nil
+}
+
+func (c *Compiler) compileIf(node *ast.IfExpression) error {
+	if err := c.Compile(node.Condition); err != nil {
+		return err
+	}
+
+	jumpNotTruthy := c.emit(opcode.OpJumpNotTruthy, placeholder)
+
+	if err := c.compileBranch(node.Consequence); err != nil {
+		return err
+	}
+
+	jump := c.emit(opcode.OpJump, placeholder)
+	c.changeOperand(jumpNotTruthy, len(c.currentInstrs()))
+
+	if node.Alternative == nil {
+		c.emit(opcode.OpNull)
+	} else if err := c.compileBranch(node.Alternative); err != nil {
+		return err
+	}
+
+	c.changeOperand(jump, len(c.currentInstrs()))
+	return nil
+}
+
+// compileMatch compiles a match expression, which keeps the subject on the
+// stack until one of its arms is chosen. Each arm stores the values bound by
+// its pattern before testing its guard.
+func (c *Compiler) compileMatch(node *ast.MatchExpression) error {
+	if err := c.Compile(node.Subject); err != nil {
+		return err
+	}
+
+	var ends []int
+	for _, arm := range node.Arms {
+		if cls := classPattern(arm.Pattern); cls != nil {
+			// the VM has no classes to match instances of
+			return ErrUnsupportedNode{node: cls}
+		}
+
+		c.emit(opcode.OpDup, 1)
+		c.emit(opcode.OpMatch, c.addConst(&object.Pattern{Pattern: arm.Pattern}))
+		skips := []int{c.emit(opcode.OpJumpNotTruthy, placeholder)}
+
+		c.enterBlock()
+
+		names := ast.Bindings(arm.Pattern)
+		bindings := make([]binding, len(names))
+		for i, name := range names {
+			bindings[i] = c.define(name.Value)
+		}
+
+		// the last value bound is on top of the stack
+		for i := len(bindings) - 1; i >= 0; i-- {
+			c.bind(bindings[i])
+		}
+
+		if arm.Guard != nil {
+			if err := c.Compile(arm.Guard); err != nil {
+				return err
+			}
+
+			skips = append(skips, c.emit(opcode.OpJumpNotTruthy, placeholder))
+		}
+
+		c.emit(opcode.OpPop) // discard the subject
+
+		var err error
+		if body, ok := arm.Body.(*ast.ExpressionStatement); ok {
+			err = c.Compile(body.Expression)
+		} else {
+			err = c.compileBranch(arm.Body.(*ast.BlockStatement))
+		}
+		if err != nil {
+			return err
+		}
+
+		c.leaveBlock()
+
+		ends = append(ends, c.emit(opcode.OpJump, placeholder))
+		for _, skip := range skips {
+			c.changeOperand(skip, len(c.currentInstrs()))
+		}
+	}
+
+	// no arm matched
+	c.emit(opcode.OpPop)
+	c.emit(opcode.OpNull)
+
+	for _, end := range ends {
+		c.changeOperand(end, len(c.currentInstrs()))
+	}
+
+	return nil
+}
+
+// compileDestructure compiles a let statement that binds the names in its
+// pattern to the parts of its value.
+func (c *Compiler) compileDestructure(node *ast.LetStatement) error {
+	if cls := classPattern(node.Pattern); cls != nil {
+		// the VM has no classes to match instances of
+		return ErrUnsupportedNode{node: cls}
+	}
+
+	if err := c.Compile(node.Value); err != nil {
+		return err
+	}
+
+	c.emit(opcode.OpDestructure, c.addConst(&object.Pattern{Pattern: node.Pattern}))
+
+	names := ast.Bindings(node.Pattern)
+	bindings := make([]binding, len(names))
+	for i, name := range names {
+		bindings[i] = c.define(name.Value)
+	}
+
+	// the last value bound is on top of the stack
+	for i := len(bindings) - 1; i >= 0; i-- {
+		c.bind(bindings[i])
+	}
+
+	return nil
+}
+
+// classPattern returns the first class pattern within the pattern, if any.
+func classPattern(pat ast.Pattern) ast.Pattern {
+	switch pat := pat.(type) {
+	case *ast.ClassPattern:
+		return pat
+	case *ast.ListPattern:
+		for _, elem := range pat.Elems {
+			if cls := classPattern(elem); cls != nil {
+				return cls
+			}
+		}
+	case *ast.MapPattern:
+		for _, val := range pat.Values {
+			if cls := classPattern(val); cls != nil {
+				return cls
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Compiler) compileAssignment(node *ast.AssignmentExpression) error {
+	var code opcode.OpCode
+	if node.Operator() != "" {
+		var ok bool
+		if code, ok = infixOpcodes[node.Operator()]; !ok {
+			return ErrUnknownOperator{node: node, operator: node.Token.Literal}
+		}
+	}
+
+	switch left := node.Left.(type) {
+	case *ast.Identifier:
+		sym, ok := c.symbols.Resolve(left.Value)
+		if !ok {
+			return ErrUndefinedVariable{ident: left}
+		}
+
+		if origin := c.symbols.Origin(sym); origin.Scope != SCOPE_GLOBAL && origin.Scope != SCOPE_LOCAL {
+			return ErrInvalidAssignment{node: left}
+		}
+
+		if node.Operator() != "" {
+			c.loadSymbol(sym)
+		}
+
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+
+		if node.Operator() != "" {
+			c.emit(code)
+		}
+
+		// assignment is an expression, so leave the assigned value on the stack
+		c.storeSymbol(sym)
+		c.loadSymbol(sym)
+	case *ast.IndexExpression:
+		if err := c.Compile(left.Left); err != nil {
+			return err
+		}
+
+		if err := c.Compile(left.Index); err != nil {
+			return err
+		}
+
+		if node.Operator() != "" {
+			// keep the list and index to assign to under the current value
+			c.emit(opcode.OpDup, 2)
+			c.emit(opcode.OpIndex)
+		}
+
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+
+		if node.Operator() != "" {
+			c.emit(code)
+		}
+
+		c.emit(opcode.OpSetIndex)
+	case *ast.GetExpression:
+		prop, ok := left.Right.(*ast.Identifier)
+		if !ok || left.Optional() {
+			return ErrUnsupportedNode{node: node.Left}
+		}
+
+		if err := c.Compile(left.Left); err != nil {
+			return err
+		}
+
+		name := c.addConst(object.NewStringObject(prop.Value))
+		if node.Operator() != "" {
+			// keep the object to assign to under the current value
+			c.emit(opcode.OpDup, 1)
+			c.emit(opcode.OpGetProperty, name)
+		}
+
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+
+		if node.Operator() != "" {
+			c.emit(code)
+		}
+
+		c.emit(opcode.OpSetProperty, name)
+	default:
+		return ErrUnsupportedNode{node: node.Left}
+	}
+
+	return nil
+}
+
+func (c *Compiler) compileMap(node *ast.MapLiteral) error {
+	keys := make([]ast.Expression, 0, len(node.Entries))
+	for k := range node.Entries {
+		keys = append(keys, k)
+	}
+
+	// emit the entries in a stable order
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	for _, k := range keys {
+		if err := c.Compile(k); err != nil {
+			return err
+		}
+
+		if err := c.Compile(node.Entries[k]); err != nil {
+			return err
+		}
+	}
+
+	c.emit(opcode.OpMap, len(node.Entries)*2)
 	return nil
 }
 
+func (c *Compiler) compileFunction(name string, params []*ast.Identifier, body *ast.BlockStatement) error {
+	c.enterScope()
+
+	if name != "" {
+		c.symbols.DefineFunctionName(name)
+	}
+
+	for _, param := range params {
+		c.symbols.Define(param.Value)
+	}
+
+	if err := c.Compile(body); err != nil {
+		return err
+	}
+
+	if c.lastInstructionIs(opcode.OpPop) {
+		c.replaceLastPopWithReturn()
+	}
+
+	if !c.lastInstructionIs(opcode.OpReturnValue) {
+		c.emit(opcode.OpReturn)
+	}
+
+	free := c.symbols.FreeSymbols
+	numLocals := c.symbols.NumDefinitions()
+	instrs, sourceMap := c.leaveScope()
+
+	for _, sym := range free {
+		c.captureSymbol(sym)
+	}
+
+	fn := &object.CompiledFunction{
+		Name:      name,
+		Instrs:    instrs,
+		NumLocals: numLocals,
+		NumParams: len(params),
+		SourceMap: sourceMap,
+	}
+
+	c.emit(opcode.OpClosure, c.addConst(fn), len(free))
+	return nil
+}
+
+func (c *Compiler) compileImport(node *ast.ImportStatement) error {
+	if c.symbols != c.module.symbols {
+		return ErrNotTopLevel{node: node}
+	}
+
+	file, err := c.loader.Resolve(node.Path.Value, node.Pos())
+	if err != nil {
+		return ErrImport{node: node, err: err}
+	}
+
+	mod, ok := c.modules[file]
+	if !ok {
+		if mod, err = c.compileModule(node, file); err != nil {
+			return err
+		}
+	}
+
+	if node.Alias != nil {
+		c.loadSymbol(mod.slot)
+		c.bind(c.define(node.Alias.Value))
+	}
+
+	for _, name := range node.Names {
+		if !mod.exports[name.Name.Value] {
+			return ErrNoExport{node: node, export: name.Name}
+		}
+
+		c.loadSymbol(mod.slot)
+		c.emit(opcode.OpGetProperty, c.addConst(object.NewStringObject(name.Name.Value)))
+		c.bind(c.define(name.Binding().Value))
+	}
+
+	return nil
+}
+
+// compileModule compiles the top level of an imported module in place,
+// followed by instructions that store the module object in a hidden global.
+func (c *Compiler) compileModule(node *ast.ImportStatement, file string) (compiledModule, error) {
+	if err := c.loader.Enter(file); err != nil {
+		return compiledModule{}, ErrImport{node: node, err: err}
+	}
+	defer c.loader.Leave(file)
+
+	prog, err := c.loader.Parse(file)
+	if err != nil {
+		return compiledModule{}, ErrImport{node: node, err: err}
+	}
+
+	outer, outerSymbols := c.module, c.symbols
+	program := c.symbols.owner()
+
+	c.symbols = NewModuleSymbolTable(program)
+	defineBuiltins(c.symbols)
+	c.module = &moduleScope{symbols: c.symbols, depth: len(c.scopes), imported: true}
+
+	if err := c.Compile(prog); err != nil {
+		return compiledModule{}, err
+	}
+
+	names := module.Exports(prog)
+	exports := make(map[string]bool, len(names))
+
+	c.emit(opcode.OpConstant, c.addConst(object.NewStringObject(node.Path.Value)))
+	for _, name := range names {
+		sym, _ := c.symbols.Resolve(name.Value)
+
+		c.emit(opcode.OpConstant, c.addConst(object.NewStringObject(name.Value)))
+		c.loadSymbol(sym)
+		exports[name.Value] = true
+	}
+	c.emit(opcode.OpModule, len(names))
+
+	c.module, c.symbols = outer, outerSymbols
+
+	// the name of the slot is not a valid identifier, so it cannot be shadowed
+	mod := compiledModule{slot: program.Define("module " + file), exports: exports}
+	c.storeSymbol(mod.slot)
+	c.modules[file] = mod
+
+	return mod, nil
+}
+
+// enterLoop starts a loop by marking the height of the stack, so that `break`
+// and `continue` can discard the values left on it by the expressions they are
+// nested in.
+func (c *Compiler) enterLoop() *loop {
+	c.enterBlock()
+
+	// the name of the mark is not a valid identifier, so it cannot be shadowed
+	l := &loop{mark: c.symbols.Define("loop mark")}
+	c.emit(opcode.OpMark, l.mark.Index)
+
+	return l
+}
+
+// compileLoopBody compiles the body of the loop, which the `break` and
+// `continue` statements within it apply to.
+func (c *Compiler) compileLoopBody(l *loop, block *ast.BlockStatement) error {
+	c.scope().loops = append(c.scope().loops, l)
+	err := c.compileScopedBlock(block)
+
+	// compiling a function may have moved the scope
+	scope := c.scope()
+	scope.loops = scope.loops[:len(scope.loops)-1]
+
+	return err
+}
+
+// leaveLoop patches the jumps of the loop and discards it. Loops are
+// statements, so they leave a null value behind to be popped like any other.
+func (c *Compiler) leaveLoop(l *loop, continueTarget, breakTarget int) {
+	for _, pos := range l.continues {
+		c.changeOperand(pos, continueTarget)
+	}
+
+	for _, pos := range l.breaks {
+		c.changeOperand(pos, breakTarget)
+	}
+
+	c.leaveBlock()
+}
+
+func (c *Compiler) compileWhile(node *ast.WhileStatement) error {
+	l := c.enterLoop()
+	start := len(c.currentInstrs())
+
+	if err := c.Compile(node.Condition); err != nil {
+		return err
+	}
+
+	exit := c.emit(opcode.OpJumpNotTruthy, placeholder)
+
+	if err := c.compileLoopBody(l, node.Block); err != nil {
+		return err
+	}
+
+	c.emit(opcode.OpJump, start)
+
+	end := len(c.currentInstrs())
+	c.changeOperand(exit, end)
+	c.leaveLoop(l, start, end)
+
+	c.emit(opcode.OpNull)
+	c.emit(opcode.OpPop)
+	return nil
+}
+
+func (c *Compiler) compileFor(node *ast.ForStatement) error {
+	// the loop variables are scoped to the loop
+	c.enterBlock()
+	defer c.leaveBlock()
+
+	if node.Init != nil {
+		if err := c.Compile(node.Init); err != nil {
+			return err
+		}
+	}
+
+	l := c.enterLoop()
+	start := len(c.currentInstrs())
+
+	exit := -1
+	if node.Condition != nil {
+		if err := c.Compile(node.Condition); err != nil {
+			return err
+		}
+
+		exit = c.emit(opcode.OpJumpNotTruthy, placeholder)
+	}
+
+	if err := c.compileLoopBody(l, node.Block); err != nil {
+		return err
+	}
+
+	post := len(c.currentInstrs())
+	if node.Post != nil {
+		if err := c.Compile(node.Post); err != nil {
+			return err
+		}
+
+		c.emit(opcode.OpPop)
+	}
+
+	c.emit(opcode.OpJump, start)
+
+	end := len(c.currentInstrs())
+	if exit >= 0 {
+		c.changeOperand(exit, end)
+	}
+	c.leaveLoop(l, post, end)
+
+	c.emit(opcode.OpNull)
+	c.emit(opcode.OpPop)
+	return nil
+}
+
+func (c *Compiler) compileForIn(node *ast.ForInStatement) error {
+	if err := c.Compile(node.Iterable); err != nil {
+		return err
+	}
+
+	// the iterator stays on the stack for the duration of the loop
+	c.emit(opcode.OpIter)
+
+	l := c.enterLoop()
+	next := c.emit(opcode.OpIterNext, placeholder)
+
+	c.enterBlock()
+	c.bind(c.define(node.Var.Value))
+
+	if err := c.compileLoopBody(l, node.Block); err != nil {
+		return err
+	}
+	c.leaveBlock()
+
+	c.emit(opcode.OpJump, next)
+
+	end := len(c.currentInstrs())
+	c.changeOperand(next, end)
+	c.leaveLoop(l, next, end)
+
+	c.emit(opcode.OpPop) // discard the iterator
+	c.emit(opcode.OpNull)
+	c.emit(opcode.OpPop)
+	return nil
+}
+
+// compileLoopJump emits the jump for a `break` or `continue` statement and
+// records it on the innermost loop to be patched later.
+func (c *Compiler) compileLoopJump(node ast.Node) error {
+	loops := c.scope().loops
+	if len(loops) == 0 {
+		return ErrOutsideLoop{node: node}
+	}
+
+	l := loops[len(loops)-1]
+	c.emit(opcode.OpUnwind, l.mark.Index)
+	pos := c.emit(opcode.OpJump, placeholder)
+
+	if _, ok := node.(*ast.BreakStatement); ok {
+		l.breaks = append(l.breaks, pos)
+	} else {
+		l.continues = append(l.continues, pos)
+	}
+
+	return nil
+}
+
+func (c *Compiler) Compile(node ast.Node) error {
+	if pos := node.Pos(); pos.IsValid() {
+		prev := c.pos
+		c.pos = pos
+		defer func() { c.pos = prev }()
+	}
+
+	switch node := node.(type) {
+	case *ast.Program:
+		for _, stmt := range node.Statements {
+			if err := c.Compile(stmt); err != nil {
+				return err
+			}
+		}
+	case *ast.BlockStatement:
+		for _, stmt := range node.Statements {
+			if err := c.Compile(stmt); err != nil {
+				return err
+			}
+		}
+	case *ast.ExpressionStatement:
+		if err := c.Compile(node.Expression); err != nil {
+			return err
+		}
+
+		c.emit(opcode.OpPop)
+	case *ast.IntegerLiteral:
+		c.emit(opcode.OpConstant, c.addConst(object.NewIntegerObject(node.Value)))
+	case *ast.FloatLiteral:
+		c.emit(opcode.OpConstant, c.addConst(object.NewFloatObject(node.Value)))
+	case *ast.StringLiteral:
+		c.emit(opcode.OpConstant, c.addConst(object.NewStringObject(node.Value)))
+	case *ast.NullLiteral:
+		c.emit(opcode.OpNull)
+	case *ast.Boolean:
+		if node.Value {
+			c.emit(opcode.OpTrue)
+		} else {
+			c.emit(opcode.OpFalse)
+		}
+	case *ast.PrefixExpression:
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+
+		switch node.Operator {
+		case "!":
+			c.emit(opcode.OpBang)
+		case "-":
+			c.emit(opcode.OpMinus)
+		case "~":
+			c.emit(opcode.OpBitNot)
+		default:
+			return ErrUnknownOperator{node: node, operator: node.Operator}
+		}
+	case *ast.InfixExpression:
+		return c.compileInfix(node)
+	case *ast.IfExpression:
+		return c.compileIf(node)
+	case *ast.MatchExpression:
+		return c.compileMatch(node)
+	case *ast.LetStatement:
+		if node.Pattern != nil {
+			return c.compileDestructure(node)
+		}
+
+		if fn, ok := node.Value.(*ast.FunctionLiteral); ok {
+			// name the function so that its body can call itself
+			if err := c.compileFunction(node.Name.Value, fn.Parameters, fn.Body); err != nil {
+				return err
+			}
+		} else if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+
+		c.bind(c.define(node.Name.Value))
+	case *ast.Identifier:
+		sym, ok := c.symbols.Resolve(node.Value)
+		if !ok {
+			return ErrUndefinedVariable{ident: node}
+		}
+
+		c.loadSymbol(sym)
+	case *ast.AssignmentExpression:
+		return c.compileAssignment(node)
+	case *ast.ListLiteral:
+		for _, elem := range node.Elems {
+			if err := c.Compile(elem); err != nil {
+				return err
+			}
+		}
+
+		c.emit(opcode.OpList, len(node.Elems))
+	case *ast.MapLiteral:
+		return c.compileMap(node)
+	case *ast.IndexExpression, *ast.GetExpression, *ast.CallExpression:
+		return c.compileChain(node.(ast.Expression))
+	case *ast.FunctionLiteral:
+		return c.compileFunction("", node.Parameters, node.Body)
+	case *ast.FunctionStatement:
+		if err := c.compileFunction(node.Name.Value, node.Parameters, node.Body); err != nil {
+			return err
+		}
+
+		c.bind(c.define(node.Name.Value))
+	case *ast.ReturnStatement:
+		if c.module.imported && len(c.scopes) == c.module.depth {
+			return ErrReturnOutsideFunction{node: node}
+		}
+
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+
+		c.emit(opcode.OpReturnValue)
+	case *ast.WhileStatement:
+		return c.compileWhile(node)
+	case *ast.ForStatement:
+		return c.compileFor(node)
+	case *ast.ForInStatement:
+		return c.compileForIn(node)
+	case *ast.BreakStatement, *ast.ContinueStatement:
+		return c.compileLoopJump(node)
+	case *ast.ImportStatement:
+		return c.compileImport(node)
+	case *ast.ExportStatement:
+		if c.symbols != c.module.symbols {
+			return ErrNotTopLevel{node: node}
+		}
+
+		return c.Compile(node.Statement)
+	default:
+		return ErrUnsupportedNode{node: node}
+	}
+
+	err := c.err
+	c.err = nil
+	return err
+}
+
 func (c *Compiler) Bytecode() *Bytecode {
 	return &Bytecode{
-		Instrs: c.instrs,
-		Consts: c.consts,
+		Instrs:    c.currentInstrs(),
+		Consts:    c.consts,
+		NumLocals: c.symbols.NumLocals(),
+		SourceMap: c.scope().sourceMap,
 	}
 }
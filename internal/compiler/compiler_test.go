@@ -1,15 +1,55 @@
 package compiler_test
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/donovandicks/gomonkey/internal/compiler"
 	"github.com/donovandicks/gomonkey/internal/lexer"
+	"github.com/donovandicks/gomonkey/internal/object"
 	"github.com/donovandicks/gomonkey/internal/opcode"
 	"github.com/donovandicks/gomonkey/internal/parser"
 	"github.com/stretchr/testify/assert"
 )
 
+func ins(code opcode.OpCode, operands ...int) opcode.Instructions {
+	return opcode.NewInstruction(code, operands)
+}
+
+func concat(instrs []opcode.Instructions) opcode.Instructions {
+	out := opcode.Instructions{}
+	for _, instr := range instrs {
+		out = append(out, instr...)
+	}
+
+	return out
+}
+
+func assertConsts(t *testing.T, expected []interface{}, actual []object.Object) {
+	t.Helper()
+
+	if !assert.Len(t, actual, len(expected), "wrong number of constants") {
+		return
+	}
+
+	for i, exp := range expected {
+		switch exp := exp.(type) {
+		case int:
+			assert.Equal(t, object.NewIntegerObject(int64(exp)), actual[i])
+		case float64:
+			assert.Equal(t, object.NewFloatObject(exp), actual[i])
+		case string:
+			assert.Equal(t, object.NewStringObject(exp), actual[i])
+		case []opcode.Instructions:
+			fn, ok := actual[i].(*object.CompiledFunction)
+			if assert.True(t, ok, "constant %d is not a function: %T", i, actual[i]) {
+				assert.Equal(t, concat(exp).String(), fn.Instrs.String(), "constant %d", i)
+			}
+		}
+	}
+}
+
 func TestCompiler(t *testing.T) {
 	t.Parallel()
 
@@ -23,11 +63,489 @@ func TestCompiler(t *testing.T) {
 			name:  "add constants",
 			input: "1 + 2",
 			expectedInstrs: []opcode.Instructions{
-				opcode.NewInstruction(opcode.OpConstant, []int{0}),
-				opcode.NewInstruction(opcode.OpConstant, []int{1}),
+				ins(opcode.OpConstant, 0),
+				ins(opcode.OpConstant, 1),
+				ins(opcode.OpAdd),
+				ins(opcode.OpPop),
+			},
+			expectedConsts: []interface{}{1, 2},
+		},
+		{
+			name:  "arithmetic",
+			input: "1 - 2; 1.5 * 2; 6 / 3; -1",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpConstant, 0),
+				ins(opcode.OpConstant, 1),
+				ins(opcode.OpSub),
+				ins(opcode.OpPop),
+				ins(opcode.OpConstant, 2),
+				ins(opcode.OpConstant, 3),
+				ins(opcode.OpMul),
+				ins(opcode.OpPop),
+				ins(opcode.OpConstant, 4),
+				ins(opcode.OpConstant, 5),
+				ins(opcode.OpDiv),
+				ins(opcode.OpPop),
+				ins(opcode.OpConstant, 6),
+				ins(opcode.OpMinus),
+				ins(opcode.OpPop),
+			},
+			expectedConsts: []interface{}{1, 2, 1.5, 2, 6, 3, 1},
+		},
+		{
+			name:  "operators",
+			input: "1 <= 2; 3 % 2; ~1",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpConstant, 0),
+				ins(opcode.OpConstant, 1),
+				ins(opcode.OpLessEqual),
+				ins(opcode.OpPop),
+				ins(opcode.OpConstant, 2),
+				ins(opcode.OpConstant, 3),
+				ins(opcode.OpMod),
+				ins(opcode.OpPop),
+				ins(opcode.OpConstant, 4),
+				ins(opcode.OpBitNot),
+				ins(opcode.OpPop),
+			},
+			expectedConsts: []interface{}{1, 2, 3, 2, 1},
+		},
+		{
+			name:  "compound assignment",
+			input: "let x = 1; x *= 2",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpConstant, 0),
+				ins(opcode.OpSetGlobal, 0),
+				ins(opcode.OpGetGlobal, 0),
+				ins(opcode.OpConstant, 1),
+				ins(opcode.OpMul),
+				ins(opcode.OpSetGlobal, 0),
+				ins(opcode.OpGetGlobal, 0),
+				ins(opcode.OpPop),
+			},
+			expectedConsts: []interface{}{1, 2},
+		},
+		{
+			name:  "index assignment",
+			input: "let xs = [1]; xs[0] += 2",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpConstant, 0),
+				ins(opcode.OpList, 1),
+				ins(opcode.OpSetGlobal, 0),
+				ins(opcode.OpGetGlobal, 0),
+				ins(opcode.OpConstant, 1),
+				ins(opcode.OpDup, 2),
+				ins(opcode.OpIndex),
+				ins(opcode.OpConstant, 2),
+				ins(opcode.OpAdd),
+				ins(opcode.OpSetIndex),
+				ins(opcode.OpPop),
+			},
+			expectedConsts: []interface{}{1, 0, 2},
+		},
+		{
+			name:  "match expression",
+			input: "match (1) { x => x }",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpConstant, 0),
+				ins(opcode.OpDup, 1),
+				ins(opcode.OpMatch, 1),
+				ins(opcode.OpJumpNotTruthy, 19),
+				ins(opcode.OpDefineLocal, 0),
+				ins(opcode.OpPop),
+				ins(opcode.OpGetLocal, 0),
+				ins(opcode.OpJump, 21),
+				ins(opcode.OpPop),
+				ins(opcode.OpNull),
+				ins(opcode.OpPop),
+			},
+			expectedConsts: []interface{}{1, nil}, // the second is the pattern
+		},
+		{
+			name:  "destructuring let statement",
+			input: "let [a, b] = [1, 2];",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpConstant, 0),
+				ins(opcode.OpConstant, 1),
+				ins(opcode.OpList, 2),
+				ins(opcode.OpDestructure, 2),
+				ins(opcode.OpSetGlobal, 1),
+				ins(opcode.OpSetGlobal, 0),
+			},
+			expectedConsts: []interface{}{1, 2, nil}, // the third is the pattern
+		},
+		{
+			name:  "null coalescing",
+			input: "null ?? 1",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpNull),
+				ins(opcode.OpJumpNull, 7),
+				ins(opcode.OpJump, 11),
+				ins(opcode.OpPop),
+				ins(opcode.OpConstant, 0),
+				ins(opcode.OpPop),
+			},
+			expectedConsts: []interface{}{1},
+		},
+		{
+			name:  "optional index",
+			input: "null?.[0]",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpNull),
+				ins(opcode.OpJumpNull, 8),
+				ins(opcode.OpConstant, 0),
+				ins(opcode.OpOptionalIndex),
+				ins(opcode.OpPop),
+			},
+			expectedConsts: []interface{}{0},
+		},
+		{
+			name:  "optional chain",
+			input: "null?.a.b",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpNull),
+				ins(opcode.OpJumpNull, 10),
+				ins(opcode.OpOptionalProperty, 0),
+				ins(opcode.OpGetProperty, 1),
+				ins(opcode.OpPop),
+			},
+			expectedConsts: []interface{}{"a", "b"},
+		},
+		{
+			name:  "logical and",
+			input: "true && false",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpTrue),
+				ins(opcode.OpJumpNotTruthy, 12),
+				ins(opcode.OpFalse),
+				ins(opcode.OpJumpNotTruthy, 12),
+				ins(opcode.OpTrue),
+				ins(opcode.OpJump, 13),
+				ins(opcode.OpFalse),
+				ins(opcode.OpPop),
+			},
+			expectedConsts: []interface{}{},
+		},
+		{
+			name:  "logical or",
+			input: "true || false",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpTrue),
+				ins(opcode.OpJumpNotTruthy, 8),
+				ins(opcode.OpTrue),
+				ins(opcode.OpJump, 17),
+				ins(opcode.OpFalse),
+				ins(opcode.OpJumpNotTruthy, 16),
+				ins(opcode.OpTrue),
+				ins(opcode.OpJump, 17),
+				ins(opcode.OpFalse),
+				ins(opcode.OpPop),
+			},
+			expectedConsts: []interface{}{},
+		},
+		{
+			name:  "booleans and comparisons",
+			input: "true; !false; 1 > 2; 1 < 2; 1 == 2; true != false",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpTrue),
+				ins(opcode.OpPop),
+				ins(opcode.OpFalse),
+				ins(opcode.OpBang),
+				ins(opcode.OpPop),
+				ins(opcode.OpConstant, 0),
+				ins(opcode.OpConstant, 1),
+				ins(opcode.OpGreaterThan),
+				ins(opcode.OpPop),
+				ins(opcode.OpConstant, 2),
+				ins(opcode.OpConstant, 3),
+				ins(opcode.OpLessThan),
+				ins(opcode.OpPop),
+				ins(opcode.OpConstant, 4),
+				ins(opcode.OpConstant, 5),
+				ins(opcode.OpEqual),
+				ins(opcode.OpPop),
+				ins(opcode.OpTrue),
+				ins(opcode.OpFalse),
+				ins(opcode.OpNotEqual),
+				ins(opcode.OpPop),
+			},
+			expectedConsts: []interface{}{1, 2, 1, 2, 1, 2},
+		},
+		{
+			name:  "conditional without alternative",
+			input: "if (true) { 10 }; 3333;",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpTrue),              // 0000
+				ins(opcode.OpJumpNotTruthy, 10), // 0001
+				ins(opcode.OpConstant, 0),       // 0004
+				ins(opcode.OpJump, 11),          // 0007
+				ins(opcode.OpNull),              // 0010
+				ins(opcode.OpPop),               // 0011
+				ins(opcode.OpConstant, 1),       // 0012
+				ins(opcode.OpPop),               // 0015
+			},
+			expectedConsts: []interface{}{10, 3333},
+		},
+		{
+			name:  "conditional with alternative",
+			input: "if (true) { 10 } else { 20 }",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpTrue),              // 0000
+				ins(opcode.OpJumpNotTruthy, 10), // 0001
+				ins(opcode.OpConstant, 0),       // 0004
+				ins(opcode.OpJump, 13),          // 0007
+				ins(opcode.OpConstant, 1),       // 0010
+				ins(opcode.OpPop),               // 0013
+			},
+			expectedConsts: []interface{}{10, 20},
+		},
+		{
+			name:  "conditional with valueless branch",
+			input: "if (true) { let x = 1; }",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpTrue),              // 0000
+				ins(opcode.OpJumpNotTruthy, 13), // 0001
+				ins(opcode.OpConstant, 0),       // 0004
+				ins(opcode.OpDefineLocal, 0),    // 0007
+				ins(opcode.OpNull),              // 0009
+				ins(opcode.OpJump, 14),          // 0010
+				ins(opcode.OpNull),              // 0013
+				ins(opcode.OpPop),               // 0014
+			},
+			expectedConsts: []interface{}{1},
+		},
+		{
+			name:  "global let statements",
+			input: "let one = 1; let two = one; two = 2;",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpConstant, 0),
+				ins(opcode.OpSetGlobal, 0),
+				ins(opcode.OpGetGlobal, 0),
+				ins(opcode.OpSetGlobal, 1),
+				ins(opcode.OpConstant, 1),
+				ins(opcode.OpSetGlobal, 1),
+				ins(opcode.OpGetGlobal, 1),
+				ins(opcode.OpPop),
 			},
 			expectedConsts: []interface{}{1, 2},
 		},
+		{
+			name:  "strings",
+			input: `"mon" + "key"`,
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpConstant, 0),
+				ins(opcode.OpConstant, 1),
+				ins(opcode.OpAdd),
+				ins(opcode.OpPop),
+			},
+			expectedConsts: []interface{}{"mon", "key"},
+		},
+		{
+			name:  "lists and index",
+			input: "[1, 2][0]",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpConstant, 0),
+				ins(opcode.OpConstant, 1),
+				ins(opcode.OpList, 2),
+				ins(opcode.OpConstant, 2),
+				ins(opcode.OpIndex),
+				ins(opcode.OpPop),
+			},
+			expectedConsts: []interface{}{1, 2, 0},
+		},
+		{
+			name:  "maps",
+			input: `{2: "b", 1: "a"}`,
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpConstant, 0),
+				ins(opcode.OpConstant, 1),
+				ins(opcode.OpConstant, 2),
+				ins(opcode.OpConstant, 3),
+				ins(opcode.OpMap, 4),
+				ins(opcode.OpPop),
+			},
+			expectedConsts: []interface{}{1, "a", 2, "b"},
+		},
+		{
+			name:  "builtins",
+			input: "len([]); print(1)",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpGetBuiltin, 0),
+				ins(opcode.OpList, 0),
+				ins(opcode.OpCall, 1),
+				ins(opcode.OpPop),
+				ins(opcode.OpGetBuiltin, 1),
+				ins(opcode.OpConstant, 0),
+				ins(opcode.OpCall, 1),
+				ins(opcode.OpPop),
+			},
+			expectedConsts: []interface{}{1},
+		},
+		{
+			name:  "functions: implicit return",
+			input: "(fn() { 1 + 2 })",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpClosure, 2, 0),
+				ins(opcode.OpPop),
+			},
+			expectedConsts: []interface{}{1, 2, []opcode.Instructions{
+				ins(opcode.OpConstant, 0),
+				ins(opcode.OpConstant, 1),
+				ins(opcode.OpAdd),
+				ins(opcode.OpReturnValue),
+			}},
+		},
+		{
+			name:  "functions: empty body",
+			input: "(fn() { })",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpClosure, 0, 0),
+				ins(opcode.OpPop),
+			},
+			expectedConsts: []interface{}{[]opcode.Instructions{
+				ins(opcode.OpReturn),
+			}},
+		},
+		{
+			name:  "functions: locals and calls",
+			input: "let f = fn(a) { let b = a; return b; }; f(1);",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpClosure, 0, 0),
+				ins(opcode.OpSetGlobal, 0),
+				ins(opcode.OpGetGlobal, 0),
+				ins(opcode.OpConstant, 1),
+				ins(opcode.OpCall, 1),
+				ins(opcode.OpPop),
+			},
+			expectedConsts: []interface{}{[]opcode.Instructions{
+				ins(opcode.OpGetLocal, 0),
+				ins(opcode.OpDefineLocal, 1),
+				ins(opcode.OpGetLocal, 1),
+				ins(opcode.OpReturnValue),
+			}, 1},
+		},
+		{
+			name:  "functions: closures",
+			input: "(fn(a) { return fn(b) { a + b }; })",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpClosure, 1, 0),
+				ins(opcode.OpPop),
+			},
+			expectedConsts: []interface{}{
+				[]opcode.Instructions{
+					ins(opcode.OpGetFree, 0),
+					ins(opcode.OpGetLocal, 0),
+					ins(opcode.OpAdd),
+					ins(opcode.OpReturnValue),
+				},
+				[]opcode.Instructions{
+					ins(opcode.OpCaptureLocal, 0),
+					ins(opcode.OpClosure, 0, 1),
+					ins(opcode.OpReturnValue),
+				},
+			},
+		},
+		{
+			name:  "functions: assigning captured variables",
+			input: "(fn(a) { return fn() { return fn() { a = 1 }; }; })",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpClosure, 3, 0),
+				ins(opcode.OpPop),
+			},
+			expectedConsts: []interface{}{
+				1,
+				[]opcode.Instructions{
+					ins(opcode.OpConstant, 0),
+					ins(opcode.OpSetFree, 0),
+					ins(opcode.OpGetFree, 0),
+					ins(opcode.OpReturnValue),
+				},
+				[]opcode.Instructions{
+					ins(opcode.OpCaptureFree, 0),
+					ins(opcode.OpClosure, 1, 1),
+					ins(opcode.OpReturnValue),
+				},
+				[]opcode.Instructions{
+					ins(opcode.OpCaptureLocal, 0),
+					ins(opcode.OpClosure, 2, 1),
+					ins(opcode.OpReturnValue),
+				},
+			},
+		},
+		{
+			name:  "functions: recursive",
+			input: "fn count(n) { count(n) }",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpClosure, 0, 0),
+				ins(opcode.OpSetGlobal, 0),
+			},
+			expectedConsts: []interface{}{[]opcode.Instructions{
+				ins(opcode.OpCurrentClosure),
+				ins(opcode.OpGetLocal, 0),
+				ins(opcode.OpCall, 1),
+				ins(opcode.OpReturnValue),
+			}},
+		},
+		{
+			name:  "while loops",
+			input: "while (true) { break; continue; }",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpMark, 0),           // 0000
+				ins(opcode.OpTrue),              // 0002
+				ins(opcode.OpJumpNotTruthy, 19), // 0003
+				ins(opcode.OpUnwind, 0),         // 0006
+				ins(opcode.OpJump, 19),          // 0008
+				ins(opcode.OpUnwind, 0),         // 0011
+				ins(opcode.OpJump, 2),           // 0013
+				ins(opcode.OpJump, 2),           // 0016
+				ins(opcode.OpNull),              // 0019
+				ins(opcode.OpPop),               // 0020
+			},
+		},
+		{
+			name:  "for loops",
+			input: "for (let i = 0; i < 1; i = i + 1) { continue; }",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpConstant, 0),       // 0000
+				ins(opcode.OpDefineLocal, 0),    // 0003
+				ins(opcode.OpMark, 1),           // 0005
+				ins(opcode.OpGetLocal, 0),       // 0007
+				ins(opcode.OpConstant, 1),       // 0009
+				ins(opcode.OpLessThan),          // 0012
+				ins(opcode.OpJumpNotTruthy, 35), // 0013
+				ins(opcode.OpUnwind, 1),         // 0016
+				ins(opcode.OpJump, 21),          // 0018
+				ins(opcode.OpGetLocal, 0),       // 0021
+				ins(opcode.OpConstant, 2),       // 0023
+				ins(opcode.OpAdd),               // 0026
+				ins(opcode.OpSetLocal, 0),       // 0027
+				ins(opcode.OpGetLocal, 0),       // 0029
+				ins(opcode.OpPop),               // 0031
+				ins(opcode.OpJump, 7),           // 0032
+				ins(opcode.OpNull),              // 0035
+				ins(opcode.OpPop),               // 0036
+			},
+			expectedConsts: []interface{}{0, 1, 1},
+		},
+		{
+			name:  "for-in loops",
+			input: "for (x in [1]) { x }",
+			expectedInstrs: []opcode.Instructions{
+				ins(opcode.OpConstant, 0),    // 0000
+				ins(opcode.OpList, 1),        // 0003
+				ins(opcode.OpIter),           // 0006
+				ins(opcode.OpMark, 0),        // 0007
+				ins(opcode.OpIterNext, 20),   // 0009
+				ins(opcode.OpDefineLocal, 1), // 0012
+				ins(opcode.OpGetLocal, 1),    // 0014
+				ins(opcode.OpPop),            // 0016
+				ins(opcode.OpJump, 9),        // 0017
+				ins(opcode.OpPop),            // 0020
+				ins(opcode.OpNull),           // 0021
+				ins(opcode.OpPop),            // 0022
+			},
+			expectedConsts: []interface{}{1},
+		},
 	}
 
 	for _, testCase := range cases {
@@ -39,6 +557,7 @@ func TestCompiler(t *testing.T) {
 			l := lexer.NewLexer(tc.input)
 			p := parser.NewParser(l)
 			program := p.ParseProgram()
+			assert.Empty(t, p.Errors())
 
 			c := compiler.NewCompiler()
 			err := c.Compile(program)
@@ -47,12 +566,131 @@ func TestCompiler(t *testing.T) {
 
 			b := c.Bytecode()
 
-			for _, exp := range tc.expectedInstrs {
-				t.Logf("Expected: %s\t Received: %s\n", exp.String(), b.Instrs.String())
-			}
+			assert.Equal(t, concat(tc.expectedInstrs).String(), b.Instrs.String(), "instructions do not match")
+			assertConsts(t, tc.expectedConsts, b.Consts)
+		})
+	}
+}
+
+func TestCompiler_Errors(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "undefined variable",
+			input:    "let x = y;",
+			expected: "1:9: undefined variable 'y'",
+		},
+		{
+			name:     "assign to captured function name",
+			input:    "let f = fn() { return fn() { f = 1 }; };",
+			expected: "1:30: cannot assign to f",
+		},
+		{
+			name:     "break outside loop",
+			input:    "break;",
+			expected: "1:1: 'break' outside of loop",
+		},
+		{
+			name:     "class pattern",
+			input:    "match (1) { [A{}] => 1 }",
+			expected: "1:14: *ast.ClassPattern is not supported by the compiler",
+		},
+		{
+			name:     "class pattern in a let statement",
+			input:    "let {p: A{}} = 1;",
+			expected: "1:9: *ast.ClassPattern is not supported by the compiler",
+		},
+		{
+			name:     "unsupported node",
+			input:    "class A {}",
+			expected: "1:1: *ast.ClassStatement is not supported by the compiler",
+		},
+	}
+
+	for _, testCase := range cases {
+		tc := testCase
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			l := lexer.NewLexer(tc.input)
+			p := parser.NewParser(l)
+			program := p.ParseProgram()
+
+			err := compiler.NewCompiler().Compile(program)
+			assert.EqualError(t, err, tc.expected)
+		})
+	}
+}
+
+func TestCompiler_Limits(t *testing.T) {
+	t.Parallel()
+
+	// repeat joins the formatted items for 0 <= i < n
+	repeat := func(format, sep string, n int) string {
+		items := make([]string, n)
+		for i := range items {
+			items[i] = fmt.Sprintf(format, i)
+		}
+
+		return strings.Join(items, sep)
+	}
+
+	cases := []struct {
+		name     string
+		input    string
+		at       string // the source the error is reported at
+		expected string
+	}{
+		{
+			name:     "locals",
+			input:    "let f = fn() { " + repeat("let v%d = null;", " ", 300) + " v0 + v256 };",
+			at:       "let v256",
+			expected: "too many local variables, the limit is 256",
+		},
+		{
+			name:     "free variables",
+			input:    "let f = fn() { " + repeat("let v%d = null;", " ", 256) + " return fn() { [" + repeat("v%d", ", ", 256) + "] } };",
+			at:       "fn() { [",
+			expected: "too many free variables, the limit is 255",
+		},
+		{
+			name:     "arguments",
+			input:    "let f = fn() { null }; f(" + repeat("%d", ", ", 257) + ")",
+			at:       "f(0",
+			expected: "too many arguments, the limit is 255",
+		},
+		{
+			name:     "globals",
+			input:    repeat("let v%d = null;", " ", 65537),
+			at:       "let v65536",
+			expected: "too many global variables, the limit is 65536",
+		},
+		{
+			name:     "constants",
+			input:    "[" + repeat("%d", ", ", 65537) + "]",
+			at:       "65536",
+			expected: "too many constants, the limit is 65536",
+		},
+	}
+
+	for _, testCase := range cases {
+		tc := testCase
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := parser.NewParser(lexer.NewLexer(tc.input))
+			program := p.ParseProgram()
+			assert.Empty(t, p.Errors())
 
-			// assert.ElementsMatch(t, tc.expectedConsts, b.Consts, "constants do not match")
-			// assert.ElementsMatch(t, tc.expectedInstrs, b.Instrs, "instructions do not match")
+			err := compiler.NewCompiler().Compile(program)
+			assert.EqualError(t, err, fmt.Sprintf("1:%d: %s", strings.Index(tc.input, tc.at)+1, tc.expected))
 		})
 	}
 }
@@ -0,0 +1,132 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/donovandicks/gomonkey/internal/ast"
+	"github.com/donovandicks/gomonkey/internal/opcode"
+	"github.com/donovandicks/gomonkey/internal/token"
+)
+
+type ErrUnsupportedNode struct {
+	node ast.Node
+}
+
+func (e ErrUnsupportedNode) Error() string {
+	return fmt.Sprintf("%s: %T is not supported by the compiler", e.node.Pos(), e.node)
+}
+
+type ErrUndefinedVariable struct {
+	ident *ast.Identifier
+}
+
+func (e ErrUndefinedVariable) Error() string {
+	return fmt.Sprintf("%s: undefined variable '%s'", e.ident.Pos(), e.ident.Value)
+}
+
+type ErrInvalidAssignment struct {
+	node ast.Node
+}
+
+func (e ErrInvalidAssignment) Error() string {
+	return fmt.Sprintf("%s: cannot assign to %s", e.node.Pos(), e.node.String())
+}
+
+type ErrUnknownOperator struct {
+	node     ast.Node
+	operator string
+}
+
+func (e ErrUnknownOperator) Error() string {
+	return fmt.Sprintf("%s: unknown operator '%s'", e.node.Pos(), e.operator)
+}
+
+type ErrOutsideLoop struct {
+	node ast.Node
+}
+
+func (e ErrOutsideLoop) Error() string {
+	return fmt.Sprintf("%s: '%s' outside of loop", e.node.Pos(), e.node.TokenLiteral())
+}
+
+type ErrNotTopLevel struct {
+	node ast.Node
+}
+
+func (e ErrNotTopLevel) Error() string {
+	return fmt.Sprintf("%s: %s must be at the top level of a module", e.node.Pos(), e.node.TokenLiteral())
+}
+
+type ErrImport struct {
+	node ast.Node
+	err  error
+}
+
+func (e ErrImport) Error() string {
+	return fmt.Sprintf("%s: %s", e.node.Pos(), e.err)
+}
+
+func (e ErrImport) Unwrap() error { return e.err }
+
+type ErrNoExport struct {
+	node   *ast.ImportStatement
+	export *ast.Identifier
+}
+
+func (e ErrNoExport) Error() string {
+	return fmt.Sprintf("%s: module \"%s\" has no export '%s'", e.export.Pos(), e.node.Path.Value, e.export.Value)
+}
+
+type ErrReturnOutsideFunction struct {
+	node ast.Node
+}
+
+func (e ErrReturnOutsideFunction) Error() string {
+	return fmt.Sprintf("%s: 'return' outside of function", e.node.Pos())
+}
+
+// ErrOperandTooLarge reports an instruction whose operand is too large to
+// encode, such as a function with too many locals or a call with too many
+// arguments.
+type ErrOperandTooLarge struct {
+	pos     token.Position
+	code    opcode.OpCode
+	index   int // the position of the operand in the instruction
+	operand int
+}
+
+func (e ErrOperandTooLarge) Error() string {
+	limit := opcode.MaxOperand(e.code.OperandWidth()[e.index])
+
+	var what string
+	switch e.code {
+	case opcode.OpConstant, opcode.OpGetProperty, opcode.OpOptionalProperty, opcode.OpSetProperty,
+		opcode.OpMatch, opcode.OpDestructure:
+		what, limit = "constants", limit+1
+	case opcode.OpClosure:
+		if e.index == 0 {
+			what, limit = "constants", limit+1
+		} else {
+			what = "free variables"
+		}
+	case opcode.OpGetGlobal, opcode.OpSetGlobal:
+		what, limit = "global variables", limit+1
+	case opcode.OpGetLocal, opcode.OpSetLocal, opcode.OpDefineLocal, opcode.OpCaptureLocal,
+		opcode.OpMark, opcode.OpUnwind:
+		what, limit = "local variables", limit+1
+	case opcode.OpGetFree, opcode.OpSetFree, opcode.OpCaptureFree:
+		what, limit = "free variables", limit+1
+	case opcode.OpCall:
+		what = "arguments"
+	case opcode.OpList, opcode.OpMap:
+		what = "elements"
+	case opcode.OpModule:
+		what = "exports"
+	case opcode.OpJump, opcode.OpJumpNotTruthy, opcode.OpJumpNull, opcode.OpIterNext:
+		return fmt.Sprintf("%s: too many instructions to jump over, the limit is %d bytes", e.pos, limit)
+	default:
+		return fmt.Sprintf("%s: operand %d of %s exceeds the limit of %d", e.pos, e.operand, *e.code.Name(), limit)
+	}
+
+	return fmt.Sprintf("%s: too many %s, the limit is %d", e.pos, what, limit)
+}
@@ -0,0 +1,184 @@
+package compiler
+
+type SymbolScope string
+
+const (
+	SCOPE_GLOBAL   SymbolScope = "GLOBAL"
+	SCOPE_LOCAL    SymbolScope = "LOCAL"
+	SCOPE_BUILTIN  SymbolScope = "BUILTIN"
+	SCOPE_FREE     SymbolScope = "FREE"
+	SCOPE_FUNCTION SymbolScope = "FUNCTION"
+)
+
+type Symbol struct {
+	Name  string
+	Scope SymbolScope
+	Index int
+}
+
+// SymbolTable resolves identifiers to the storage they refer to at runtime.
+//
+// Each function body gets its own table enclosed by the table of the
+// surrounding code. Names defined in the outermost table are globals, while
+// names defined in an enclosed table are locals of that function. Resolving a
+// local of an enclosing function from an inner one records it as a free
+// variable that the inner function's closure must capture.
+//
+// Blocks get a block table, whose names are only visible inside the block but
+// are stored alongside the names of the enclosing function. The names of blocks
+// at the top level are locals of the main function rather than globals, so that
+// closures capture them like the locals of any other function. Likewise, imported
+// modules get a module table, whose names are stored as globals of the program
+// but are only visible inside the module.
+type SymbolTable struct {
+	Outer       *SymbolTable
+	FreeSymbols []Symbol
+
+	store   map[string]Symbol
+	numDefs int
+	block   bool
+
+	// numLocals is the number of locals of the main function, for the table
+	// of the program
+	numLocals int
+
+	// storage is the table of the program, for a module table
+	storage *SymbolTable
+}
+
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{
+		store: make(map[string]Symbol),
+	}
+}
+
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	st := NewSymbolTable()
+	st.Outer = outer
+	return st
+}
+
+func NewBlockSymbolTable(outer *SymbolTable) *SymbolTable {
+	st := NewEnclosedSymbolTable(outer)
+	st.block = true
+	return st
+}
+
+func NewModuleSymbolTable(storage *SymbolTable) *SymbolTable {
+	st := NewSymbolTable()
+	st.storage = storage
+	return st
+}
+
+// owner returns the table that allocates storage for the table's names: the
+// table itself, for a block the table of the enclosing function, or for a
+// module the table of the program.
+func (st *SymbolTable) owner() *SymbolTable {
+	for st.block {
+		st = st.Outer
+	}
+
+	if st.storage != nil {
+		return st.storage
+	}
+
+	return st
+}
+
+// NumDefinitions returns the number of bindings defined in the table.
+func (st *SymbolTable) NumDefinitions() int {
+	return st.numDefs
+}
+
+// NumLocals returns the number of locals of the main function, defined by the
+// blocks at the top level.
+func (st *SymbolTable) NumLocals() int {
+	return st.owner().numLocals
+}
+
+// Defines reports whether the name is already defined in the table itself, in
+// which case defining it again reuses its storage.
+func (st *SymbolTable) Defines(name string) bool {
+	sym, ok := st.store[name]
+	return ok && (sym.Scope == SCOPE_GLOBAL || sym.Scope == SCOPE_LOCAL)
+}
+
+func (st *SymbolTable) Define(name string) Symbol {
+	if st.Defines(name) {
+		return st.store[name]
+	}
+
+	owner := st.owner()
+
+	var sym Symbol
+	switch {
+	case owner.Outer != nil:
+		sym = Symbol{Name: name, Index: owner.numDefs, Scope: SCOPE_LOCAL}
+		owner.numDefs += 1
+	case st.block:
+		sym = Symbol{Name: name, Index: owner.numLocals, Scope: SCOPE_LOCAL}
+		owner.numLocals += 1
+	default:
+		sym = Symbol{Name: name, Index: owner.numDefs, Scope: SCOPE_GLOBAL}
+		owner.numDefs += 1
+	}
+
+	st.store[name] = sym
+	return sym
+}
+
+func (st *SymbolTable) DefineBuiltin(index int, name string) Symbol {
+	sym := Symbol{Name: name, Index: index, Scope: SCOPE_BUILTIN}
+	st.store[name] = sym
+	return sym
+}
+
+// DefineFunctionName binds the name of the function being compiled so that
+// its body can refer to itself.
+func (st *SymbolTable) DefineFunctionName(name string) Symbol {
+	sym := Symbol{Name: name, Index: 0, Scope: SCOPE_FUNCTION}
+	st.store[name] = sym
+	return sym
+}
+
+func (st *SymbolTable) defineFree(original Symbol) Symbol {
+	st.FreeSymbols = append(st.FreeSymbols, original)
+
+	sym := Symbol{Name: original.Name, Index: len(st.FreeSymbols) - 1, Scope: SCOPE_FREE}
+	st.store[original.Name] = sym
+	return sym
+}
+
+// Origin returns the symbol that a free variable captures from an enclosing
+// function, following it through any functions in between.
+func (st *SymbolTable) Origin(sym Symbol) Symbol {
+	for sym.Scope == SCOPE_FREE {
+		for st.block {
+			st = st.Outer
+		}
+
+		sym = st.FreeSymbols[sym.Index]
+		st = st.Outer
+	}
+
+	return sym
+}
+
+func (st *SymbolTable) Resolve(name string) (Symbol, bool) {
+	sym, ok := st.store[name]
+	if ok || st.Outer == nil {
+		return sym, ok
+	}
+
+	sym, ok = st.Outer.Resolve(name)
+	if !ok || st.block {
+		// a block shares the storage of its function, so nothing is captured
+		return sym, ok
+	}
+
+	if sym.Scope == SCOPE_GLOBAL || sym.Scope == SCOPE_BUILTIN {
+		return sym, ok
+	}
+
+	return st.defineFree(sym), true
+}
@@ -0,0 +1,109 @@
+package compiler_test
+
+import (
+	"testing"
+
+	"github.com/donovandicks/gomonkey/internal/compiler"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymbolTable_Resolve(t *testing.T) {
+	t.Parallel()
+
+	global := compiler.NewSymbolTable()
+	global.DefineBuiltin(0, "len")
+	global.Define("a")
+
+	outer := compiler.NewEnclosedSymbolTable(global)
+	outer.DefineFunctionName("f")
+	outer.Define("b")
+
+	inner := compiler.NewEnclosedSymbolTable(outer)
+	inner.Define("c")
+
+	cases := []struct {
+		name     string
+		expected compiler.Symbol
+	}{
+		{"len", compiler.Symbol{Name: "len", Scope: compiler.SCOPE_BUILTIN, Index: 0}},
+		{"a", compiler.Symbol{Name: "a", Scope: compiler.SCOPE_GLOBAL, Index: 0}},
+		{"c", compiler.Symbol{Name: "c", Scope: compiler.SCOPE_LOCAL, Index: 0}},
+		{"b", compiler.Symbol{Name: "b", Scope: compiler.SCOPE_FREE, Index: 0}},
+		{"f", compiler.Symbol{Name: "f", Scope: compiler.SCOPE_FREE, Index: 1}},
+	}
+
+	for _, tc := range cases {
+		sym, ok := inner.Resolve(tc.name)
+		assert.True(t, ok, "could not resolve %s", tc.name)
+		assert.Equal(t, tc.expected, sym)
+	}
+
+	assert.Equal(t, []compiler.Symbol{
+		{Name: "b", Scope: compiler.SCOPE_LOCAL, Index: 0},
+		{Name: "f", Scope: compiler.SCOPE_FUNCTION, Index: 0},
+	}, inner.FreeSymbols)
+
+	_, ok := inner.Resolve("missing")
+	assert.False(t, ok)
+}
+
+func TestSymbolTable_Block(t *testing.T) {
+	t.Parallel()
+
+	global := compiler.NewSymbolTable()
+	global.Define("a")
+
+	block := compiler.NewBlockSymbolTable(global)
+	assert.Equal(t, compiler.Symbol{Name: "a", Scope: compiler.SCOPE_LOCAL, Index: 0}, block.Define("a"))
+
+	fn := compiler.NewEnclosedSymbolTable(block)
+	fn.Define("b")
+
+	inner := compiler.NewBlockSymbolTable(fn)
+	assert.Equal(t, compiler.Symbol{Name: "c", Scope: compiler.SCOPE_LOCAL, Index: 1}, inner.Define("c"))
+
+	cases := []struct {
+		table    *compiler.SymbolTable
+		name     string
+		expected compiler.Symbol
+	}{
+		{global, "a", compiler.Symbol{Name: "a", Scope: compiler.SCOPE_GLOBAL, Index: 0}},
+		{block, "a", compiler.Symbol{Name: "a", Scope: compiler.SCOPE_LOCAL, Index: 0}},
+		{inner, "a", compiler.Symbol{Name: "a", Scope: compiler.SCOPE_FREE, Index: 0}},
+		{inner, "b", compiler.Symbol{Name: "b", Scope: compiler.SCOPE_LOCAL, Index: 0}},
+	}
+
+	for _, tc := range cases {
+		sym, ok := tc.table.Resolve(tc.name)
+		assert.True(t, ok, "could not resolve %s", tc.name)
+		assert.Equal(t, tc.expected, sym)
+	}
+
+	assert.Equal(t, 1, global.NumDefinitions())
+	assert.Equal(t, 1, global.NumLocals())
+	assert.Equal(t, 2, fn.NumDefinitions())
+	assert.Empty(t, inner.FreeSymbols)
+	assert.Equal(t, []compiler.Symbol{{Name: "a", Scope: compiler.SCOPE_LOCAL, Index: 0}}, fn.FreeSymbols)
+
+	_, ok := fn.Resolve("c")
+	assert.False(t, ok)
+}
+
+func TestSymbolTable_Origin(t *testing.T) {
+	t.Parallel()
+
+	global := compiler.NewSymbolTable()
+
+	outer := compiler.NewEnclosedSymbolTable(global)
+	outer.DefineFunctionName("f")
+	outer.Define("a")
+
+	middle := compiler.NewEnclosedSymbolTable(outer)
+	inner := compiler.NewBlockSymbolTable(compiler.NewEnclosedSymbolTable(middle))
+
+	a, _ := inner.Resolve("a")
+	f, _ := inner.Resolve("f")
+
+	assert.Equal(t, compiler.Symbol{Name: "a", Scope: compiler.SCOPE_LOCAL, Index: 0}, inner.Origin(a))
+	assert.Equal(t, compiler.Symbol{Name: "f", Scope: compiler.SCOPE_FUNCTION, Index: 0}, inner.Origin(f))
+}
@@ -0,0 +1,104 @@
+package diagnostic
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/donovandicks/gomonkey/internal/token"
+)
+
+type Severity string
+
+const (
+	SEVERITY_ERROR   Severity = "error"
+	SEVERITY_WARNING Severity = "warning"
+)
+
+// Code identifies the kind of problem a diagnostic reports. Codes are stable
+// so that tools can match on them.
+type Code string
+
+// Range is the span of source text a diagnostic refers to. The end position is
+// exclusive.
+type Range struct {
+	Start token.Position `json:"start"`
+	End   token.Position `json:"end"`
+}
+
+// Fix is a suggested edit that resolves a diagnostic: the text in the range is
+// replaced with the replacement, so an empty range inserts it.
+type Fix struct {
+	Message     string `json:"message"`
+	Range       Range  `json:"range"`
+	Replacement string `json:"replacement"`
+}
+
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Code     Code     `json:"code"`
+	Range    Range    `json:"range"`
+	Message  string   `json:"message"`
+	Fixes    []Fix    `json:"fixes,omitempty"`
+}
+
+func (d Diagnostic) Error() string {
+	return fmt.Sprintf("%s: %s", d.Range.Start, d.Message)
+}
+
+// Render formats the diagnostic for humans, followed by an excerpt of the
+// source line it refers to with the range underlined and any fixes as help.
+func (d Diagnostic) Render(source string) string {
+	var out strings.Builder
+
+	fmt.Fprintf(&out, "%s: %s[%s]: %s\n", d.Range.Start, d.Severity, d.Code, d.Message)
+
+	line, ok := sourceLine(source, d.Range.Start.Line)
+	if ok {
+		num := fmt.Sprintf("%d", d.Range.Start.Line)
+		gutter := strings.Repeat(" ", len(num))
+
+		fmt.Fprintf(&out, "%s | %s\n", num, line)
+		fmt.Fprintf(&out, "%s | %s\n", gutter, underline(line, d.Range))
+	}
+
+	for _, fix := range d.Fixes {
+		fmt.Fprintf(&out, "help: %s\n", fix.Message)
+	}
+
+	return out.String()
+}
+
+func sourceLine(source string, line int) (string, bool) {
+	lines := strings.Split(source, "\n")
+	if line < 1 || line > len(lines) {
+		return "", false
+	}
+
+	return strings.TrimRight(lines[line-1], "\r"), true
+}
+
+// underline builds the caret line placed below the source line. Tabs in the
+// indentation are kept so that the carets line up with the text above.
+func underline(line string, r Range) string {
+	start := min(max(r.Start.Column-1, 0), len(line))
+
+	width := 1
+	if r.End.Line == r.Start.Line && r.End.Column-1 > start {
+		end := min(r.End.Column-1, len(line))
+		width = max(utf8.RuneCountInString(line[start:end]), 1)
+	}
+
+	var out strings.Builder
+	for _, ch := range line[:start] {
+		if ch == '\t' {
+			out.WriteRune('\t')
+		} else {
+			out.WriteRune(' ')
+		}
+	}
+
+	out.WriteString(strings.Repeat("^", width))
+
+	return out.String()
+}
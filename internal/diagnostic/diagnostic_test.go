@@ -0,0 +1,100 @@
+package diagnostic_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/donovandicks/gomonkey/internal/diagnostic"
+	"github.com/donovandicks/gomonkey/internal/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnostic_Render(t *testing.T) {
+	t.Parallel()
+
+	pos := func(line, column int) token.Position {
+		return token.Position{Line: line, Column: column}
+	}
+
+	cases := []struct {
+		name     string
+		source   string
+		diag     diagnostic.Diagnostic
+		expected string
+	}{
+		{
+			name:   "underlines the range",
+			source: "let x = 1;\nlet yy 2;",
+			diag: diagnostic.Diagnostic{
+				Severity: diagnostic.SEVERITY_ERROR,
+				Code:     "unexpected-token",
+				Range:    diagnostic.Range{Start: pos(2, 5), End: pos(2, 7)},
+				Message:  "bad name",
+			},
+			expected: "2:5: error[unexpected-token]: bad name\n" +
+				"2 | let yy 2;\n" +
+				"  |     ^^\n",
+		},
+		{
+			name:   "keeps tabs and shows fixes",
+			source: "\tx +",
+			diag: diagnostic.Diagnostic{
+				Severity: diagnostic.SEVERITY_WARNING,
+				Code:     "missing-expression",
+				Range:    diagnostic.Range{Start: pos(1, 5), End: pos(1, 5)},
+				Message:  "expected an expression",
+				Fixes:    []diagnostic.Fix{{Message: "insert '1'"}},
+			},
+			expected: "1:5: warning[missing-expression]: expected an expression\n" +
+				"1 | \tx +\n" +
+				"  | \t   ^\n" +
+				"help: insert '1'\n",
+		},
+		{
+			name:   "without source",
+			source: "",
+			diag: diagnostic.Diagnostic{
+				Severity: diagnostic.SEVERITY_ERROR,
+				Code:     "illegal-token",
+				Range:    diagnostic.Range{Start: pos(3, 1), End: pos(3, 2)},
+				Message:  "unexpected character '#'",
+			},
+			expected: "3:1: error[illegal-token]: unexpected character '#'\n",
+		},
+	}
+
+	for _, testCase := range cases {
+		tc := testCase
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, tc.diag.Render(tc.source))
+		})
+	}
+}
+
+func TestDiagnostic_JSON(t *testing.T) {
+	t.Parallel()
+
+	diag := diagnostic.Diagnostic{
+		Severity: diagnostic.SEVERITY_ERROR,
+		Code:     "missing-closer",
+		Range: diagnostic.Range{
+			Start: token.Position{File: "a.monkey", Offset: 3, Line: 1, Column: 4},
+			End:   token.Position{File: "a.monkey", Offset: 4, Line: 1, Column: 5},
+		},
+		Message: "missing closing ')'",
+	}
+
+	out, err := json.Marshal(diag)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"severity": "error",
+		"code": "missing-closer",
+		"range": {
+			"start": {"file": "a.monkey", "offset": 3, "line": 1, "column": 4},
+			"end": {"file": "a.monkey", "offset": 4, "line": 1, "column": 5}
+		},
+		"message": "missing closing ')'"
+	}`, string(out))
+}
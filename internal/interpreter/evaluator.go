@@ -1,6 +1,8 @@
 package interpreter
 
 import (
+	"context"
+
 	"github.com/donovandicks/gomonkey/internal/ast"
 	"github.com/donovandicks/gomonkey/internal/object"
 )
@@ -18,36 +20,55 @@ func evalProgram(stmts []ast.Statement, env *object.Environment) object.Object {
 			return res.Value
 		case *object.Err:
 			return res
+		case *object.Break, *object.Continue:
+			return errOutsideLoop(res)
 		}
 	}
 
 	return res
 }
 
+// errOutsideLoop reports a loop control signal that escaped every loop.
+func errOutsideLoop(signal object.Object) object.Object {
+	return object.NewErr("'%s' outside of loop", signal.Inspect())
+}
+
+// evalBlockStatement evaluates the statements of a block, giving the value of
+// the last one, or null if it has none.
 func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
-	var res object.Object
+	var res object.Object = object.NullObject
 
 	for _, stmt := range block.Statements {
 		res = Eval(stmt, env)
 
-		if res != nil {
-			rt := res.Type()
-			if rt == object.OBJ_ERR || rt == object.OBJ_RETURN {
-				return res
-			}
+		if res == nil {
+			// statements such as let have no value
+			res = object.NullObject
+			continue
+		}
+
+		switch res.Type() {
+		case object.OBJ_ERR, object.OBJ_RETURN, object.OBJ_BREAK, object.OBJ_CONTINUE:
+			return res
 		}
 	}
 
 	return res
 }
 
+// evalScopedBlock evaluates a block in a new scope, so that its bindings are
+// not visible once it finishes.
+func evalScopedBlock(block *ast.BlockStatement, env *object.Environment) object.Object {
+	return evalBlockStatement(block, object.NewEnvFromEnv(env))
+}
+
 func evalExpressions(exprs []ast.Expression, env *object.Environment) []object.Object {
 	objs := make([]object.Object, 0, len(exprs))
 
 	for _, expr := range exprs {
 		val := Eval(expr, env)
 		objs = append(objs, val)
-		if object.IsErr(val) {
+		if object.IsAbrupt(val) {
 			return objs
 		}
 	}
@@ -60,123 +81,73 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 		return ident
 	}
 
-	if builtin, ok := Builtins[node.Value]; ok {
+	if builtin, ok := builtins[node.Value]; ok {
 		return builtin
 	}
 
-	return object.NewErr("undefined variable '%s'", node.Value)
-}
-
-func evalBangOpExpr(right object.Object) object.Object {
-	switch right {
-	case object.TrueBool:
-		return object.FalseBool
-	case object.FalseBool:
-		return object.TrueBool
-	case object.NullObject:
-		return object.TrueBool
-	default:
-		return object.FalseBool
+	if builtin := object.GetBuiltinByName(node.Value); builtin != nil {
+		return builtin
 	}
+
+	return object.NewErrWithKind(object.ERR_NAME, "undefined variable '%s'", node.Value)
 }
 
-func evalMinusOpExpr(right object.Object) object.Object {
-	if right.Type() != object.OBJ_INTEGER {
-		return object.NewErr("invalid operator '-' for type %s", right.Type())
+func evalIfExpression(expr *ast.IfExpression, env *object.Environment) object.Object {
+	cond := Eval(expr.Condition, env)
+	if object.IsAbrupt(cond) {
+		return cond
 	}
 
-	val := right.(*object.Integer).Value
-	return object.NewIntegerObject(-val)
-}
+	if object.IsTruthy(cond) {
+		return evalScopedBlock(expr.Consequence, env)
+	}
 
-func evalPrefixExpr(operator string, right object.Object) object.Object {
-	switch operator {
-	case "!":
-		return evalBangOpExpr(right)
-	case "-":
-		return evalMinusOpExpr(right)
-	default:
-		return object.NewErr("unknown operator '%s' for type %s", operator, right.Type())
+	if expr.Alternative != nil {
+		return evalScopedBlock(expr.Alternative, env)
 	}
+
+	return object.NullObject
 }
 
-func evalIntegerInfixExpr(operator string, left, right object.Object) object.Object {
-	l := left.(*object.Integer).Value
-	r := right.(*object.Integer).Value
-
-	switch operator {
-	case "+":
-		return object.NewIntegerObject(l + r)
-	case "-":
-		return object.NewIntegerObject(l - r)
-	case "*":
-		return object.NewIntegerObject(l * r)
-	case "/":
-		return object.NewIntegerObject(l / r)
-	case "<":
-		return object.BoolFromNative(l < r)
-	case ">":
-		return object.BoolFromNative(l > r)
-	case "==":
-		return object.BoolFromNative(l == r)
-	case "!=":
-		return object.BoolFromNative(l != r)
-	default:
-		return object.NewErr("unknown integer operator '%s' on integers %d, %d", operator, l, r)
+// evalMatchExpression evaluates the body of the first arm that matches the
+// subject, or gives null if none does. The names bound by the pattern are
+// visible to the guard and the body of the arm.
+func evalMatchExpression(expr *ast.MatchExpression, env *object.Environment) object.Object {
+	subject := Eval(expr.Subject, env)
+	if object.IsAbrupt(subject) {
+		return subject
 	}
-}
 
-func evalStringInfixExpr(operator string, left, right object.Object) object.Object {
-	l := left.(*object.String).Value
-	r := right.(*object.String).Value
+	for _, arm := range expr.Arms {
+		armEnv := object.NewEnvFromEnv(env)
 
-	switch operator {
-	case "+":
-		return object.NewStringObject(l + r)
-	default:
-		return object.NewErr("unknown string operator '%s' on strings %s, %s", operator, l, r)
-	}
-}
+		err := object.Match(arm.Pattern, subject, armEnv, func(name string, val object.Object) {
+			armEnv.Set(name, val)
+		})
+		if err != nil {
+			if err.Kind == object.ERR_MATCH {
+				continue
+			}
 
-func evalInfixExpr(operator string, left, right object.Object) object.Object {
-	switch {
-	case left.Type() == object.OBJ_INTEGER && right.Type() == object.OBJ_INTEGER:
-		return evalIntegerInfixExpr(operator, left, right)
-	case left.Type() == object.OBJ_STR && right.Type() == object.OBJ_STR:
-		return evalStringInfixExpr(operator, left, right)
-	case operator == "==":
-		return object.BoolFromNative(left == right)
-	case operator == "!=":
-		return object.BoolFromNative(left != right)
-	case left.Type() != right.Type():
-		return object.NewErr(
-			"type error: cannot perform '%s' on %s, %s",
-			operator,
-			left.Type(),
-			right.Type(),
-		)
-	default:
-		return object.NewErr(
-			"unknown operator '%s' for types %s, %s",
-			operator,
-			left.Type(),
-			right.Type(),
-		)
-	}
-}
+			return err
+		}
 
-func evalIfExpression(expr *ast.IfExpression, env *object.Environment) object.Object {
-	cond := Eval(expr.Condition, env)
-	if object.IsErr(cond) {
-		return cond
-	}
+		if arm.Guard != nil {
+			guard := Eval(arm.Guard, armEnv)
+			if object.IsAbrupt(guard) {
+				return guard
+			}
 
-	if object.IsTruthy(cond) {
-		return Eval(expr.Consequence, env)
-	}
+			if !object.IsTruthy(guard) {
+				continue
+			}
+		}
 
-	if expr.Alternative != nil {
-		return Eval(expr.Alternative, env)
+		if res := Eval(arm.Body, armEnv); res != nil {
+			return res
+		}
+
+		return object.NullObject
 	}
 
 	return object.NullObject
@@ -186,17 +157,17 @@ func evalMapLiteral(node *ast.MapLiteral, env *object.Environment) object.Object
 	pairs := make(map[object.HashKey]object.KVPair, len(node.Entries))
 	for key, val := range node.Entries {
 		k := Eval(key, env)
-		if object.IsErr(k) {
+		if object.IsAbrupt(k) {
 			return k
 		}
 
 		hashable, ok := k.(object.HashableObject)
 		if !ok {
-			return object.NewErr("cannot use unhashable type %s as hash key", k.Type())
+			return object.NewErrWithKind(object.ERR_TYPE, "cannot use unhashable type %s as hash key", k.Type())
 		}
 
 		v := Eval(val, env)
-		if object.IsErr(v) {
+		if object.IsAbrupt(v) {
 			return v
 		}
 
@@ -206,54 +177,110 @@ func evalMapLiteral(node *ast.MapLiteral, env *object.Environment) object.Object
 	return &object.Map{Entries: pairs}
 }
 
-func evalListIndexExpr(left, index object.Object) object.Object {
-	l := left.(*object.List)
-	idx := index.(*object.Integer).Value
+func evalWhileStatement(stmt *ast.WhileStatement, env *object.Environment) object.Object {
+	for {
+		cond := Eval(stmt.Condition, env)
+		if object.IsAbrupt(cond) {
+			return cond
+		}
+
+		if !object.IsTruthy(cond) {
+			break
+		}
 
-	max := int64(len(l.Elems) - 1)
-	if idx > max {
-		return object.NewErr("index out of bounds: %d", idx)
+		if res, stop := evalLoopBody(stmt.Block, env); stop {
+			if res != nil {
+				return res
+			}
+			break
+		}
 	}
 
-	if idx < 0 {
-		idx = int64(len(l.Elems)) + idx
+	return object.NullObject
+}
+
+// evalLoopBody evaluates one iteration of a loop body in its own scope. It
+// reports whether the loop should stop, along with the result to propagate out
+// of the loop when the body returned or failed.
+func evalLoopBody(block *ast.BlockStatement, env *object.Environment) (object.Object, bool) {
+	res := evalScopedBlock(block, env)
+	if res == nil {
+		return nil, false
 	}
 
-	return l.Elems[idx]
+	switch res.Type() {
+	case object.OBJ_BREAK:
+		return nil, true
+	case object.OBJ_ERR, object.OBJ_RETURN:
+		return res, true
+	default:
+		return nil, false
+	}
 }
 
-func evalMapIndexExpr(left, index object.Object) object.Object {
-	l := left.(*object.Map)
-	key, _ := index.(object.HashableObject)
+func evalForStatement(stmt *ast.ForStatement, outer *object.Environment) object.Object {
+	// the loop variables are scoped to the loop
+	env := object.NewEnvFromEnv(outer)
 
-	kv, ok := l.Entries[key.Hash()]
-	if !ok {
-		return object.NewErr("no key found for %s (hash=%d)", key.Inspect(), key.Hash().Value)
+	if stmt.Init != nil {
+		init := Eval(stmt.Init, env)
+		if object.IsAbrupt(init) {
+			return init
+		}
 	}
 
-	return kv.Value
-}
+	for {
+		if stmt.Condition != nil {
+			cond := Eval(stmt.Condition, env)
+			if object.IsAbrupt(cond) {
+				return cond
+			}
 
-func evalIndexExpr(left, index object.Object) object.Object {
-	switch left.Type() {
-	case object.OBJ_LIST:
-		if index.Type() != object.OBJ_INTEGER {
-			return object.NewErr("cannot index list using non-integer type %s", left.Type())
+			if !object.IsTruthy(cond) {
+				break
+			}
+		}
+
+		if res, stop := evalLoopBody(stmt.Block, env); stop {
+			if res != nil {
+				return res
+			}
+			break
 		}
-		return evalListIndexExpr(left, index)
-	case object.OBJ_MAP:
-		if !object.IsHashable(index) {
-			return object.NewErr("cannot index map using non-hashable type %s", left.Type())
+
+		if stmt.Post != nil {
+			post := Eval(stmt.Post, env)
+			if object.IsAbrupt(post) {
+				return post
+			}
 		}
-		return evalMapIndexExpr(left, index)
-	default:
-		return object.NewErr("cannot index %s object", left.Type())
 	}
+
+	return object.NullObject
 }
 
-func evalWhileStatement(stmt *ast.WhileStatement, env *object.Environment) object.Object {
-	for object.IsTruthy(Eval(stmt.Condition, env)) {
-		evalBlockStatement(stmt.Block, env)
+func evalForInStatement(stmt *ast.ForInStatement, env *object.Environment) object.Object {
+	iterable := Eval(stmt.Iterable, env)
+	if object.IsAbrupt(iterable) {
+		return iterable
+	}
+
+	elems, ok := object.IterElems(iterable)
+	if !ok {
+		return object.NewErrWithKind(object.ERR_TYPE, "cannot iterate over %s", iterable.Type())
+	}
+
+	for _, elem := range elems {
+		// each iteration binds a fresh variable, so closures capture the
+		// element they were created with
+		iterEnv := object.NewEnvFromEnv(env).With(map[string]object.Object{stmt.Var.Value: elem})
+
+		if res, stop := evalLoopBody(stmt.Block, iterEnv); stop {
+			if res != nil {
+				return res
+			}
+			break
+		}
 	}
 
 	return object.NullObject
@@ -271,41 +298,328 @@ func applyFunc(callable object.Object, args []object.Object) object.Object {
 	switch c := callable.(type) {
 	case *object.Class:
 		inst := object.NewInstance(c)
-		init := inst.Get("init")
-		if initFn, ok := init.(*object.Function); ok {
+		if init, ok := inst.Methods["init"]; ok {
 			// invoke the init function if one exists
-			applyFunc(initFn, args)
+			if res := applyFunc(init, args); object.IsErr(res) {
+				return res
+			}
 		}
 		return inst
 	case *object.Function:
-		newEnv := object.NewEnvFromEnv(c.Env)
+		if len(args) != len(c.Parameters) {
+			return object.NewErrWithKind(object.ERR_TYPE, "wrong number of arguments: want=%d, got=%d", len(c.Parameters), len(args))
+		}
+
+		budget := c.Env.Budget()
+		if err := budget.Enter(); err != nil {
+			return err
+		}
+		defer budget.Leave()
+
+		newEnv := object.NewCallEnv(c.Env, c.DisplayName())
 		for idx, param := range c.Parameters {
 			newEnv.Set(param.Value, args[idx])
 		}
-		return unwrap(Eval(c.Body, newEnv))
+
+		res := Eval(c.Body, newEnv)
+		switch res.(type) {
+		case nil:
+			// a body without a final expression produces null
+			return object.NullObject
+		case *object.Break, *object.Continue:
+			return errOutsideLoop(res)
+		}
+
+		return unwrap(res)
 	case *object.Builtin:
 		return c.Fn(args...)
 	default:
-		return object.NewErr("undefined callable '%s'", c.Type())
+		return object.NewErrWithKind(object.ERR_TYPE, "undefined callable '%s'", c.Type())
+	}
+}
+
+// traceCall records that an error escaped the body of a function called at
+// the call expression.
+func traceCall(err *object.Err, call *ast.CallExpression, env *object.Environment) {
+	if len(err.Trace) == 0 {
+		return
 	}
+
+	err.Trace = append(err.Trace, object.TraceFrame{Function: env.Function(), Pos: call.Pos()})
+}
+
+func evalThrowStatement(stmt *ast.ThrowStatement, env *object.Environment) object.Object {
+	val := Eval(stmt.Value, env)
+	if object.IsAbrupt(val) {
+		return val
+	}
+
+	if ex, ok := val.(*object.Exception); ok {
+		// rethrowing a caught error raises it again from here
+		return &object.Err{Kind: ex.Err.Kind, Msg: ex.Err.Msg, Value: ex.Err.Value}
+	}
+
+	return &object.Err{Kind: object.ERR_ERROR, Msg: val.Inspect(), Value: val}
+}
+
+func evalTryStatement(stmt *ast.TryStatement, env *object.Environment) object.Object {
+	res := evalScopedBlock(stmt.Block, env)
+
+	if err, ok := res.(*object.Err); ok && stmt.Catch != nil {
+		catchEnv := object.NewEnvFromEnv(env)
+		catchEnv.Set(stmt.Param.Value, &object.Exception{Err: err})
+		res = evalBlockStatement(stmt.Catch, catchEnv)
+	}
+
+	if stmt.Finally != nil {
+		fin := evalScopedBlock(stmt.Finally, env)
+		if fin != nil {
+			switch fin.Type() {
+			case object.OBJ_ERR, object.OBJ_RETURN, object.OBJ_BREAK, object.OBJ_CONTINUE:
+				// leaving the finally block abruptly discards the earlier result
+				return fin
+			}
+		}
+	}
+
+	return res
+}
+
+func evalClassStatement(stmt *ast.ClassStatement, env *object.Environment) object.Object {
+	var parent *object.Class
+	if stmt.Parent != nil {
+		val := Eval(stmt.Parent, env)
+		if object.IsAbrupt(val) {
+			return val
+		}
+
+		cls, ok := val.(*object.Class)
+		if !ok {
+			return object.NewErrWithKind(object.ERR_TYPE, "cannot extend non-class type %s", val.Type())
+		}
+
+		parent = cls
+	}
+
+	env.Set(stmt.Name.Value, object.NewClassObject(stmt.Name, parent, stmt.Methods, env))
+	return nil
+}
+
+// evalAssignment assigns to the target of the assignment, returning the
+// assigned value. A compound assignment applies its operator to the current
+// value of the target and the right operand.
+func evalAssignment(expr *ast.AssignmentExpression, env *object.Environment) object.Object {
+	var get func() object.Object
+	var set func(object.Object) object.Object
+
+	switch left := expr.Left.(type) {
+	case *ast.Identifier:
+		get = func() object.Object { return evalIdentifier(left, env) }
+		set = func(val object.Object) object.Object { return env.Update(left.Value, val) }
+	case *ast.GetExpression:
+		obj := Eval(left.Left, env)
+		if object.IsAbrupt(obj) {
+			return obj
+		}
+
+		get = func() object.Object { return object.GetProperty(obj, left.Right.String()) }
+		set = func(val object.Object) object.Object { return object.SetProperty(obj, left.Right.String(), val) }
+	case *ast.IndexExpression:
+		obj := Eval(left.Left, env)
+		if object.IsAbrupt(obj) {
+			return obj
+		}
+
+		index := Eval(left.Index, env)
+		if object.IsAbrupt(index) {
+			return index
+		}
+
+		get = func() object.Object { return evalIndex(obj, index) }
+		set = func(val object.Object) object.Object { return object.SetIndex(obj, index, val) }
+	default:
+		return object.NewErrWithKind(object.ERR_TYPE, "cannot assign to %s", expr.Left.String())
+	}
+
+	var current object.Object
+	if expr.Operator() != "" {
+		if current = get(); object.IsAbrupt(current) {
+			return current
+		}
+	}
+
+	val := Eval(expr.Right, env)
+	if object.IsAbrupt(val) {
+		return val
+	}
+
+	if current != nil {
+		if val = evalInfixOp(expr.Operator(), current, val); object.IsAbrupt(val) {
+			return val
+		}
+	}
+
+	return set(val)
+}
+
+// evalLogicalExpression evaluates `&&` and `||`, which only evaluate their
+// right operand if the left one does not decide the result.
+func evalLogicalExpression(expr *ast.InfixExpression, env *object.Environment) object.Object {
+	left := Eval(expr.Left, env)
+	if object.IsAbrupt(left) {
+		return left
+	}
+
+	isOr := expr.Operator == "||"
+	if object.IsTruthy(left) == isOr {
+		return object.BoolFromNative(isOr)
+	}
+
+	right := Eval(expr.Right, env)
+	if object.IsAbrupt(right) {
+		return right
+	}
+
+	return object.BoolFromNative(object.IsTruthy(right))
+}
+
+// evalNullishExpression evaluates `??`, which only evaluates its right operand
+// if the left one is null.
+func evalNullishExpression(expr *ast.InfixExpression, env *object.Environment) object.Object {
+	left := Eval(expr.Left, env)
+	if left != object.NullObject {
+		return left
+	}
+
+	return Eval(expr.Right, env)
+}
+
+// evalChain evaluates a property lookup, index or call, reporting whether an
+// optional link of the chain it ends found null. The links after that one are
+// skipped, so that the whole chain gives null.
+func evalChain(node ast.Expression, env *object.Environment) (object.Object, bool) {
+	switch node := node.(type) {
+	case *ast.GetExpression:
+		obj, short := evalLink(node.Left, env)
+		if short || object.IsAbrupt(obj) {
+			return obj, short
+		}
+
+		if node.Optional() {
+			if obj == object.NullObject {
+				return obj, true
+			}
+
+			return object.OptionalProperty(obj, node.Right.String()), false
+		}
+
+		return object.GetProperty(obj, node.Right.String()), false
+	case *ast.IndexExpression:
+		left, short := evalLink(node.Left, env)
+		if short || object.IsAbrupt(left) {
+			return left, short
+		}
+
+		if node.Optional() && left == object.NullObject {
+			return left, true
+		}
+
+		index := Eval(node.Index, env)
+		if object.IsAbrupt(index) {
+			return index, false
+		}
+
+		if node.Optional() {
+			return object.Optional(evalIndex(left, index)), false
+		}
+
+		return evalIndex(left, index), false
+	case *ast.CallExpression:
+		f, short := evalLink(node.Function, env)
+		if short || object.IsAbrupt(f) {
+			return f, short
+		}
+
+		args := evalExpressions(node.Arguments, env)
+		if len(args) > 0 && object.IsAbrupt(args[len(args)-1]) {
+			return args[len(args)-1], false
+		}
+
+		res := applyFunc(f, args)
+		if err, ok := res.(*object.Err); ok {
+			if _, ok := f.(*object.Function); ok {
+				traceCall(err, node, env)
+			}
+		}
+
+		return res, false
+	}
+
+	return Eval(node, env), false
+}
+
+// evalLink evaluates the operand of a link of a chain like Eval, reporting
+// whether it is itself a link that was skipped.
+func evalLink(node ast.Expression, env *object.Environment) (object.Object, bool) {
+	switch node.(type) {
+	case *ast.GetExpression, *ast.IndexExpression, *ast.CallExpression:
+	default:
+		return Eval(node, env), false
+	}
+
+	if err := env.Budget().Step(); err != nil {
+		return annotate(err, node, env), false
+	}
+
+	res, short := evalChain(node, env)
+	return annotate(res, node, env), short
 }
 
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	if err := env.Budget().Step(); err != nil {
+		return annotate(err, node, env)
+	}
+
+	return annotate(eval(node, env), node, env)
+}
+
+// annotate records the node as the one that raised the result, if it is an
+// error that no node has seen yet.
+func annotate(res object.Object, node ast.Node, env *object.Environment) object.Object {
+	if err, ok := res.(*object.Err); ok && err.Node == nil {
+		// the innermost node to see the error is the one that raised it
+		err.Node = node
+		err.Trace = []object.TraceFrame{{Function: env.Function(), Pos: node.Pos()}}
+	}
+
+	return res
+}
+
+func eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
+		if env.Budget() == nil {
+			// limit the depth of calls even if nothing else is limited
+			env.SetBudget(object.NewBudget(context.Background(), 0))
+		}
+
 		return evalProgram(node.Statements, env)
 	case *ast.ExpressionStatement:
 		return Eval(node.Expression, env)
 	case *ast.IntegerLiteral:
 		return object.NewIntegerObject(node.Value)
+	case *ast.FloatLiteral:
+		return object.NewFloatObject(node.Value)
 	case *ast.StringLiteral:
 		return object.NewStringObject(node.Value)
+	case *ast.NullLiteral:
+		return object.NullObject
 	case *ast.Boolean:
 		return object.BoolFromNative(node.Value)
 	case *ast.ListLiteral:
 		elems := evalExpressions(node.Elems, env)
-		if len(elems) >= 1 && object.IsErr(elems[0]) {
-			return elems[0]
+		if len(elems) >= 1 && object.IsAbrupt(elems[len(elems)-1]) {
+			return elems[len(elems)-1]
 		}
 
 		return object.NewListObject(elems)
@@ -313,74 +627,74 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return evalMapLiteral(node, env)
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, env) // evaluate the operand
-		if object.IsErr(right) {
+		if object.IsAbrupt(right) {
 			return right
 		}
-		return evalPrefixExpr(node.Operator, right)
+		return object.PrefixOp(node.Operator, right)
 	case *ast.AssignmentExpression:
-		right := Eval(node.Right, env)
-		if object.IsErr(right) {
-			return right
+		return evalAssignment(node, env)
+	case *ast.InfixExpression:
+		switch node.Operator {
+		case "&&", "||":
+			return evalLogicalExpression(node, env)
+		case "??":
+			return evalNullishExpression(node, env)
 		}
 
-		switch left := node.Left.(type) {
-		case *ast.Identifier:
-			return env.Update(left.Value, right)
-		case *ast.GetExpression:
-			evaled := Eval(left.Left, env)
-			inst, ok := evaled.(*object.Instance)
-			if !ok {
-				return object.NewErr("cannot assign field to non-instance type %T", evaled)
-			}
-
-			field, ok := left.Right.(*ast.Identifier)
-			if !ok {
-				return object.NewErr("undefined property %s on %s", left.Right.String(), inst.Inspect())
-			}
-
-			inst.Set(field.String(), right)
-			return right
-		default:
-			object.NewErr("cannot assign to %s (%T)", left, left)
-		}
-	case *ast.InfixExpression:
 		left := Eval(node.Left, env)
-		if object.IsErr(left) {
+		if object.IsAbrupt(left) {
 			return left
 		}
 
 		right := Eval(node.Right, env)
-		if object.IsErr(right) {
+		if object.IsAbrupt(right) {
 			return right
 		}
 
-		return evalInfixExpr(node.Operator, left, right)
-	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
-		if object.IsErr(left) {
-			return left
-		}
-
-		index := Eval(node.Index, env)
-		if object.IsErr(index) {
-			return index
-		}
-
-		return evalIndexExpr(left, index)
+		return evalInfixOp(node.Operator, left, right)
+	case *ast.IndexExpression, *ast.GetExpression, *ast.CallExpression:
+		res, _ := evalChain(node.(ast.Expression), env)
+		return res
 	case *ast.BlockStatement:
 		return evalBlockStatement(node, env)
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
+	case *ast.MatchExpression:
+		return evalMatchExpression(node, env)
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
 	case *ast.WhileStatement:
 		return evalWhileStatement(node, env)
+	case *ast.ForStatement:
+		return evalForStatement(node, env)
+	case *ast.ForInStatement:
+		return evalForInStatement(node, env)
+	case *ast.BreakStatement:
+		return object.BreakObject
+	case *ast.ContinueStatement:
+		return object.ContinueObject
 	case *ast.LetStatement:
 		val := Eval(node.Value, env)
-		if object.IsErr(val) {
+		if object.IsAbrupt(val) {
 			return val
 		}
 
+		if node.Pattern != nil {
+			err := object.Match(node.Pattern, val, env, func(name string, val object.Object) {
+				env.Set(name, val)
+			})
+			if err != nil {
+				return err
+			}
+
+			return nil
+		}
+
+		if fn, ok := val.(*object.Function); ok && fn.Name == nil {
+			// name anonymous functions after the variable they are bound to
+			fn.Name = node.Name
+		}
+
 		env.Set(node.Name.Value, val)
 		return nil
 	case *ast.FunctionStatement: // named func stmt
@@ -390,41 +704,18 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.FunctionLiteral: // anon func expr
 		return object.NewFunctionObject(nil, node.Parameters, node.Body, env)
 	case *ast.ClassStatement:
-		cls := object.NewClassObject(node.Name, node.Methods, env)
-		env.Set(node.Name.Value, cls)
-		return nil
-	case *ast.GetExpression:
-		obj := Eval(node.Left, env)
-		if object.IsErr(obj) {
-			return obj
-		}
-
-		inst, ok := obj.(*object.Instance)
-		if !ok {
-			return object.NewErr("object %s has no properties", obj.Type())
-		}
-
-		val := inst.Get(node.Right.String())
-		if val == nil {
-			return object.NewErr("object %s has no property %s", inst.Inspect(), node.Right.String())
-		}
-
-		return val
-	case *ast.CallExpression:
-		f := Eval(node.Function, env)
-		if object.IsErr(f) {
-			return f
-		}
-
-		args := evalExpressions(node.Arguments, env)
-		if len(args) > 0 && object.IsErr(args[0]) {
-			return args[0]
-		}
-
-		return applyFunc(f, args)
+		return evalClassStatement(node, env)
+	case *ast.ImportStatement:
+		return evalImportStatement(node, env)
+	case *ast.ExportStatement:
+		return evalExportStatement(node, env)
+	case *ast.ThrowStatement:
+		return evalThrowStatement(node, env)
+	case *ast.TryStatement:
+		return evalTryStatement(node, env)
 	case *ast.ReturnStatement:
 		val := Eval(node.Value, env)
-		if object.IsErr(val) {
+		if object.IsAbrupt(val) {
 			return val
 		}
 		return object.NewReturnVal(val)
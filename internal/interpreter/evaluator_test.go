@@ -1,9 +1,12 @@
 package interpreter_test
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/donovandicks/gomonkey/internal/ast"
 	"github.com/donovandicks/gomonkey/internal/interpreter"
 	"github.com/donovandicks/gomonkey/internal/lexer"
 	"github.com/donovandicks/gomonkey/internal/object"
@@ -69,6 +72,41 @@ func TestEvaluator(t *testing.T) {
 			input:  "5 / 5",
 			output: object.NewIntegerObject(1),
 		},
+		{
+			name:   "float literal",
+			input:  "3.14",
+			output: object.NewFloatObject(3.14),
+		},
+		{
+			name:   "minus operator: float literal",
+			input:  "-2.5",
+			output: object.NewFloatObject(-2.5),
+		},
+		{
+			name:   "infix expression: float arithmetic",
+			input:  "1.5 * 4.0 - 0.5",
+			output: object.NewFloatObject(5.5),
+		},
+		{
+			name:   "infix expression: mixed division promotes to float",
+			input:  "7 / 2.0",
+			output: object.NewFloatObject(3.5),
+		},
+		{
+			name:   "infix expression: percentage",
+			input:  "let pct = fn(part, whole) { part * 100.0 / whole }; pct(1, 8)",
+			output: object.NewFloatObject(12.5),
+		},
+		{
+			name:   "comparison: mixed integer and float",
+			input:  "1 < 1.5",
+			output: object.TrueBool,
+		},
+		{
+			name:   "comparison: integer equals integral float",
+			input:  "2 == 2.0",
+			output: object.TrueBool,
+		},
 		{
 			name:   "comparison: integer less than",
 			input:  "5 < 6",
@@ -104,6 +142,46 @@ func TestEvaluator(t *testing.T) {
 			input:  "(1 < 2) == true",
 			output: object.TrueBool,
 		},
+		{
+			name:   "comparison: less than or equal",
+			input:  "[1 <= 1, 2 <= 1, 1.5 <= 2]",
+			output: object.NewListObject([]object.Object{object.TrueBool, object.FalseBool, object.TrueBool}),
+		},
+		{
+			name:   "comparison: greater than or equal",
+			input:  "[1 >= 1, 1 >= 2, 2 >= 1.5]",
+			output: object.NewListObject([]object.Object{object.TrueBool, object.FalseBool, object.TrueBool}),
+		},
+		{
+			name:   "infix expression: modulo",
+			input:  "[7 % 3, -7 % 3, 7.5 % 2]",
+			output: object.NewListObject([]object.Object{object.NewIntegerObject(1), object.NewIntegerObject(-1), object.NewFloatObject(1.5)}),
+		},
+		{
+			name:   "infix expression: exponentiation",
+			input:  "[2 ** 10, 2 ** 3 ** 2, 2 ** -1, -2 ** 2]",
+			output: object.NewListObject([]object.Object{object.NewIntegerObject(1024), object.NewIntegerObject(512), object.NewFloatObject(0.5), object.NewIntegerObject(-4)}),
+		},
+		{
+			name:   "infix expression: bitwise operators",
+			input:  "[6 & 3, 6 | 3, 6 ^ 3, ~6, 1 << 4, -16 >> 2]",
+			output: object.NewListObject([]object.Object{object.NewIntegerObject(2), object.NewIntegerObject(7), object.NewIntegerObject(5), object.NewIntegerObject(-7), object.NewIntegerObject(16), object.NewIntegerObject(-4)}),
+		},
+		{
+			name:   "logical operators",
+			input:  "[true && 1, true && 0 == 1, false || 1, false || false]",
+			output: object.NewListObject([]object.Object{object.TrueBool, object.FalseBool, object.TrueBool, object.FalseBool}),
+		},
+		{
+			name:   "logical operators: short circuit",
+			input:  "let calls = 0; let f = fn() { calls = calls + 1; true }; false && f(); true || f(); calls",
+			output: object.NewIntegerObject(0),
+		},
+		{
+			name:   "logical operators: evaluate the right operand when needed",
+			input:  "let calls = 0; let f = fn() { calls = calls + 1; true }; true && f(); false || f(); calls",
+			output: object.NewIntegerObject(2),
+		},
 		{
 			name:   "if expression: truthy condition",
 			input:  "if (10) { 10 } else { -10 }",
@@ -124,6 +202,108 @@ func TestEvaluator(t *testing.T) {
 			input:  "if (false) { 10 }",
 			output: object.NullObject,
 		},
+		{
+			name:   "if expression: else if chain",
+			input:  `let f = fn(n) { if (n < 0) { "neg" } else if (n == 0) { "zero" } else if (n < 10) { "small" } else { "big" } }; [f(-1), f(0), f(5), f(50)]`,
+			output: object.NewListObject([]object.Object{object.NewStringObject("neg"), object.NewStringObject("zero"), object.NewStringObject("small"), object.NewStringObject("big")}),
+		},
+		{
+			name:   "if expression: else if without else",
+			input:  "if (false) { 1 } else if (false) { 2 }",
+			output: object.NullObject,
+		},
+		{
+			name:  "match expression: literals",
+			input: `let f = fn(x) { match (x) { 0 => "zero", -1 => "minus one", "a" => "letter", true => "yes", null => "none", _ => "other" } }; [f(0), f(-1), f("a"), f(true), f(null), f(2)]`,
+			output: object.NewListObject([]object.Object{
+				object.NewStringObject("zero"),
+				object.NewStringObject("minus one"),
+				object.NewStringObject("letter"),
+				object.NewStringObject("yes"),
+				object.NewStringObject("none"),
+				object.NewStringObject("other"),
+			}),
+		},
+		{
+			name:   "match expression: lists",
+			input:  "let f = fn(xs) { match (xs) { [] => 0, [x] => x, [x, y, ...rest] => x + y + len(rest) } }; [f([]), f([5]), f([1, 2]), f([1, 2, 3, 4])]",
+			output: object.NewListObject([]object.Object{object.NewIntegerObject(0), object.NewIntegerObject(5), object.NewIntegerObject(3), object.NewIntegerObject(5)}),
+		},
+		{
+			name:   "match expression: maps",
+			input:  `let f = fn(shape) { match (shape) { {"kind": "square", side} => side * side, {kind: "circle", center: [x, y]} => x + y } }; [f({"kind": "square", "side": 3}), f({"kind": "circle", "center": [1, 2]})]`,
+			output: object.NewListObject([]object.Object{object.NewIntegerObject(9), object.NewIntegerObject(3)}),
+		},
+		{
+			name:   "match expression: guards",
+			input:  `let f = fn(n) { match (n) { [x] if x > 0 => x, [x] => 0, {"n": x} if x > 0 => x, {"n": x} => 0, 0 if false => 1, x if x > 9 => 9, _ => -1 } }; [f([2]), f([-2]), f({"n": 5}), f({"n": -5}), f(0), f(12)]`,
+			output: object.NewListObject([]object.Object{object.NewIntegerObject(2), object.NewIntegerObject(0), object.NewIntegerObject(5), object.NewIntegerObject(0), object.NewIntegerObject(-1), object.NewIntegerObject(9)}),
+		},
+		{
+			name:   "match expression: no matching arm",
+			input:  "match (1) { 2 => 2 }",
+			output: object.NullObject,
+		},
+		{
+			name:   "match expression: boolean map key",
+			input:  `match ({"a": 1}) { {true: x} => x, _ => 0 }`,
+			output: object.NewErr("cannot index map using non-hashable type BOOLEAN"),
+		},
+		{
+			name:   "null literal",
+			input:  "let x = null; [x == null, x != null]",
+			output: object.NewListObject([]object.Object{object.TrueBool, object.FalseBool}),
+		},
+		{
+			name:   "null literal: type",
+			input:  "1 + null",
+			output: object.NewErr("type error: cannot perform '+' on INTEGER, NULL"),
+		},
+		{
+			name:   "null coalescing",
+			input:  "[null ?? 1, 0 ?? 1, false ?? 1]",
+			output: object.NewListObject([]object.Object{object.NewIntegerObject(1), object.NewIntegerObject(0), object.FalseBool}),
+		},
+		{
+			name:   "null coalescing: default is only evaluated for null",
+			input:  "let n = 0; let f = fn() { n = n + 1; }; 1 ?? f(); null ?? f(); n",
+			output: object.NewIntegerObject(1),
+		},
+		{
+			name:   "optional index: present key",
+			input:  `let config = {"port": 80}; config?.["port"] ?? 8080`,
+			output: object.NewIntegerObject(80),
+		},
+		{
+			name:   "optional index: missing key",
+			input:  `let config = {"name": "app"}; config?.["port"] ?? 8080`,
+			output: object.NewIntegerObject(8080),
+		},
+		{
+			name:   "optional index: out of bounds",
+			input:  "[1, 2]?.[5]",
+			output: object.NullObject,
+		},
+		{
+			name:   "optional index: short-circuits on null",
+			input:  "let n = 0; let f = fn() { n = n + 1; 0 }; null?.[f()]; n",
+			output: object.NewIntegerObject(0),
+		},
+		{
+			name:   "optional chain: short-circuits the rest of the chain",
+			input:  "let n = 0; let g = fn() { n = n + 1 }; let m = null; [m?.a.b, m?.[0][1], m?.f(g()), n]",
+			output: object.NewListObject([]object.Object{object.NullObject, object.NullObject, object.NullObject, object.NewIntegerObject(0)}),
+		},
+		{
+			name:   "optional chain: missing property",
+			input:  "let m = {}; m?.a.b",
+			output: object.NewErr("object NULL has no properties"),
+		},
+		{
+			name:   "optional index: type error",
+			input:  "let x = 1; x?.[0]",
+			output: object.NewErr("cannot index INTEGER object"),
+		},
 		{
 			name:   "return: top-level",
 			input:  "return 5;",
@@ -167,6 +347,31 @@ func TestEvaluator(t *testing.T) {
 			input:  "let x = 5; let y = 10; x + y;",
 			output: object.NewIntegerObject(15),
 		},
+		{
+			name:   "compound assignment: identifiers",
+			input:  "let x = 10; x += 5; x -= 3; x *= 4; x /= 6; x %= 5; x",
+			output: object.NewIntegerObject(3),
+		},
+		{
+			name:   "compound assignment: value of the expression",
+			input:  "let x = 1; let y = (x += 2) * 10; [x, y]",
+			output: object.NewListObject([]object.Object{object.NewIntegerObject(3), object.NewIntegerObject(30)}),
+		},
+		{
+			name:   "index assignment: list",
+			input:  "let xs = [1, 2, 3]; xs[1] = 20; xs[-1] += 1; xs",
+			output: object.NewListObject([]object.Object{object.NewIntegerObject(1), object.NewIntegerObject(20), object.NewIntegerObject(4)}),
+		},
+		{
+			name:   "index assignment: map",
+			input:  `let m = {"a": 1}; m["a"] = 2; m["b"] = 3; m["a"] + m["b"]`,
+			output: object.NewIntegerObject(5),
+		},
+		{
+			name:   "index assignment: out of bounds",
+			input:  "let xs = [1]; xs[3] = 1",
+			output: object.NewErr("index out of bounds: 3"),
+		},
 		{
 			name:   "function calls: top-level",
 			input:  "let res = fn(x) {return x + 1;}(1); res;",
@@ -229,6 +434,16 @@ func TestEvaluator(t *testing.T) {
 			input:  `"hello" + " " + "world"`,
 			output: object.NewStringObject("hello world"),
 		},
+		{
+			name:   "strings: escape sequences",
+			input:  `"say \"hi\"\n" + "\u{263A}"`,
+			output: object.NewStringObject("say \"hi\"\n\u263A"),
+		},
+		{
+			name:   "strings: raw multi-line",
+			input:  "let json = `{\n  \"name\": \"monkey\"\n}`; len(json)",
+			output: object.NewIntegerObject(22),
+		},
 		{
 			name: "while statement: counter",
 			input: `let x = 0;
@@ -239,6 +454,150 @@ func TestEvaluator(t *testing.T) {
 			`,
 			output: object.NewIntegerObject(3),
 		},
+		{
+			name: "for statement: counter",
+			input: `let sum = 0;
+			for (let i = 0; i < 4; i = i + 1) {
+				sum = sum + i;
+			}
+			return sum;
+			`,
+			output: object.NewIntegerObject(6),
+		},
+		{
+			name: "for statement: return from body",
+			input: `let find = fn(xs, target) {
+				for (let i = 0; i < len(xs); i = i + 1) {
+					if (xs[i] == target) {
+						return i;
+					}
+				}
+				return -1;
+			};
+			find([5, 6, 7], 6);
+			`,
+			output: object.NewIntegerObject(1),
+		},
+		{
+			name: "for in statement: list",
+			input: `let sum = 0;
+			for (x in [1, 2, 3]) {
+				sum = sum + x;
+			}
+			return sum;
+			`,
+			output: object.NewIntegerObject(6),
+		},
+		{
+			name: "for in statement: map keys",
+			input: `let keys = "";
+			for (k in {"b": 1, 10: 2, "a": 3, 2: 4, 1.5: 5}) {
+				keys = keys + str(k) + " ";
+			}
+			return keys;
+			`,
+			output: object.NewStringObject("1.5 2 10 a b "),
+		},
+		{
+			name: "for in statement: string",
+			input: `let rev = "";
+			for (ch in "abc") {
+				rev = ch + rev;
+			}
+			return rev;
+			`,
+			output: object.NewStringObject("cba"),
+		},
+		{
+			name: "while statement: break",
+			input: `let x = 0;
+			while (true) {
+				x = x + 1;
+				if (x == 5) {
+					break;
+				}
+			}
+			return x;
+			`,
+			output: object.NewIntegerObject(5),
+		},
+		{
+			name: "while statement: return inside function",
+			input: `let firstOver = fn(xs, limit) {
+				let i = 0;
+				while (i < len(xs)) {
+					if (xs[i] > limit) {
+						return xs[i];
+					}
+					i = i + 1;
+				}
+				return -1;
+			};
+			firstOver([1, 5, 9], 4);
+			`,
+			output: object.NewIntegerObject(5),
+		},
+		{
+			name: "for statement: continue",
+			input: `let sum = 0;
+			for (let i = 0; i < 6; i = i + 1) {
+				if (i == 2) {
+					continue;
+				}
+				sum = sum + i;
+			}
+			return sum;
+			`,
+			output: object.NewIntegerObject(13),
+		},
+		{
+			name: "for in statement: break",
+			input: `let last = 0;
+			for (x in [1, 2, 3, 4]) {
+				if (x > 2) {
+					break;
+				}
+				last = x;
+			}
+			return last;
+			`,
+			output: object.NewIntegerObject(2),
+		},
+		{
+			name: "nested loops: break exits the inner loop only",
+			input: `let count = 0;
+			for (i in [1, 2, 3]) {
+				for (j in [1, 2, 3]) {
+					if (j == 2) {
+						break;
+					}
+					count = count + 1;
+				}
+			}
+			return count;
+			`,
+			output: object.NewIntegerObject(3),
+		},
+		{
+			name:   "loop control in expressions: continue in a list",
+			input:  "let n = 0; for (x in [1, 2, 3]) { let y = [1, if (x == 2) { continue; } else { 2 }]; n += 1; } n",
+			output: object.NewIntegerObject(2),
+		},
+		{
+			name:   "loop control in expressions: break in an operand",
+			input:  "let n = 0; while (n < 5) { n = n + if (true) { break; } else { 1 }; } n",
+			output: object.NewIntegerObject(0),
+		},
+		{
+			name:   "loop control in expressions: return in an operand",
+			input:  "let f = fn() { let x = 1 + if (true) { return 5; } else { 0 }; x }; f()",
+			output: object.NewIntegerObject(5),
+		},
+		{
+			name:   "loop control in expressions: outside of loop",
+			input:  "1 + if (true) { break; }",
+			output: object.NewErr("'break' outside of loop"),
+		},
 		{
 			name:   "builtin: len:: empty",
 			input:  `len("")`,
@@ -300,11 +659,51 @@ func TestEvaluator(t *testing.T) {
 			input:  `{1: "a"}[1]`,
 			output: object.NewStringObject("a"),
 		},
+		{
+			name:   "map index expression: float key",
+			input:  `{0.5: "half"}[1 / 2.0]`,
+			output: object.NewStringObject("half"),
+		},
 		{
 			name:   "map index expression: expression key",
 			input:  `{3: "three"}[1+2]`,
 			output: object.NewStringObject("three"),
 		},
+		{
+			name:   "destructuring: list",
+			input:  "let [a, b] = [1, 2]; a * 10 + b",
+			output: object.NewIntegerObject(12),
+		},
+		{
+			name:   "destructuring: rest of a list",
+			input:  "let [first, ...rest] = [1, 2, 3]; len(rest) * 10 + first",
+			output: object.NewIntegerObject(21),
+		},
+		{
+			name:   "destructuring: map",
+			input:  `let {x, y} = {"x": 1, "y": 2}; x + y`,
+			output: object.NewIntegerObject(3),
+		},
+		{
+			name:   "destructuring: nested patterns",
+			input:  `let {point: [x, y], name} = {"point": [3, 4], "name": "p"}; name + str(x * y)`,
+			output: object.NewStringObject("p12"),
+		},
+		{
+			name:   "destructuring: function parameters",
+			input:  `let f = fn([a, b], {c}) { a + b + c }; f([1, 2], {"c": 3})`,
+			output: object.NewIntegerObject(6),
+		},
+		{
+			name:   "destructuring: mismatch",
+			input:  "let [a, b] = [1, 2, 3];",
+			output: object.NewErr("expected a list of 2 elements, got 3"),
+		},
+		{
+			name:   "destructuring: boolean key",
+			input:  `let {true: x} = {"a": 1};`,
+			output: object.NewErr("cannot index map using non-hashable type BOOLEAN"),
+		},
 	}
 
 	for _, testCase := range cases {
@@ -320,7 +719,7 @@ func TestEvaluator(t *testing.T) {
 			}
 			env := object.NewEnv()
 
-			assert.Equal(t, tc.output, interpreter.Eval(prog, env))
+			assertObject(t, tc.output, interpreter.Eval(prog, env))
 		})
 	}
 }
@@ -353,6 +752,26 @@ func TestEvaluator_Errors(t *testing.T) {
 			input: "x;",
 			err:   &object.Err{Msg: "undefined variable 'x'"},
 		},
+		{
+			name:  "for in statement: non-iterable",
+			input: "for (x in 5) { x; }",
+			err:   &object.Err{Msg: "cannot iterate over INTEGER"},
+		},
+		{
+			name:  "while statement: error in body",
+			input: "while (true) { y; }",
+			err:   &object.Err{Msg: "undefined variable 'y'"},
+		},
+		{
+			name:  "break: outside of loop",
+			input: "break;",
+			err:   &object.Err{Msg: "'break' outside of loop"},
+		},
+		{
+			name:  "continue: outside of loop in function",
+			input: "let f = fn() { continue; }; f();",
+			err:   &object.Err{Msg: "'continue' outside of loop"},
+		},
 		{
 			name:  "list index expression: out of bounds",
 			input: "[1, 2, 3][4]",
@@ -370,12 +789,12 @@ func TestEvaluator_Errors(t *testing.T) {
 			prog := p.ParseProgram()
 			env := object.NewEnv()
 
-			assert.Equal(t, tc.err, interpreter.Eval(prog, env))
+			assertObject(t, tc.err, interpreter.Eval(prog, env))
 		})
 	}
 }
 
-func TestEvaluator_FullPrograms(t *testing.T) {
+func TestEvaluator_Scoping(t *testing.T) {
 	t.Parallel()
 
 	cases := []struct {
@@ -384,20 +803,93 @@ func TestEvaluator_FullPrograms(t *testing.T) {
 		output object.Object
 	}{
 		{
-			name: "object oriented",
+			name: "closure assigns to enclosing variable",
 			input: `
-			class Item{
-				init(price) {
-					inst.price = price;
-				}
-			}
-
-			let pencil = Item(10)
-			let erasor = Item(5)
-
-			return pencil.price + erasor.price;
-			`,
-			output: object.NewIntegerObject(15),
+			let counter = fn() {
+				let n = 0;
+				return fn() { n = n + 1; n };
+			};
+			let next = counter();
+			next(); next(); next()`,
+			output: object.NewIntegerObject(3),
+		},
+		{
+			name: "counters do not share state",
+			input: `
+			let counter = fn() { let n = 0; return fn() { n = n + 1; n }; };
+			let a = counter();
+			let b = counter();
+			a(); a(); b()`,
+			output: object.NewIntegerObject(1),
+		},
+		{
+			name: "accumulating in a loop",
+			input: `
+			let total = 0;
+			let add = fn(x) { total = total + x; };
+			for (x in [1, 2, 3]) { add(x); }
+			total`,
+			output: object.NewIntegerObject(6),
+		},
+		{
+			name:   "assignment updates the innermost binding",
+			input:  `let x = 1; let f = fn() { let x = 2; x = 3; x }; f() + x`,
+			output: object.NewIntegerObject(4),
+		},
+		{
+			name:   "assignment to undefined variable",
+			input:  `let f = fn() { y = 1; }; f()`,
+			output: &object.Err{Msg: "undefined variable 'y'"},
+		},
+		{
+			name:   "if body shadows",
+			input:  `let x = 1; if (true) { let x = 2; }; x`,
+			output: object.NewIntegerObject(1),
+		},
+		{
+			name:   "if body bindings are not visible outside",
+			input:  `if (true) { let y = 2; }; y`,
+			output: &object.Err{Msg: "undefined variable 'y'"},
+		},
+		{
+			name:   "else body bindings are not visible outside",
+			input:  `if (false) { 1 } else { let y = 2; }; y`,
+			output: &object.Err{Msg: "undefined variable 'y'"},
+		},
+		{
+			name:   "while body shadows",
+			input:  `let x = 1; let i = 0; while (i < 2) { let x = i; i = i + 1; } x + i`,
+			output: object.NewIntegerObject(3),
+		},
+		{
+			name:   "while body bindings are fresh each iteration",
+			input:  `let i = 0; while (i < 2) { if (i == 1) { return y; } let y = i; i = i + 1; }`,
+			output: &object.Err{Msg: "undefined variable 'y'"},
+		},
+		{
+			name:   "for loop variable is scoped to the loop",
+			input:  `for (let i = 0; i < 3; i = i + 1) { i } i`,
+			output: &object.Err{Msg: "undefined variable 'i'"},
+		},
+		{
+			name:   "for in variable is scoped to the loop",
+			input:  `for (x in [1, 2]) { x } x`,
+			output: &object.Err{Msg: "undefined variable 'x'"},
+		},
+		{
+			name: "loop closures capture each iteration",
+			input: `
+			let f = fn() { 0 };
+			for (x in [1, 2, 3]) { if (x == 2) { f = fn() { x }; } }
+			f()`,
+			output: object.NewIntegerObject(2),
+		},
+		{
+			name: "catch binding is scoped to the catch block",
+			input: `
+			try { throw "a"; } catch (e) { 1 }
+			e`,
+			output: &object.Err{Msg: "undefined variable 'e'"},
 		},
 	}
 
@@ -406,12 +898,701 @@ func TestEvaluator_FullPrograms(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			l := lexer.NewLexer(tc.input)
-			p := parser.NewParser(l)
+			p := parser.NewParser(lexer.NewLexer(tc.input))
 			prog := p.ParseProgram()
-			env := object.NewEnv()
+			assert.Empty(t, p.Errors())
 
-			assert.Equal(t, tc.output, interpreter.Eval(prog, env))
+			assertObject(t, tc.output, interpreter.Eval(prog, object.NewEnv()))
 		})
 	}
 }
+
+func TestEvaluator_TryCatch(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		input  string
+		output object.Object
+	}{
+		{
+			name:   "catch thrown value",
+			input:  `try { throw "boom"; } catch (e) { e.message }`,
+			output: object.NewStringObject("boom"),
+		},
+		{
+			name:   "thrown value is preserved",
+			input:  `try { throw [1, 2]; } catch (e) { (e.value)[1] }`,
+			output: object.NewIntegerObject(2),
+		},
+		{
+			name:   "thrown value kind",
+			input:  `try { throw 1; } catch (e) { e.kind }`,
+			output: object.NewStringObject("Error"),
+		},
+		{
+			name:   "catch index error",
+			input:  `try { [1, 2][5] } catch (e) { e.kind + ": " + e.message }`,
+			output: object.NewStringObject("IndexError: index out of bounds: 5"),
+		},
+		{
+			name:   "catch invalid assignment target",
+			input:  `try { 1 = 2 } catch (e) { e.kind + ": " + e.message }`,
+			output: object.NewStringObject("TypeError: cannot assign to 1"),
+		},
+		{
+			name:   "catch division by zero",
+			input:  `try { 1 / 0 } catch (e) { e.kind + ": " + e.message }`,
+			output: object.NewStringObject("ZeroDivisionError: division by zero"),
+		},
+		{
+			name:   "catch type error",
+			input:  `try { 1 + "a" } catch (e) { e.kind }`,
+			output: object.NewStringObject("TypeError"),
+		},
+		{
+			name:   "catch undefined variable",
+			input:  `try { missing } catch (e) { e.kind }`,
+			output: object.NewStringObject("NameError"),
+		},
+		{
+			name:   "catch missing key",
+			input:  `try { {"a": 1}["b"] } catch (e) { e.kind }`,
+			output: object.NewStringObject("KeyError"),
+		},
+		{
+			name:   "catch builtin error",
+			input:  `try { len(1) } catch (e) { e.kind }`,
+			output: object.NewStringObject("TypeError"),
+		},
+		{
+			name: "catch error from nested call",
+			input: `
+			let inner = fn() { throw "deep"; };
+			let outer = fn() { inner() };
+			try { outer() } catch (e) { len(e.trace) }`,
+			output: object.NewIntegerObject(3),
+		},
+		{
+			name:   "no error skips catch",
+			input:  `try { 1 } catch (e) { 2 }`,
+			output: object.NewIntegerObject(1),
+		},
+		{
+			name:   "finally runs after catch",
+			input:  `try { throw "a"; } catch (e) { 1 } finally { throw "b"; }`,
+			output: &object.Err{Msg: "b"},
+		},
+		{
+			name:   "finally does not replace result",
+			input:  `try { throw "a"; } catch (e) { 1 } finally { 2 }`,
+			output: object.NewIntegerObject(1),
+		},
+		{
+			name: "return from catch",
+			input: `
+			let f = fn() { try { throw "a"; } catch (e) { return e.message; } return "none"; };
+			f()`,
+			output: object.NewStringObject("a"),
+		},
+		{
+			name: "finally overrides return",
+			input: `
+			let f = fn() { try { return 1; } finally { return 2; } };
+			f()`,
+			output: object.NewIntegerObject(2),
+		},
+		{
+			name: "rethrow preserves kind",
+			input: `
+			try {
+				try { [][0] } catch (e) { throw e; }
+			} catch (e) { e.kind }`,
+			output: object.NewStringObject("IndexError"),
+		},
+		{
+			name:   "uncaught error passes through finally",
+			input:  `try { throw "a"; } finally { 1 }`,
+			output: &object.Err{Msg: "a"},
+		},
+		{
+			name:   "error in catch propagates",
+			input:  `try { throw "a"; } catch (e) { throw "b"; }`,
+			output: &object.Err{Msg: "b"},
+		},
+		{
+			name:   "unknown exception property",
+			input:  `try { throw "a"; } catch (e) { e.foo }`,
+			output: &object.Err{Msg: "object EXCEPTION has no property foo"},
+		},
+	}
+
+	for _, testCase := range cases {
+		tc := testCase
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			l := lexer.NewLexer(tc.input)
+			p := parser.NewParser(l)
+			prog := p.ParseProgram()
+			assert.Empty(t, p.Errors())
+
+			assertObject(t, tc.output, interpreter.Eval(prog, object.NewEnv()))
+		})
+	}
+}
+
+func TestEvaluator_CaughtTrace(t *testing.T) {
+	t.Parallel()
+
+	input := `fn fail() {
+	throw "boom"
+}
+try {
+	fail()
+} catch (e) {
+	e.trace
+}`
+
+	p := parser.NewParser(lexer.NewFileLexer("catch.monkey", input))
+	prog := p.ParseProgram()
+
+	res := interpreter.Eval(prog, object.NewEnv())
+	expected := object.NewListObject([]object.Object{
+		object.NewStringObject("catch.monkey:5:2 in <main>"),
+		object.NewStringObject("catch.monkey:2:2 in fail"),
+	})
+	assert.Equal(t, expected, res)
+}
+
+func TestEvaluator_Inheritance(t *testing.T) {
+	t.Parallel()
+
+	classes := `
+	class Animal {
+		init(name) {
+			inst.name = name;
+		}
+		speak() {
+			return inst.name + " makes a sound";
+		}
+		describe() {
+			return inst.speak();
+		}
+	}
+
+	class Dog extends Animal {
+		init(name, breed) {
+			super.init(name);
+			inst.breed = breed;
+		}
+		speak() {
+			return inst.name + " barks";
+		}
+	}
+
+	class Puppy extends Dog {
+		speak() {
+			return super.speak() + " softly";
+		}
+	}
+	`
+
+	cases := []struct {
+		name   string
+		input  string
+		output object.Object
+	}{
+		{
+			name:   "inherited method",
+			input:  `let a = Animal("cat"); a.speak()`,
+			output: object.NewStringObject("cat makes a sound"),
+		},
+		{
+			name:   "overridden method",
+			input:  `let d = Dog("rex", "lab"); d.speak()`,
+			output: object.NewStringObject("rex barks"),
+		},
+		{
+			name:   "init chaining",
+			input:  `let d = Dog("rex", "lab"); d.name + " " + d.breed`,
+			output: object.NewStringObject("rex lab"),
+		},
+		{
+			name:   "inherited init",
+			input:  `let p = Puppy("bit", "pug"); p.breed`,
+			output: object.NewStringObject("pug"),
+		},
+		{
+			name:   "super calls the parent method",
+			input:  `let p = Puppy("bit", "pug"); p.speak()`,
+			output: object.NewStringObject("bit barks softly"),
+		},
+		{
+			name:   "parent method dispatches to override",
+			input:  `let p = Puppy("bit", "pug"); p.describe()`,
+			output: object.NewStringObject("bit barks softly"),
+		},
+		{
+			name:   "instanceof own class",
+			input:  `Dog("rex", "lab") instanceof Dog`,
+			output: object.TrueBool,
+		},
+		{
+			name:   "instanceof ancestor",
+			input:  `Puppy("bit", "pug") instanceof Animal`,
+			output: object.TrueBool,
+		},
+		{
+			name:   "instanceof descendant",
+			input:  `Animal("cat") instanceof Dog`,
+			output: object.FalseBool,
+		},
+		{
+			name:   "instanceof non-instance",
+			input:  `1 instanceof Animal`,
+			output: object.FalseBool,
+		},
+		{
+			name:   "instanceof non-class",
+			input:  `Animal("cat") instanceof 1`,
+			output: &object.Err{Msg: "right operand of 'instanceof' must be a class, got INTEGER"},
+		},
+		{
+			name:   "extending a non-class",
+			input:  `let x = 1; class Y extends x {}`,
+			output: &object.Err{Msg: "cannot extend non-class type INTEGER"},
+		},
+		{
+			name:   "super without the method",
+			input:  `class Cat extends Animal { purr() { return super.purr(); } } Cat("tom").purr()`,
+			output: &object.Err{Msg: "object SUPER has no property purr"},
+		},
+		{
+			name:  "match class patterns",
+			input: `let f = fn(x) { match (x) { Puppy{} => "puppy", Dog{name} => name + " the dog", Animal{} => "animal", _ => "other" } }; [f(Puppy("a", "pug")), f(Dog("rex", "lab")), f(Animal("cat")), f(1)]`,
+			output: object.NewListObject([]object.Object{
+				object.NewStringObject("puppy"),
+				object.NewStringObject("rex the dog"),
+				object.NewStringObject("animal"),
+				object.NewStringObject("other"),
+			}),
+		},
+		{
+			name:   "match an undefined class",
+			input:  `match (1) { Cat{} => 1 }`,
+			output: &object.Err{Msg: "undefined variable 'Cat'"},
+		},
+		{
+			name:   "destructure instances",
+			input:  `let Dog{name, breed} = Puppy("bit", "pug"); name + " the " + breed`,
+			output: object.NewStringObject("bit the pug"),
+		},
+		{
+			name:   "destructure an instance of another class",
+			input:  `let Dog{name} = Animal("cat");`,
+			output: &object.Err{Msg: "expected an instance of Dog, got INSTANCE"},
+		},
+		{
+			name:   "undefined property",
+			input:  `Animal("cat").age`,
+			output: &object.Err{Msg: "object INSTANCE has no property age"},
+		},
+		{
+			name:   "optional property",
+			input:  `let cat = Animal("cat"); [cat?.name, cat?.age ?? 0]`,
+			output: object.NewListObject([]object.Object{object.NewStringObject("cat"), object.NewIntegerObject(0)}),
+		},
+		{
+			name:   "super outside of a subclass",
+			input:  `Animal("cat"); super.speak()`,
+			output: &object.Err{Msg: "undefined variable 'super'"},
+		},
+		{
+			name:   "errors in init propagate",
+			input:  `class Bad { init() { missing; } } Bad()`,
+			output: &object.Err{Msg: "undefined variable 'missing'"},
+		},
+	}
+
+	for _, testCase := range cases {
+		tc := testCase
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := parser.NewParser(lexer.NewLexer(classes + tc.input))
+			prog := p.ParseProgram()
+			assert.Empty(t, p.Errors())
+
+			assertObject(t, tc.output, interpreter.Eval(prog, object.NewEnv()))
+		})
+	}
+}
+
+func TestEvaluator_Overloading(t *testing.T) {
+	t.Parallel()
+
+	classes := `
+	class Vec {
+		init(x, y) {
+			inst.x = x;
+			inst.y = y;
+		}
+		add(other) {
+			return Vec(inst.x + other.x, inst.y + other.y);
+		}
+		mul(k) {
+			return Vec(inst.x * k, inst.y * k);
+		}
+		eq(other) {
+			return inst.x == other.x;
+		}
+		lt(other) {
+			return inst.x < other.x;
+		}
+		le(other) {
+			return inst.x <= other.x;
+		}
+		mod(k) {
+			return Vec(inst.x % k, inst.y % k);
+		}
+		index(i) {
+			if (i == 0) { return inst.x; }
+			return inst.y;
+		}
+		len() {
+			return 2;
+		}
+		str() {
+			return "Vec(" + str(inst.x) + ", " + str(inst.y) + ")";
+		}
+	}
+
+	class Plain {}
+	`
+
+	cases := []struct {
+		name   string
+		input  string
+		output object.Object
+	}{
+		{
+			name:   "add",
+			input:  `str(Vec(1, 2) + Vec(3, 4))`,
+			output: object.NewStringObject("Vec(4, 6)"),
+		},
+		{
+			name:   "mul with a non-instance operand",
+			input:  `str(Vec(1, 2) * 3)`,
+			output: object.NewStringObject("Vec(3, 6)"),
+		},
+		{
+			name:   "eq",
+			input:  `Vec(1, 2) == Vec(1, 5)`,
+			output: object.TrueBool,
+		},
+		{
+			name:   "not eq",
+			input:  `Vec(1, 2) != Vec(1, 5)`,
+			output: object.FalseBool,
+		},
+		{
+			name:   "compound assignment",
+			input:  `let v = Vec(1, 2); v.x += 10; v += Vec(1, 1); str(v)`,
+			output: object.NewStringObject("Vec(12, 3)"),
+		},
+		{
+			name:   "mod",
+			input:  `str(Vec(5, 7) % 3)`,
+			output: object.NewStringObject("Vec(2, 1)"),
+		},
+		{
+			name:   "le",
+			input:  `Vec(1, 2) <= Vec(1, 0)`,
+			output: object.TrueBool,
+		},
+		{
+			name:   "reflected ge",
+			input:  `Vec(0, 0) >= Vec(1, 0)`,
+			output: object.FalseBool,
+		},
+		{
+			name:   "lt",
+			input:  `Vec(1, 2) < Vec(2, 0)`,
+			output: object.TrueBool,
+		},
+		{
+			name:   "gt is reflected lt",
+			input:  `Vec(1, 2) > Vec(2, 0)`,
+			output: object.FalseBool,
+		},
+		{
+			name:   "index",
+			input:  `let v = Vec(7, 8); v[0] + v[1]`,
+			output: object.NewIntegerObject(15),
+		},
+		{
+			name:   "len",
+			input:  `len(Vec(0, 0))`,
+			output: object.NewIntegerObject(2),
+		},
+		{
+			name:   "without overloads instances compare by identity",
+			input:  `let p = Plain(); p == p`,
+			output: object.TrueBool,
+		},
+		{
+			name:   "without overloads operators fail",
+			input:  `Plain() + Plain()`,
+			output: &object.Err{Msg: "unknown operator '+' for types INSTANCE, INSTANCE"},
+		},
+		{
+			name:   "without overloads len fails",
+			input:  `len(Plain())`,
+			output: &object.Err{Msg: "invalid argument INSTANCE"},
+		},
+		{
+			name:   "str must return a string",
+			input:  `class Bad { str() { return 1; } } str(Bad())`,
+			output: &object.Err{Msg: "str method must return STRING, got INTEGER"},
+		},
+		{
+			name:   "errors in overloads propagate",
+			input:  `Vec(1, 2) + 1`,
+			output: &object.Err{Msg: "object INTEGER has no properties"},
+		},
+	}
+
+	for _, testCase := range cases {
+		tc := testCase
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := parser.NewParser(lexer.NewLexer(classes + tc.input))
+			prog := p.ParseProgram()
+			assert.Empty(t, p.Errors())
+
+			assertObject(t, tc.output, interpreter.Eval(prog, object.NewEnv()))
+		})
+	}
+}
+
+func TestEvaluator_FullPrograms(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		input  string
+		output object.Object
+	}{
+		{
+			name: "object oriented",
+			input: `
+			class Item{
+				init(price) {
+					inst.price = price;
+				}
+			}
+
+			let pencil = Item(10)
+			let erasor = Item(5)
+
+			return pencil.price + erasor.price;
+			`,
+			output: object.NewIntegerObject(15),
+		},
+	}
+
+	for _, testCase := range cases {
+		tc := testCase
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			l := lexer.NewLexer(tc.input)
+			p := parser.NewParser(l)
+			prog := p.ParseProgram()
+			env := object.NewEnv()
+
+			assertObject(t, tc.output, interpreter.Eval(prog, env))
+		})
+	}
+}
+
+func TestEvaluator_Traceback(t *testing.T) {
+	t.Parallel()
+
+	input := `let inner = fn(x) {
+	x + y
+};
+fn outer() {
+	let z = 1;
+	inner(z)
+}
+outer();`
+
+	p := parser.NewParser(lexer.NewFileLexer("trace.monkey", input))
+	prog := p.ParseProgram()
+
+	res := interpreter.Eval(prog, object.NewEnv())
+	err, ok := res.(*object.Err)
+	if !assert.True(t, ok, "expected an error, got %v", res) {
+		return
+	}
+
+	assert.IsType(t, &ast.Identifier{}, err.Node)
+	assert.Equal(t, "y", err.Node.String())
+
+	expected := `Traceback (most recent call last):
+  trace.monkey:8:1 in <main>
+  trace.monkey:6:2 in outer
+  trace.monkey:2:6 in inner
+ERROR: undefined variable 'y'`
+	assert.Equal(t, expected, err.Traceback())
+}
+
+func TestEvaluator_Budget(t *testing.T) {
+	t.Parallel()
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cases := []struct {
+		name     string
+		input    string
+		ctx      context.Context
+		maxSteps int
+		output   object.Object
+	}{
+		{
+			name:     "within the limit",
+			input:    "let x = 1; x + 1",
+			ctx:      context.Background(),
+			maxSteps: 100,
+			output:   object.NewIntegerObject(2),
+		},
+		{
+			name:     "exceeds the limit",
+			input:    "while (true) {}",
+			ctx:      context.Background(),
+			maxSteps: 100,
+			output:   object.NewErrWithKind(object.ERR_STEP_LIMIT, "step limit of 100 exceeded"),
+		},
+		{
+			name:     "catching the error does not reset the limit",
+			input:    "let n = 0; while (true) { try { while (true) {} } catch (e) { n = n + 1 } }",
+			ctx:      context.Background(),
+			maxSteps: 100,
+			output:   object.NewErrWithKind(object.ERR_STEP_LIMIT, "step limit of 100 exceeded"),
+		},
+		{
+			name:   "cancelled",
+			input:  "while (true) {}",
+			ctx:    cancelled,
+			output: object.NewErrWithKind(object.ERR_CANCELLED, "context canceled"),
+		},
+	}
+
+	for _, testCase := range cases {
+		tc := testCase
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			prog := parser.NewParser(lexer.NewLexer(tc.input)).ParseProgram()
+			env := object.NewEnv().SetBudget(object.NewBudget(tc.ctx, tc.maxSteps))
+
+			assertObject(t, tc.output, interpreter.Eval(prog, env))
+		})
+	}
+}
+
+func TestEvaluator_Recursion(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		input  string
+		output object.Object
+	}{
+		{
+			name:   "unbounded recursion",
+			input:  "fn f() { f() } f()",
+			output: object.NewErrWithKind(object.ERR_RECURSION, "maximum recursion depth exceeded"),
+		},
+		{
+			name:   "mutual recursion",
+			input:  "fn f() { g() } fn g() { f() } f()",
+			output: object.NewErrWithKind(object.ERR_RECURSION, "maximum recursion depth exceeded"),
+		},
+		{
+			name:   "recursion in init",
+			input:  "class A { init() { A() } } A()",
+			output: object.NewErrWithKind(object.ERR_RECURSION, "maximum recursion depth exceeded"),
+		},
+		{
+			name:   "catchable",
+			input:  "fn f() { f() } try { f() } catch (e) { e.kind }",
+			output: object.NewStringObject("RecursionError"),
+		},
+		{
+			name:   "depth is restored after the error",
+			input:  "fn f() { f() } fn g(n) { if (n == 0) { 0 } else { g(n - 1) } } try { f() } catch (e) {} g(999)",
+			output: object.NewIntegerObject(0),
+		},
+	}
+
+	for _, testCase := range cases {
+		tc := testCase
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			prog := parser.NewParser(lexer.NewLexer(tc.input)).ParseProgram()
+			assertObject(t, tc.output, interpreter.Eval(prog, object.NewEnv()))
+		})
+	}
+}
+
+func TestEvaluator_RecursionTrace(t *testing.T) {
+	t.Parallel()
+
+	input := `fn f(n) {
+	f(n + 1)
+}
+f(0);`
+
+	prog := parser.NewParser(lexer.NewFileLexer("rec.monkey", input)).ParseProgram()
+	env := object.NewEnv().SetBudget(object.NewBudget(context.Background(), 0).WithMaxDepth(50))
+
+	err, ok := interpreter.Eval(prog, env).(*object.Err)
+	if !assert.True(t, ok) {
+		return
+	}
+
+	assert.Len(t, err.Trace, 51)
+
+	lines := []string{"Traceback (most recent call last):", "  rec.monkey:4:1 in <main>"}
+	for i := 0; i < 9; i++ {
+		lines = append(lines, "  rec.monkey:2:2 in f")
+	}
+	lines = append(lines, "  ... 31 more calls ...")
+	for i := 0; i < 10; i++ {
+		lines = append(lines, "  rec.monkey:2:2 in f")
+	}
+	lines = append(lines, "ERROR: maximum recursion depth exceeded")
+
+	assert.Equal(t, strings.Join(lines, "\n"), err.Traceback())
+}
+
+// assertObject compares evaluation results, ignoring where errors were raised.
+func assertObject(t *testing.T, expected, actual object.Object) {
+	t.Helper()
+
+	if err, ok := expected.(*object.Err); ok {
+		actualErr, ok := actual.(*object.Err)
+		if assert.True(t, ok, "expected an error, got %v", actual) {
+			assert.Equal(t, err.Msg, actualErr.Msg)
+		}
+		return
+	}
+
+	assert.Equal(t, expected, actual)
+}
@@ -0,0 +1,121 @@
+package interpreter
+
+import (
+	"github.com/donovandicks/gomonkey/internal/ast"
+	"github.com/donovandicks/gomonkey/internal/module"
+	"github.com/donovandicks/gomonkey/internal/object"
+	"github.com/donovandicks/gomonkey/internal/token"
+)
+
+// Importer loads modules for the interpreter, evaluating each one the first
+// time it is imported.
+type Importer struct {
+	loader  *module.Loader
+	modules map[string]*object.Module
+	budget  *object.Budget
+}
+
+func NewImporter(loader *module.Loader) *Importer {
+	return &Importer{
+		loader:  loader,
+		modules: make(map[string]*object.Module),
+	}
+}
+
+// WithBudget limits the modules evaluated by the importer to the budget of the
+// program importing them.
+func (imp *Importer) WithBudget(budget *object.Budget) *Importer {
+	imp.budget = budget
+	return imp
+}
+
+func (imp *Importer) Import(path string, from token.Position) object.Object {
+	file, err := imp.loader.Resolve(path, from)
+	if err != nil {
+		return object.NewErrWithKind(object.ERR_IMPORT, "%s", err)
+	}
+
+	if mod, ok := imp.modules[file]; ok {
+		return mod
+	}
+
+	if err := imp.loader.Enter(file); err != nil {
+		return object.NewErrWithKind(object.ERR_IMPORT, "%s", err)
+	}
+	defer imp.loader.Leave(file)
+
+	prog, err := imp.loader.Parse(file)
+	if err != nil {
+		return object.NewErrWithKind(object.ERR_IMPORT, "%s", err)
+	}
+
+	env := object.NewEnv().SetImporter(imp).SetBudget(imp.budget)
+	if res := evalModule(prog.Statements, env); object.IsErr(res) {
+		return res
+	}
+
+	mod := &object.Module{Name: path, Exports: make(map[string]object.Object)}
+	for _, name := range module.Exports(prog) {
+		mod.Exports[name.Value], _ = env.Get(name.Value)
+	}
+
+	imp.modules[file] = mod
+	return mod
+}
+
+// evalModule evaluates the top level of an imported module.
+func evalModule(stmts []ast.Statement, env *object.Environment) object.Object {
+	for _, stmt := range stmts {
+		switch res := Eval(stmt, env).(type) {
+		case *object.ReturnVal:
+			return object.NewErr("'return' outside of function")
+		case *object.Err:
+			return res
+		case *object.Break, *object.Continue:
+			return errOutsideLoop(res)
+		}
+	}
+
+	return nil
+}
+
+func evalImportStatement(stmt *ast.ImportStatement, env *object.Environment) object.Object {
+	if !env.IsTopLevel() {
+		return object.NewErrWithKind(object.ERR_IMPORT, "import must be at the top level of a module")
+	}
+
+	importer := env.Importer()
+	if importer == nil {
+		importer = NewImporter(module.NewLoader(module.SearchPath()...)).WithBudget(env.Budget())
+		env.SetImporter(importer)
+	}
+
+	res := importer.Import(stmt.Path.Value, stmt.Pos())
+	mod, ok := res.(*object.Module)
+	if !ok {
+		return res
+	}
+
+	if stmt.Alias != nil {
+		env.Set(stmt.Alias.Value, mod)
+	}
+
+	for _, name := range stmt.Names {
+		val := mod.Get(name.Name.Value)
+		if val == nil {
+			return object.NewErrWithKind(object.ERR_IMPORT, "module \"%s\" has no export '%s'", mod.Name, name.Name.Value)
+		}
+
+		env.Set(name.Binding().Value, val)
+	}
+
+	return nil
+}
+
+func evalExportStatement(stmt *ast.ExportStatement, env *object.Environment) object.Object {
+	if !env.IsTopLevel() {
+		return object.NewErrWithKind(object.ERR_IMPORT, "export must be at the top level of a module")
+	}
+
+	return Eval(stmt.Statement, env)
+}
@@ -0,0 +1,149 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/donovandicks/gomonkey/internal/object"
+)
+
+// Classes can overload operators and builtins by defining the methods below,
+// which the interpreter dispatches to when an operand is an instance.
+
+// operatorMethods maps operators to the methods that overload them.
+var operatorMethods = map[string]string{
+	"+":  "add",
+	"-":  "sub",
+	"*":  "mul",
+	"/":  "div",
+	"%":  "mod",
+	"**": "pow",
+	"==": "eq",
+	"<":  "lt",
+	">":  "gt",
+	"<=": "le",
+	">=": "ge",
+}
+
+// reflectedMethods maps comparisons to the methods of the right operand that
+// answer them when the left operand does not: a < b is b > a, and so on.
+var reflectedMethods = map[string]string{
+	"==": "eq",
+	"<":  "gt",
+	">":  "lt",
+	"<=": "ge",
+	">=": "le",
+}
+
+// builtins overrides the shared builtins that dispatch to methods.
+var builtins map[string]*object.Builtin
+
+func init() {
+	builtins = map[string]*object.Builtin{
+		"len":   {Fn: lenBuiltin},
+		"print": {Fn: printBuiltin},
+		"str":   {Fn: strBuiltin},
+	}
+}
+
+// callMethod calls the named method of the object if it is an instance that
+// defines it, reporting whether it did.
+func callMethod(obj object.Object, name string, args ...object.Object) (object.Object, bool) {
+	inst, ok := obj.(*object.Instance)
+	if !ok {
+		return nil, false
+	}
+
+	fn, ok := inst.Methods[name]
+	if !ok {
+		return nil, false
+	}
+
+	return applyFunc(fn, args), true
+}
+
+func negate(res object.Object) object.Object {
+	if object.IsErr(res) {
+		return res
+	}
+
+	return object.BoolFromNative(!object.IsTruthy(res))
+}
+
+func evalInfixOp(operator string, left, right object.Object) object.Object {
+	if name, ok := operatorMethods[operator]; ok {
+		if res, ok := callMethod(left, name, right); ok {
+			return res
+		}
+	}
+
+	if name, ok := reflectedMethods[operator]; ok {
+		if res, ok := callMethod(right, name, left); ok {
+			return res
+		}
+	}
+
+	if operator == "!=" {
+		if res, ok := callMethod(left, "eq", right); ok {
+			return negate(res)
+		}
+
+		if res, ok := callMethod(right, "eq", left); ok {
+			return negate(res)
+		}
+	}
+
+	return object.InfixOp(operator, left, right)
+}
+
+func evalIndex(left, index object.Object) object.Object {
+	if res, ok := callMethod(left, "index", index); ok {
+		return res
+	}
+
+	return object.Index(left, index)
+}
+
+// str converts the object to the string shown when it is printed.
+func str(obj object.Object) object.Object {
+	res, ok := callMethod(obj, "str")
+	if !ok {
+		return object.Str(obj)
+	}
+
+	if !object.IsErr(res) && res.Type() != object.OBJ_STR {
+		return object.NewErrWithKind(object.ERR_TYPE, "str method must return STRING, got %s", res.Type())
+	}
+
+	return res
+}
+
+func lenBuiltin(args ...object.Object) object.Object {
+	if len(args) == 1 {
+		if res, ok := callMethod(args[0], "len"); ok {
+			return res
+		}
+	}
+
+	return object.Len(args...)
+}
+
+func strBuiltin(args ...object.Object) object.Object {
+	if len(args) == 1 {
+		return str(args[0])
+	}
+
+	return object.Str(args...)
+}
+
+func printBuiltin(args ...object.Object) object.Object {
+	for _, arg := range args {
+		s := str(arg)
+		if object.IsErr(s) {
+			return s
+		}
+
+		fmt.Println(s.(*object.String).Value)
+	}
+
+	return object.NullObject
+}
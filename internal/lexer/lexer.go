@@ -1,15 +1,26 @@
 package lexer
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
 	"github.com/donovandicks/gomonkey/internal/token"
 )
 
 type Lexer struct {
 	input       string
+	file        string // name of the file being lexed, if any
 	stringCache map[string]token.Token
 	pos         int  // current position in input (current char)
 	readPos     int  // current reading position in input (next char)
 	ch          byte // current char
+	line        int  // line of the current char
+	lineStart   int  // offset of the first char on the current line
+
+	keepComments bool // emit comments as COMMENT tokens instead of skipping them
 }
 
 func isLetter(ch byte) bool {
@@ -20,15 +31,39 @@ func isDigit(ch byte) bool {
 	return '0' <= ch && ch <= '9'
 }
 
+func isHexDigit(ch byte) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
+// illegal creates an ILLEGAL token whose literal describes the problem.
+func illegal(format string, args ...interface{}) token.Token {
+	return token.Token{Type: token.ILLEGAL, Literal: fmt.Sprintf(format, args...)}
+}
+
 func NewLexer(input string) *Lexer {
 	l := &Lexer{
 		input:       input,
 		stringCache: make(map[string]token.Token),
+		line:        1,
 	}
 	l.readChar()
 	return l
 }
 
+// NewFileLexer creates a lexer whose token positions refer to the named file.
+func NewFileLexer(file, input string) *Lexer {
+	l := NewLexer(input)
+	l.file = file
+	return l
+}
+
+// WithComments configures the lexer to emit comments as COMMENT tokens rather
+// than discarding them, so that tools such as formatters can preserve them.
+func (l *Lexer) WithComments() *Lexer {
+	l.keepComments = true
+	return l
+}
+
 func (l *Lexer) peek() byte {
 	if l.readPos >= len(l.input) {
 		return 0
@@ -38,11 +73,34 @@ func (l *Lexer) peek() byte {
 }
 
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line += 1
+		l.lineStart = l.readPos
+	}
+
 	l.ch = l.peek()
 	l.pos = l.readPos
 	l.readPos += 1
 }
 
+// position returns the source position of the current char.
+func (l *Lexer) position() token.Position {
+	return token.Position{
+		File:   l.file,
+		Offset: l.pos,
+		Line:   l.line,
+		Column: l.pos - l.lineStart + 1,
+	}
+}
+
+// locate sets the span of the token from the start position to the current
+// position of the lexer.
+func (l *Lexer) locate(tok token.Token, start token.Position) token.Token {
+	tok.Pos = start
+	tok.End = l.position()
+	return tok
+}
+
 // readIdentifier reads an entire word at a time.
 //
 // The starting location of the word is the current lexer position at the time
@@ -58,31 +116,159 @@ func (l *Lexer) readIdentifier() string {
 	return l.input[pos:l.pos]
 }
 
-func (l *Lexer) readNumber() string {
-	pos := l.pos
+func (l *Lexer) readDigits() {
 	for isDigit(l.ch) {
 		l.readChar()
 	}
+}
 
-	return l.input[pos:l.pos]
+// readNumber reads an integer or floating point number.
+//
+// A number is a float if its digits are followed by a fractional part, an
+// exponent, or both, as in `3.14`, `1e-9` and `2.5E3`. A '.' that is not
+// followed by a digit is left for the next token so that `1.method` still
+// lexes as a property access.
+func (l *Lexer) readNumber() token.Token {
+	pos := l.pos
+	isFloat := false
+
+	l.readDigits()
+
+	if l.ch == '.' && isDigit(l.peek()) {
+		isFloat = true
+		l.readChar() // advance past the '.'
+		l.readDigits()
+	}
+
+	if l.ch == 'e' || l.ch == 'E' {
+		// only treat this as an exponent if digits follow
+		next := l.readPos
+		if next < len(l.input) && (l.input[next] == '+' || l.input[next] == '-') {
+			next += 1
+		}
+
+		if next < len(l.input) && isDigit(l.input[next]) {
+			isFloat = true
+			for l.readPos < next {
+				l.readChar()
+			}
+			l.readChar() // advance to the first digit of the exponent
+			l.readDigits()
+		}
+	}
+
+	if isFloat {
+		return token.NewFloat(l.input[pos:l.pos])
+	}
+
+	return token.NewInt(l.input[pos:l.pos])
 }
 
+// readString reads a double quoted string, decoding any escape sequences.
+//
+// The lexer is left on the closing quote. A string that is not closed before
+// the end of the line, or that contains an invalid escape sequence, produces
+// an ILLEGAL token.
 func (l *Lexer) readString() token.Token {
-	pos := l.pos + 1 // after the starting quote
+	var out strings.Builder
+	var bad error // the first invalid escape sequence in the string, if any
 
 	for {
 		l.readChar()
-		if l.ch == '"' || l.ch == 0 {
-			break
+
+		switch l.ch {
+		case '"':
+			if bad != nil {
+				return illegal("%s", bad)
+			}
+
+			str := out.String()
+			if t, ok := l.stringCache[str]; ok {
+				return t
+			}
+
+			return token.NewStr(str)
+		case 0, '\n':
+			return illegal("unterminated string literal")
+		case '\\':
+			l.readChar()
+			if l.ch == 0 || l.ch == '\n' {
+				return illegal("unterminated string literal")
+			}
+
+			ch, err := l.readEscape()
+			if err != nil && bad == nil {
+				bad = err
+			}
+
+			out.WriteRune(ch)
+		default:
+			out.WriteByte(l.ch)
 		}
 	}
+}
+
+// readEscape decodes the escape sequence whose first char, following the
+// backslash, is the current char. The lexer is left on the last char of the
+// sequence.
+func (l *Lexer) readEscape() (rune, error) {
+	switch l.ch {
+	case 'n':
+		return '\n', nil
+	case 't':
+		return '\t', nil
+	case 'r':
+		return '\r', nil
+	case '0':
+		return 0, nil
+	case '"', '\\':
+		return rune(l.ch), nil
+	case 'u':
+		if l.peek() != '{' {
+			return utf8.RuneError, errors.New("invalid unicode escape: expected '{' after \\u")
+		}
+
+		l.readChar() // advance to the '{'
+
+		pos := l.readPos
+		for isHexDigit(l.peek()) {
+			l.readChar()
+		}
+
+		digits := l.input[pos:l.readPos]
+		if l.peek() != '}' {
+			return utf8.RuneError, errors.New("invalid unicode escape: missing closing '}'")
+		}
+
+		l.readChar() // advance to the '}'
 
-	str := l.input[pos:l.pos]
-	if t, ok := l.stringCache[str]; ok {
-		return t
+		code, err := strconv.ParseUint(digits, 16, 32)
+		if err != nil || len(digits) > 6 || !utf8.ValidRune(rune(code)) {
+			return utf8.RuneError, fmt.Errorf("invalid unicode code point '%s'", digits)
+		}
+
+		return rune(code), nil
+	default:
+		return utf8.RuneError, fmt.Errorf("invalid escape sequence '\\%c'", l.ch)
 	}
+}
+
+// readRawString reads a backtick quoted string. Raw strings may span multiple
+// lines and contain no escape sequences. The lexer is left on the closing
+// backtick.
+func (l *Lexer) readRawString() token.Token {
+	pos := l.pos + 1 // after the opening backtick
+
+	for {
+		l.readChar()
+		if l.ch == '`' {
+			return token.NewStr(l.input[pos:l.pos])
+		}
 
-	return token.NewStr(str)
+		if l.ch == 0 {
+			return illegal("unterminated raw string literal")
+		}
+	}
 }
 
 // skipWhitespace advances the lexer over any whitespace characters
@@ -92,6 +278,49 @@ func (l *Lexer) skipWhitespace() {
 	}
 }
 
+// atComment reports whether the current char begins a line or block comment.
+func (l *Lexer) atComment() bool {
+	return l.ch == '/' && (l.peek() == '/' || l.peek() == '*')
+}
+
+// readComment advances the lexer over the comment beginning at the current
+// char and returns its text.
+//
+// Line comments run until the end of the line. Block comments run until their
+// matching closer and may be nested. The returned bool is false when a block
+// comment is not closed before the end of the input.
+func (l *Lexer) readComment() (string, bool) {
+	pos := l.pos
+
+	if l.peek() == '/' {
+		for l.ch != '\n' && l.ch != 0 {
+			l.readChar()
+		}
+
+		return l.input[pos:l.pos], true
+	}
+
+	depth := 0
+	for {
+		switch {
+		case l.ch == 0:
+			return l.input[pos:l.pos], false
+		case l.ch == '/' && l.peek() == '*':
+			depth += 1
+			l.readChar()
+		case l.ch == '*' && l.peek() == '/':
+			depth -= 1
+			l.readChar()
+			if depth == 0 {
+				l.readChar() // advance past the closing '/'
+				return l.input[pos:l.pos], true
+			}
+		}
+
+		l.readChar()
+	}
+}
+
 func (l *Lexer) readSpecial(ch string) token.Token {
 	var tok token.Token
 	switch ch {
@@ -135,21 +364,100 @@ func (l *Lexer) readSpecial(ch string) token.Token {
 		tok = token.TokenBang
 	case "!=":
 		tok = token.TokenNE
+	case "<=":
+		tok = token.TokenLE
+	case ">=":
+		tok = token.TokenGE
+	case "%":
+		tok = token.TokenPercent
+	case "**":
+		tok = token.TokenPow
+	case "&&":
+		tok = token.TokenAnd
+	case "||":
+		tok = token.TokenOr
+	case "&":
+		tok = token.TokenAmpersand
+	case "|":
+		tok = token.TokenPipe
+	case "^":
+		tok = token.TokenCaret
+	case "~":
+		tok = token.TokenTilde
+	case "<<":
+		tok = token.TokenSHL
+	case ">>":
+		tok = token.TokenSHR
+	case "+=":
+		tok = token.TokenPlusAssign
+	case "-=":
+		tok = token.TokenMinusAssign
+	case "*=":
+		tok = token.TokenStarAssign
+	case "/=":
+		tok = token.TokenFSlashAssign
+	case "%=":
+		tok = token.TokenPercentAssign
+	case "?.":
+		tok = token.TokenQuestionDot
+	case "??":
+		tok = token.TokenNullish
+	case "...":
+		tok = token.TokenEllipsis
+	case "=>":
+		tok = token.TokenArrow
 	}
 
 	return tok
 }
 
+// readOperator reads an operator that may be one or two chars long, such as
+// `<`, `<=` and `<<`. The lexer is left on the last char of the operator.
+func (l *Lexer) readOperator() token.Token {
+	op := string(l.ch)
+
+	switch next := l.peek(); {
+	case next == '=' && l.ch != '&' && l.ch != '|':
+		// `<=`, `>=` and the compound assignments such as `+=`
+		op += "="
+	case next == l.ch && strings.ContainsRune("<>*&|", rune(l.ch)):
+		// `<<`, `>>`, `**`, `&&` and `||`
+		op += string(next)
+	default:
+		return l.readSpecial(op)
+	}
+
+	l.readChar()
+	return l.readSpecial(op)
+}
+
 func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 
 	l.skipWhitespace()
 
+	for l.atComment() {
+		start := l.position()
+
+		text, ok := l.readComment()
+		if !ok {
+			return l.locate(illegal("unterminated block comment"), start)
+		}
+
+		if l.keepComments {
+			return l.locate(token.Token{Type: token.COMMENT, Literal: text}, start)
+		}
+
+		l.skipWhitespace()
+	}
+
+	start := l.position()
+
 	switch l.ch {
 	case '=':
-		if l.peek() == '=' {
+		if next := l.peek(); next == '=' || next == '>' {
 			l.readChar()
-			tok = l.readSpecial("==")
+			tok = l.readSpecial("=" + string(next))
 		} else {
 			tok = l.readSpecial("=")
 		}
@@ -160,26 +468,47 @@ func (l *Lexer) NextToken() token.Token {
 		} else {
 			tok = l.readSpecial("!")
 		}
-	case ';', '(', ')', ',', '+', '-', '/', '*', '<', '>', '.', '{', '}', '[', ']', ':':
+	case '<', '>', '*', '&', '|', '+', '-', '/', '%':
+		tok = l.readOperator()
+	case '.':
+		if strings.HasPrefix(l.input[l.pos:], "...") {
+			l.readChar()
+			l.readChar()
+			tok = l.readSpecial("...")
+		} else {
+			tok = l.readSpecial(".")
+		}
+	case ';', '(', ')', ',', '^', '~', '{', '}', '[', ']', ':':
 		tok = l.readSpecial(string(l.ch))
+	case '?':
+		// `?.` and `??`; there is no lone `?`
+		if next := l.peek(); next == '.' || next == '?' {
+			l.readChar()
+			tok = l.readSpecial("?" + string(next))
+		} else {
+			tok = illegal("unexpected character '%c'", l.ch)
+		}
 	case '"':
 		tok = l.readString()
+	case '`':
+		tok = l.readRawString()
 	case 0:
-		tok = token.TokenEOF
+		// do not advance past the end of the input
+		return l.locate(token.TokenEOF, start)
 	default:
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
 			// exit early because the lexer has already been advanced in readIdentifier
-			return tok
+			return l.locate(tok, start)
 		} else if isDigit(l.ch) {
-			tok = token.NewInt(l.readNumber())
-			return tok
+			tok = l.readNumber()
+			return l.locate(tok, start)
 		} else {
-			tok = token.New(token.ILLEGAL, l.ch)
+			tok = illegal("unexpected character '%c'", l.ch)
 		}
 	}
 
 	l.readChar()
-	return tok
+	return l.locate(tok, start)
 }
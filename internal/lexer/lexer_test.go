@@ -16,7 +16,7 @@ func TestNextToken(t *testing.T) {
 	}{
 		{
 			name:  "special characters",
-			input: "=+(){}[],;!-/*<>:.",
+			input: "=+(){}[],;!-/ *<>:.",
 			expTokens: []token.Token{
 				token.TokenAssign,
 				token.TokenPlus,
@@ -40,7 +40,7 @@ func TestNextToken(t *testing.T) {
 		},
 		{
 			name:  "keywords",
-			input: "fn let return if else true false while for class",
+			input: "fn let return if else true false while for in class",
 			expTokens: []token.Token{
 				token.NewKeyword("fn"),
 				token.NewKeyword("let"),
@@ -51,6 +51,7 @@ func TestNextToken(t *testing.T) {
 				token.NewKeyword("false"),
 				token.NewKeyword("while"),
 				token.NewKeyword("for"),
+				token.NewKeyword("in"),
 				token.NewKeyword("class"),
 			},
 		},
@@ -64,6 +65,83 @@ func TestNextToken(t *testing.T) {
 				token.TokenNE,
 			},
 		},
+		{
+			name:  "operators",
+			input: "<= >= % ** && || & | ^ ~ << >> < * &",
+			expTokens: []token.Token{
+				token.TokenLE,
+				token.TokenGE,
+				token.TokenPercent,
+				token.TokenPow,
+				token.TokenAnd,
+				token.TokenOr,
+				token.TokenAmpersand,
+				token.TokenPipe,
+				token.TokenCaret,
+				token.TokenTilde,
+				token.TokenSHL,
+				token.TokenSHR,
+				token.TokenLT,
+				token.TokenStar,
+				token.TokenAmpersand,
+			},
+		},
+		{
+			name:  "assignment operators",
+			input: "+= -= *= /= %= = + - --x",
+			expTokens: []token.Token{
+				token.TokenPlusAssign,
+				token.TokenMinusAssign,
+				token.TokenStarAssign,
+				token.TokenFSlashAssign,
+				token.TokenPercentAssign,
+				token.TokenAssign,
+				token.TokenPlus,
+				token.TokenMinus,
+				token.TokenMinus,
+				token.TokenMinus,
+				token.NewIdent("x"),
+			},
+		},
+		{
+			name:  "match arms",
+			input: "match (x) { [a, ...rest] => a }",
+			expTokens: []token.Token{
+				token.NewKeyword("match"),
+				token.TokenLParen,
+				token.NewIdent("x"),
+				token.TokenRParen,
+				token.TokenLBrace,
+				token.TokenLBrack,
+				token.NewIdent("a"),
+				token.TokenComma,
+				token.TokenEllipsis,
+				token.NewIdent("rest"),
+				token.TokenRBrack,
+				token.TokenArrow,
+				token.NewIdent("a"),
+				token.TokenRBrace,
+			},
+		},
+		{
+			name:  "null and optional access",
+			input: "null a?.b c?.[0] d ?? e ?",
+			expTokens: []token.Token{
+				token.NewKeyword("null"),
+				token.NewIdent("a"),
+				token.TokenQuestionDot,
+				token.NewIdent("b"),
+				token.NewIdent("c"),
+				token.TokenQuestionDot,
+				token.TokenLBrack,
+				token.NewInt("0"),
+				token.TokenRBrack,
+				token.NewIdent("d"),
+				token.TokenNullish,
+				token.NewIdent("e"),
+				{Type: token.ILLEGAL, Literal: "unexpected character '?'"},
+			},
+		},
 		{
 			name: "source code program",
 			input: `let five = 5;
@@ -115,6 +193,53 @@ func TestNextToken(t *testing.T) {
 				token.TokenEOF,
 			},
 		},
+		{
+			name: "comments",
+			input: `// leading comment
+			let x = 5; // trailing comment
+			/* block
+			   comment */ x / 2;
+			/* outer /* nested */ still a comment */ x`,
+			expTokens: []token.Token{
+				token.NewKeyword("let"),
+				token.NewIdent("x"),
+				token.TokenAssign,
+				token.NewInt("5"),
+				token.TokenSemi,
+				token.NewIdent("x"),
+				token.TokenFSlash,
+				token.NewInt("2"),
+				token.TokenSemi,
+				token.NewIdent("x"),
+				token.TokenEOF,
+			},
+		},
+		{
+			name:  "comments: unterminated block",
+			input: "x /* never closed",
+			expTokens: []token.Token{
+				token.NewIdent("x"),
+				{Type: token.ILLEGAL, Literal: "unterminated block comment"},
+			},
+		},
+		{
+			name:  "numbers",
+			input: "7 3.14 1e-9 2E10 1.5e+3 1.len 2e x",
+			expTokens: []token.Token{
+				token.NewInt("7"),
+				token.NewFloat("3.14"),
+				token.NewFloat("1e-9"),
+				token.NewFloat("2E10"),
+				token.NewFloat("1.5e+3"),
+				token.NewInt("1"),
+				token.TokenDot,
+				token.NewIdent("len"),
+				token.NewInt("2"),
+				token.NewIdent("e"),
+				token.NewIdent("x"),
+				token.TokenEOF,
+			},
+		},
 		{
 			name:  "strings",
 			input: `"hello, world!" "one"`,
@@ -123,6 +248,63 @@ func TestNextToken(t *testing.T) {
 				token.NewStr("one"),
 			},
 		},
+		{
+			name:  "strings: escape sequences",
+			input: `"a\"b" "tab\there" "line\nbreak" "back\\slash" "\u{48}\u{1F600}"`,
+			expTokens: []token.Token{
+				token.NewStr(`a"b`),
+				token.NewStr("tab\there"),
+				token.NewStr("line\nbreak"),
+				token.NewStr(`back\slash`),
+				token.NewStr("H\U0001F600"),
+				token.TokenEOF,
+			},
+		},
+		{
+			name:  "strings: invalid escape sequences",
+			input: `"bad\q" "\u{110000}" "\u41" "ok"`,
+			expTokens: []token.Token{
+				{Type: token.ILLEGAL, Literal: `invalid escape sequence '\q'`},
+				{Type: token.ILLEGAL, Literal: "invalid unicode code point '110000'"},
+				{Type: token.ILLEGAL, Literal: `invalid unicode escape: expected '{' after \u`},
+				token.NewStr("ok"),
+			},
+		},
+		{
+			name:  "strings: unterminated",
+			input: "\"never closed\nx",
+			expTokens: []token.Token{
+				{Type: token.ILLEGAL, Literal: "unterminated string literal"},
+				token.NewIdent("x"),
+				token.TokenEOF,
+			},
+		},
+		{
+			name:  "strings: raw multi-line",
+			input: "`{\"key\": \"va\\lue\"}\nsecond line` x",
+			expTokens: []token.Token{
+				token.NewStr("{\"key\": \"va\\lue\"}\nsecond line"),
+				token.NewIdent("x"),
+				token.TokenEOF,
+			},
+		},
+		{
+			name:  "strings: unterminated raw",
+			input: "`never closed",
+			expTokens: []token.Token{
+				{Type: token.ILLEGAL, Literal: "unterminated raw string literal"},
+				token.TokenEOF,
+			},
+		},
+		{
+			name:  "illegal character",
+			input: "x # y",
+			expTokens: []token.Token{
+				token.NewIdent("x"),
+				{Type: token.ILLEGAL, Literal: "unexpected character '#'"},
+				token.NewIdent("y"),
+			},
+		},
 		{
 			name:  "strings: multiple same",
 			input: `"hello" "hello" "hello"`,
@@ -147,3 +329,57 @@ func TestNextToken(t *testing.T) {
 		})
 	}
 }
+
+func TestNextToken_Positions(t *testing.T) {
+	t.Parallel()
+
+	input := "let x = 10;\n  x == \"hi\";"
+	pos := func(offset, line, column int) token.Position {
+		return token.Position{File: "a.monkey", Offset: offset, Line: line, Column: column}
+	}
+
+	cases := []struct {
+		typ   token.TokenType
+		start token.Position
+		end   token.Position
+	}{
+		{token.LET, pos(0, 1, 1), pos(3, 1, 4)},
+		{token.IDENT, pos(4, 1, 5), pos(5, 1, 6)},
+		{token.ASSIGN, pos(6, 1, 7), pos(7, 1, 8)},
+		{token.INT, pos(8, 1, 9), pos(10, 1, 11)},
+		{token.SEMICOLON, pos(10, 1, 11), pos(11, 1, 12)},
+		{token.IDENT, pos(14, 2, 3), pos(15, 2, 4)},
+		{token.EQ, pos(16, 2, 5), pos(18, 2, 7)},
+		{token.STRING, pos(19, 2, 8), pos(23, 2, 12)},
+		{token.SEMICOLON, pos(23, 2, 12), pos(24, 2, 13)},
+		{token.EOF, pos(24, 2, 13), pos(24, 2, 13)},
+	}
+
+	l := lexer.NewFileLexer("a.monkey", input)
+	for _, exp := range cases {
+		tok := l.NextToken()
+		assert.Equal(t, exp.typ, tok.Type)
+		assert.Equal(t, exp.start, tok.Pos, "start of %s", tok.Literal)
+		assert.Equal(t, exp.end, tok.End, "end of %s", tok.Literal)
+	}
+}
+
+func TestNextToken_KeepComments(t *testing.T) {
+	t.Parallel()
+
+	l := lexer.NewLexer("x // line\n/* block */ y").WithComments()
+
+	expected := []token.Token{
+		token.NewIdent("x"),
+		{Type: token.COMMENT, Literal: "// line"},
+		{Type: token.COMMENT, Literal: "/* block */"},
+		token.NewIdent("y"),
+		token.TokenEOF,
+	}
+
+	for _, exp := range expected {
+		tok := l.NextToken()
+		assert.Equal(t, exp.Type, tok.Type)
+		assert.Equal(t, exp.Literal, tok.Literal)
+	}
+}
@@ -0,0 +1,44 @@
+package module
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/donovandicks/gomonkey/internal/diagnostic"
+)
+
+type ErrNotFound struct {
+	path     string
+	searched []string
+}
+
+func (e ErrNotFound) Error() string {
+	return fmt.Sprintf("module \"%s\" not found (searched %s)", e.path, strings.Join(e.searched, ", "))
+}
+
+type ErrCycle struct {
+	files []string
+}
+
+func (e ErrCycle) Error() string {
+	return fmt.Sprintf("import cycle: %s", strings.Join(e.files, " -> "))
+}
+
+type ErrSyntax struct {
+	file  string
+	diags []diagnostic.Diagnostic
+}
+
+func (e ErrSyntax) Error() string {
+	msgs := make([]string, 0, len(e.diags))
+	for _, diag := range e.diags {
+		msgs = append(msgs, diag.Error())
+	}
+
+	return fmt.Sprintf("syntax errors in module %s: %s", e.file, strings.Join(msgs, "; "))
+}
+
+// Diagnostics returns the syntax errors found in the module.
+func (e ErrSyntax) Diagnostics() []diagnostic.Diagnostic {
+	return e.diags
+}
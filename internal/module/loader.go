@@ -0,0 +1,135 @@
+package module
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/donovandicks/gomonkey/internal/ast"
+	"github.com/donovandicks/gomonkey/internal/lexer"
+	"github.com/donovandicks/gomonkey/internal/parser"
+	"github.com/donovandicks/gomonkey/internal/token"
+)
+
+const (
+	// EXTENSION is the extension of module files, which import paths may omit.
+	EXTENSION = ".monkey"
+
+	// SEARCH_PATH_VAR is the environment variable listing the directories
+	// searched for modules that are not found next to the importing file.
+	SEARCH_PATH_VAR = "MONKEY_PATH"
+)
+
+// SearchPath returns the directories listed in MONKEY_PATH.
+func SearchPath() []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(os.Getenv(SEARCH_PATH_VAR)) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return dirs
+}
+
+// Loader finds and parses the files of imported modules. It is shared by the
+// interpreter and the compiler, which each decide what loading a module means.
+type Loader struct {
+	searchPath []string
+
+	// loading lists the modules being loaded, outermost first, to detect
+	// import cycles
+	loading []string
+}
+
+func NewLoader(searchPath ...string) *Loader {
+	return &Loader{searchPath: searchPath}
+}
+
+// Resolve returns the absolute path of the module file imported by the path.
+//
+// Paths starting with "./" or "../" are relative to the importing file. Other
+// relative paths are looked up next to the importing file and then in each
+// directory of the search path.
+func (l *Loader) Resolve(path string, from token.Position) (string, error) {
+	if filepath.Ext(path) == "" {
+		path += EXTENSION
+	}
+
+	dir := filepath.Dir(from.File)
+
+	var candidates []string
+	switch {
+	case filepath.IsAbs(path):
+		candidates = []string{path}
+	case strings.HasPrefix(path, "./"), strings.HasPrefix(path, "../"):
+		candidates = []string{filepath.Join(dir, path)}
+	default:
+		candidates = []string{filepath.Join(dir, path)}
+		for _, searchDir := range l.searchPath {
+			candidates = append(candidates, filepath.Join(searchDir, path))
+		}
+	}
+
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		return filepath.Abs(candidate)
+	}
+
+	return "", ErrNotFound{path: path, searched: candidates}
+}
+
+// Enter records that the module file is being loaded, failing if it is
+// already being loaded further up the chain of imports.
+func (l *Loader) Enter(file string) error {
+	for i, loading := range l.loading {
+		if loading == file {
+			return ErrCycle{files: append(append([]string{}, l.loading[i:]...), file)}
+		}
+	}
+
+	l.loading = append(l.loading, file)
+	return nil
+}
+
+// Leave records that the module file has finished loading.
+func (l *Loader) Leave(file string) {
+	for i := len(l.loading) - 1; i >= 0; i-- {
+		if l.loading[i] == file {
+			l.loading = append(l.loading[:i], l.loading[i+1:]...)
+			return
+		}
+	}
+}
+
+// Parse reads and parses the module file.
+func (l *Loader) Parse(file string) (*ast.Program, error) {
+	source, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser.NewParser(lexer.NewFileLexer(file, string(source)))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		return nil, ErrSyntax{file: file, diags: errs}
+	}
+
+	return prog, nil
+}
+
+// Exports returns the names exported by the top-level statements of a module.
+func Exports(prog *ast.Program) []*ast.Identifier {
+	var names []*ast.Identifier
+	for _, stmt := range prog.Statements {
+		if export, ok := stmt.(*ast.ExportStatement); ok {
+			names = append(names, export.Names()...)
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,71 @@
+package module_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/donovandicks/gomonkey/internal/module"
+	"github.com/donovandicks/gomonkey/internal/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoader_Resolve(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{"app/main.monkey", "app/util.monkey", "lib/util.monkey", "lib/extra.monkey"} {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.Nil(t, os.WriteFile(path, nil, 0o644))
+	}
+
+	from := token.Position{File: filepath.Join(dir, "app", "main.monkey"), Line: 1, Column: 1}
+	loader := module.NewLoader(filepath.Join(dir, "lib"))
+
+	cases := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"next to the importing file", "util", "app/util.monkey"},
+		{"with extension", "util.monkey", "app/util.monkey"},
+		{"relative", "../lib/util", "lib/util.monkey"},
+		{"search path", "extra", "lib/extra.monkey"},
+		{"absolute", filepath.Join(dir, "lib", "util.monkey"), "lib/util.monkey"},
+	}
+
+	for _, testCase := range cases {
+		tc := testCase
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			file, err := loader.Resolve(tc.path, from)
+			assert.Nil(t, err)
+			assert.Equal(t, filepath.Join(dir, tc.expected), file)
+		})
+	}
+
+	_, err := loader.Resolve("./extra", from)
+	assert.EqualError(t, err, `module "./extra.monkey" not found (searched `+filepath.Join(dir, "app", "extra.monkey")+`)`)
+}
+
+func TestLoader_Cycle(t *testing.T) {
+	t.Parallel()
+
+	loader := module.NewLoader()
+	assert.Nil(t, loader.Enter("a"))
+	assert.Nil(t, loader.Enter("b"))
+	assert.EqualError(t, loader.Enter("a"), "import cycle: a -> b -> a")
+
+	loader.Leave("b")
+	assert.Nil(t, loader.Enter("c"))
+	assert.EqualError(t, loader.Enter("c"), "import cycle: c -> c")
+}
+
+func TestSearchPath(t *testing.T) {
+	t.Setenv(module.SEARCH_PATH_VAR, "/one"+string(os.PathListSeparator)+string(os.PathListSeparator)+"/two")
+
+	assert.Equal(t, []string{"/one", "/two"}, module.SearchPath())
+}
@@ -0,0 +1,121 @@
+package object
+
+import "context"
+
+const (
+	// CHECK_INTERVAL is the number of steps a program takes between checks
+	// of its context.
+	CHECK_INTERVAL = 1024
+
+	// MAX_CALL_DEPTH is the number of nested calls a program may make unless
+	// its budget allows otherwise.
+	MAX_CALL_DEPTH = 1000
+)
+
+// Budget limits how long a program runs: until its context is done, and for at
+// most a number of steps. A step is an evaluated node in the interpreter and
+// an executed instruction in the VM.
+//
+// Once the budget is spent, every further step fails, so a program that
+// catches the error cannot keep running.
+//
+// A budget also limits how deeply the calls of a program nest, so that
+// unbounded recursion raises an error rather than overflowing the stack.
+type Budget struct {
+	ctx      context.Context
+	maxSteps int // no limit if 0
+	steps    int
+
+	stop *Err // why the program was stopped, once it has been
+
+	maxDepth int
+	depth    int
+}
+
+func NewBudget(ctx context.Context, maxSteps int) *Budget {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return &Budget{ctx: ctx, maxSteps: maxSteps, maxDepth: MAX_CALL_DEPTH}
+}
+
+// WithMaxDepth sets the number of nested calls the program may make.
+func (b *Budget) WithMaxDepth(depth int) *Budget {
+	b.maxDepth = depth
+	return b
+}
+
+// MaxDepth returns the number of nested calls the program may make.
+func (b *Budget) MaxDepth() int {
+	if b == nil {
+		return MAX_CALL_DEPTH
+	}
+
+	return b.maxDepth
+}
+
+// Enter records a call, and returns an error if it nests too deeply. Each call
+// entered without error must be left.
+func (b *Budget) Enter() *Err {
+	if b == nil {
+		return nil
+	}
+
+	if b.depth >= b.maxDepth {
+		return ErrRecursion()
+	}
+
+	b.depth += 1
+	return nil
+}
+
+// Leave records the return of a call.
+func (b *Budget) Leave() {
+	if b != nil {
+		b.depth -= 1
+	}
+}
+
+// ErrRecursion is the error raised by a call that nests too deeply.
+func ErrRecursion() *Err {
+	return NewErrWithKind(ERR_RECURSION, "maximum recursion depth exceeded")
+}
+
+// Step records a step of the program, and returns an error if the program
+// must stop. A nil budget never stops a program.
+func (b *Budget) Step() *Err {
+	if b == nil {
+		return nil
+	}
+
+	b.steps += 1
+
+	if b.stop == nil && b.maxSteps > 0 && b.steps > b.maxSteps {
+		b.stop = NewErrWithKind(ERR_STEP_LIMIT, "step limit of %d exceeded", b.maxSteps)
+	}
+
+	if b.stop == nil && b.steps%CHECK_INTERVAL == 0 {
+		if err := b.ctx.Err(); err != nil {
+			b.stop = NewErrWithKind(ERR_CANCELLED, "%s", err)
+		}
+	}
+
+	return b.Err()
+}
+
+// Steps returns the number of steps the program has taken.
+func (b *Budget) Steps() int {
+	return b.steps
+}
+
+// Err returns the error that stopped the program, or nil if it is still
+// running.
+func (b *Budget) Err() *Err {
+	if b == nil || b.stop == nil {
+		return nil
+	}
+
+	// each step that fails raises its own error, with its own trace
+	return NewErrWithKind(b.stop.Kind, "%s", b.stop.Msg)
+}
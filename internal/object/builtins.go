@@ -0,0 +1,72 @@
+package object
+
+import (
+	"fmt"
+)
+
+// Builtins lists the builtin functions available to every program. The order
+// is significant: compiled bytecode refers to builtins by their index.
+var Builtins = []struct {
+	Name    string
+	Builtin *Builtin
+}{
+	{
+		Name:    "len",
+		Builtin: &Builtin{Fn: Len},
+	},
+	{
+		Name:    "print",
+		Builtin: &Builtin{Fn: Print},
+	},
+	{
+		Name:    "str",
+		Builtin: &Builtin{Fn: Str},
+	},
+}
+
+// GetBuiltinByName returns the builtin registered under the name, or nil if
+// there is none.
+func GetBuiltinByName(name string) *Builtin {
+	for _, def := range Builtins {
+		if def.Name == name {
+			return def.Builtin
+		}
+	}
+
+	return nil
+}
+
+func Len(args ...Object) Object {
+	if len(args) != 1 {
+		return NewErrWithKind(ERR_TYPE, "invalid number of args %d, expected 1", len(args))
+	}
+
+	switch arg := args[0].(type) {
+	case *String:
+		return NewIntegerObject(int64(len(arg.Value)))
+	case *List:
+		return NewIntegerObject(int64(len(arg.Elems)))
+	default:
+		return NewErrWithKind(ERR_TYPE, "invalid argument %s", args[0].Type())
+	}
+}
+
+func Print(args ...Object) Object {
+	for _, arg := range args {
+		fmt.Println(arg.Inspect())
+	}
+
+	return NullObject
+}
+
+func Str(args ...Object) Object {
+	if len(args) != 1 {
+		return NewErrWithKind(ERR_TYPE, "invalid number of args %d, expected 1", len(args))
+	}
+
+	if str, ok := args[0].(*String); ok {
+		return str
+	}
+
+	return NewStringObject(args[0].Inspect())
+}
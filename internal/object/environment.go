@@ -3,6 +3,18 @@ package object
 type Environment struct {
 	vals  map[string]Object
 	outer *Environment
+
+	// fn is the name of the function whose call created the environment, if
+	// any.
+	fn string
+
+	// importer loads the modules imported by the program. It is only set on
+	// the outermost environment.
+	importer Importer
+
+	// budget limits how long the program runs. Environments share the budget
+	// of the environment enclosing them.
+	budget *Budget
 }
 
 func NewEnv() *Environment {
@@ -13,9 +25,69 @@ func NewEnv() *Environment {
 
 func NewEnvFromEnv(outer *Environment) *Environment {
 	return &Environment{
-		vals:  make(map[string]Object),
-		outer: outer,
+		vals:   make(map[string]Object),
+		outer:  outer,
+		budget: outer.budget,
+	}
+}
+
+// NewCallEnv creates the environment for a call to the named function.
+func NewCallEnv(outer *Environment, fn string) *Environment {
+	env := NewEnvFromEnv(outer)
+	env.fn = fn
+	return env
+}
+
+// Function returns the name of the innermost function executing in the
+// environment, as shown in traces.
+func (e *Environment) Function() string {
+	for env := e; env != nil; env = env.outer {
+		if env.fn != "" {
+			return env.fn
+		}
 	}
+
+	return MAIN_FUNCTION
+}
+
+// IsTopLevel reports whether the environment is the outermost scope of a
+// module.
+func (e *Environment) IsTopLevel() bool {
+	return e.outer == nil
+}
+
+// root returns the outermost environment enclosing this one.
+func (e *Environment) root() *Environment {
+	env := e
+	for env.outer != nil {
+		env = env.outer
+	}
+
+	return env
+}
+
+// Importer returns the importer of the program running in the environment, if
+// one has been set.
+func (e *Environment) Importer() Importer {
+	return e.root().importer
+}
+
+func (e *Environment) SetImporter(importer Importer) *Environment {
+	e.root().importer = importer
+	return e
+}
+
+// Budget returns the budget of the program running in the environment, or nil
+// if it has none.
+func (e *Environment) Budget() *Budget {
+	return e.budget
+}
+
+// SetBudget limits the program run in the environment, and in the environments
+// created from it afterwards.
+func (e *Environment) SetBudget(budget *Budget) *Environment {
+	e.budget = budget
+	return e
 }
 
 func (e *Environment) Values() map[string]Object {
@@ -44,12 +116,15 @@ func (e *Environment) Set(name string, val Object) Object {
 	return val
 }
 
+// Update assigns to an existing binding in the innermost scope that defines
+// it.
 func (e *Environment) Update(name string, val Object) Object {
-	_, ok := e.vals[name]
-	if !ok {
-		return NewErr("undefined variable '%s'", name)
+	for env := e; env != nil; env = env.outer {
+		if _, ok := env.vals[name]; ok {
+			env.vals[name] = val
+			return val
+		}
 	}
 
-	e.vals[name] = val
-	return val
+	return NewErrWithKind(ERR_NAME, "undefined variable '%s'", name)
 }
@@ -3,9 +3,14 @@ package object
 import (
 	"fmt"
 	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/donovandicks/gomonkey/internal/ast"
+	"github.com/donovandicks/gomonkey/internal/opcode"
+	"github.com/donovandicks/gomonkey/internal/token"
 )
 
 type (
@@ -14,24 +19,42 @@ type (
 )
 
 const (
-	OBJ_INTEGER  ObjectType = "INTEGER"
-	OBJ_BOOLEAN  ObjectType = "BOOLEAN"
-	OBJ_FUNC     ObjectType = "FUNCTION"
-	OBJ_NULL     ObjectType = "NULL"
-	OBJ_RETURN   ObjectType = "RETURN"
-	OBJ_ERR      ObjectType = "ERROR"
-	OBJ_STR      ObjectType = "STRING"
-	OBJ_BUILTIN  ObjectType = "BUILTIN"
-	OBJ_LIST     ObjectType = "LIST"
-	OBJ_MAP      ObjectType = "MAP"
-	OBJ_CLASS    ObjectType = "CLASS"
-	OBJ_INSTANCE ObjectType = "INSTANCE"
+	// the names used in traces for code outside of any function
+	MAIN_FUNCTION      = "<main>"
+	ANONYMOUS_FUNCTION = "<anonymous>"
+)
+
+const (
+	OBJ_INTEGER   ObjectType = "INTEGER"
+	OBJ_FLOAT     ObjectType = "FLOAT"
+	OBJ_BOOLEAN   ObjectType = "BOOLEAN"
+	OBJ_FUNC      ObjectType = "FUNCTION"
+	OBJ_COMPILED  ObjectType = "COMPILED_FUNCTION"
+	OBJ_CLOSURE   ObjectType = "CLOSURE"
+	OBJ_NULL      ObjectType = "NULL"
+	OBJ_RETURN    ObjectType = "RETURN"
+	OBJ_BREAK     ObjectType = "BREAK"
+	OBJ_CONTINUE  ObjectType = "CONTINUE"
+	OBJ_ERR       ObjectType = "ERROR"
+	OBJ_STR       ObjectType = "STRING"
+	OBJ_BUILTIN   ObjectType = "BUILTIN"
+	OBJ_LIST      ObjectType = "LIST"
+	OBJ_MAP       ObjectType = "MAP"
+	OBJ_CLASS     ObjectType = "CLASS"
+	OBJ_INSTANCE  ObjectType = "INSTANCE"
+	OBJ_EXCEPTION ObjectType = "EXCEPTION"
+	OBJ_SUPER     ObjectType = "SUPER"
+	OBJ_MODULE    ObjectType = "MODULE"
+	OBJ_PATTERN   ObjectType = "PATTERN"
 )
 
 var (
 	TrueBool   = &Boolean{Value: true}
 	FalseBool  = &Boolean{Value: false}
 	NullObject = &Null{}
+
+	BreakObject    = &Break{}
+	ContinueObject = &Continue{}
 )
 
 type HashKey struct {
@@ -49,6 +72,21 @@ func IsHashable(o Object) bool {
 	return ok
 }
 
+// PropertyObject is an object whose properties are read with `obj.name`. Get
+// returns nil if the object has no such property.
+type PropertyObject interface {
+	Object
+	Get(key string) Object
+}
+
+// SettableObject is an object whose properties are assigned with
+// `obj.name = val`. SetProperty returns the value, or an error if the property
+// cannot be assigned.
+type SettableObject interface {
+	PropertyObject
+	SetProperty(key string, val Object) Object
+}
+
 type Object interface {
 	Type() ObjectType
 	Inspect() string
@@ -63,6 +101,40 @@ func (i *Integer) Type() ObjectType       { return OBJ_INTEGER }
 func (i *Integer) Hash() HashKey          { return HashKey{Type: i.Type(), Value: uint64(i.Value)} }
 func NewIntegerObject(val int64) *Integer { return &Integer{Value: val} }
 
+type Float struct {
+	Value float64
+}
+
+// Inspect formats the float in decimal notation, switching to scientific
+// notation for very large and very small magnitudes. Integral values keep a
+// trailing ".0" so they remain distinguishable from integers.
+func (f *Float) Inspect() string {
+	var s string
+	if abs := math.Abs(f.Value); abs == 0 || (abs >= 1e-4 && abs < 1e21) {
+		s = strconv.FormatFloat(f.Value, 'f', -1, 64)
+	} else {
+		s = strconv.FormatFloat(f.Value, 'g', -1, 64)
+	}
+
+	if !strings.ContainsAny(s, ".eIN") { // Inf and NaN need no suffix
+		s += ".0"
+	}
+
+	return s
+}
+func (f *Float) Type() ObjectType { return OBJ_FLOAT }
+
+// Hash gives integral floats the key of the equal integer, so that 2.0 and 2
+// are the same key. This also makes -0.0 and 0.0 the same key.
+func (f *Float) Hash() HashKey {
+	if val := f.Value; val == math.Trunc(val) && val >= math.MinInt64 && val < math.MaxInt64 {
+		return NewIntegerObject(int64(val)).Hash()
+	}
+
+	return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)}
+}
+func NewFloatObject(val float64) *Float { return &Float{Value: val} }
+
 type String struct {
 	Value string
 }
@@ -117,6 +189,15 @@ func (f *Function) Inspect() string {
 	return out.String()
 }
 func (f *Function) Type() ObjectType { return OBJ_FUNC }
+
+// DisplayName returns the name of the function as shown in traces.
+func (f *Function) DisplayName() string {
+	if f.Name == nil {
+		return ANONYMOUS_FUNCTION
+	}
+
+	return f.Name.Value
+}
 func NewFunctionObject(
 	name *ast.Identifier,
 	params []*ast.Identifier,
@@ -131,8 +212,46 @@ func NewFunctionObject(
 	}
 }
 
+// CompiledFunction is a function body compiled to bytecode.
+type CompiledFunction struct {
+	Name      string
+	Instrs    opcode.Instructions
+	NumLocals int // number of local bindings, including parameters
+	NumParams int
+	SourceMap opcode.SourceMap
+}
+
+func (cf *CompiledFunction) Inspect() string {
+	if cf.Name != "" {
+		return fmt.Sprintf("compiled fn %s", cf.Name)
+	}
+
+	return "compiled fn"
+}
+func (cf *CompiledFunction) Type() ObjectType { return OBJ_COMPILED }
+
+// DisplayName returns the name of the function as shown in traces.
+func (cf *CompiledFunction) DisplayName() string {
+	if cf.Name == "" {
+		return ANONYMOUS_FUNCTION
+	}
+
+	return cf.Name
+}
+
+// Closure pairs a compiled function with the free variables it captured when
+// it was created.
+type Closure struct {
+	Fn   *CompiledFunction
+	Free []Object
+}
+
+func (c *Closure) Inspect() string  { return fmt.Sprintf("closure[%s]", c.Fn.Inspect()) }
+func (c *Closure) Type() ObjectType { return OBJ_CLOSURE }
+
 type Class struct {
 	Name    *ast.Identifier
+	Parent  *Class
 	Methods []*ast.FunctionStatement
 	Env     *Environment
 }
@@ -145,13 +264,41 @@ func (cls *Class) Inspect() string {
 	return out.String()
 }
 func (cls *Class) Type() ObjectType { return OBJ_CLASS }
+
+// findMethod looks up a method on the class and then on its ancestors,
+// returning the method along with the class that defines it.
+func (cls *Class) findMethod(name string) (*ast.FunctionStatement, *Class) {
+	for c := cls; c != nil; c = c.Parent {
+		for _, fn := range c.Methods {
+			if fn.Name.Value == name {
+				return fn, c
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// IsSubclassOf reports whether the class is the other class or descends from
+// it.
+func (cls *Class) IsSubclassOf(other *Class) bool {
+	for c := cls; c != nil; c = c.Parent {
+		if c == other {
+			return true
+		}
+	}
+
+	return false
+}
 func NewClassObject(
 	name *ast.Identifier,
+	parent *Class,
 	methods []*ast.FunctionStatement,
 	env *Environment,
 ) *Class {
 	return &Class{
 		Name:    name,
+		Parent:  parent,
 		Methods: methods,
 		Env:     env,
 	}
@@ -177,46 +324,102 @@ func (in *Instance) Get(key string) Object {
 		return fn
 	}
 
-	return NewErr("undefined property on %s: %s", in.Inspect(), key)
+	return nil
 }
 func (in *Instance) Set(key string, value Object) {
 	in.State[key] = value
 }
+func (in *Instance) SetProperty(key string, val Object) Object {
+	in.Set(key, val)
+	return val
+}
+
+// bind returns the method defined by the class bound to the instance. The
+// method sees the instance as `inst`, and the methods of the parent class as
+// `super`.
+func (in *Instance) bind(fn *ast.FunctionStatement, cls *Class) *Function {
+	env := NewEnvFromEnv(cls.Env)
+	env.Set("inst", in)
+	if cls.Parent != nil {
+		env.Set("super", &Super{Instance: in, Class: cls.Parent})
+	}
+
+	return NewFunctionObject(fn.Name, fn.Parameters, fn.Body, env)
+}
+
+// InstanceOf reports whether the instance was created from the class or one
+// of its subclasses.
+func (in *Instance) InstanceOf(cls *Class) bool {
+	return in.class.IsSubclassOf(cls)
+}
 func NewInstance(class *Class) *Instance {
 	inst := &Instance{
-		class: class,
-		State: make(map[string]Object),
+		class:   class,
+		State:   make(map[string]Object),
+		Methods: make(map[string]Object),
+	}
+
+	// bind the methods of the ancestors first so that subclasses override them
+	var chain []*Class
+	for c := class; c != nil; c = c.Parent {
+		chain = append(chain, c)
 	}
 
-	newEnv := NewEnvFromEnv(class.Env)
-	newEnv.Set("inst", inst)
-	methods := make(map[string]Object, len(class.Methods))
-	for _, fn := range class.Methods {
-		methods[fn.Name.String()] = NewFunctionObject(fn.Name, fn.Parameters, fn.Body, newEnv)
+	for i := len(chain) - 1; i >= 0; i-- {
+		for _, fn := range chain[i].Methods {
+			inst.Methods[fn.Name.Value] = inst.bind(fn, chain[i])
+		}
 	}
 
-	inst.Methods = methods
 	return inst
 }
 
-type List struct {
-	Elems []Object
+// Super gives a method access to the methods of its class's parent, bound to
+// the same instance.
+type Super struct {
+	Instance *Instance
+	Class    *Class
 }
 
-func (l *List) Inspect() string {
-	var out strings.Builder
+func (s *Super) Inspect() string  { return fmt.Sprintf("<super: %s>", s.Class.Name) }
+func (s *Super) Type() ObjectType { return OBJ_SUPER }
 
-	es := []string{}
-	for _, elem := range l.Elems {
-		es = append(es, elem.Inspect())
+// Get returns the named method of the parent class, or nil if it has none.
+func (s *Super) Get(key string) Object {
+	fn, cls := s.Class.findMethod(key)
+	if fn == nil {
+		return nil
 	}
 
-	out.WriteString("[")
-	out.WriteString(strings.Join(es, ", "))
-	out.WriteString("]")
+	return s.Instance.bind(fn, cls)
+}
+
+// Module is an imported module, exposing the names it exports.
+type Module struct {
+	Name    string
+	Exports map[string]Object
+}
+
+func (m *Module) Inspect() string  { return fmt.Sprintf("<module: %s>", m.Name) }
+func (m *Module) Type() ObjectType { return OBJ_MODULE }
 
-	return out.String()
+// Get returns the named export of the module, or nil if it has none.
+func (m *Module) Get(key string) Object {
+	return m.Exports[key]
 }
+
+// Importer loads the modules imported by a program.
+type Importer interface {
+	// Import returns the module found at the path, as imported from the
+	// position, or an error.
+	Import(path string, from token.Position) Object
+}
+
+type List struct {
+	Elems []Object
+}
+
+func (l *List) Inspect() string          { return inspect(l, map[Object]bool{}) }
 func (l *List) Type() ObjectType         { return OBJ_LIST }
 func NewListObject(elems []Object) *List { return &List{Elems: elems} }
 
@@ -233,18 +436,88 @@ type Map struct {
 	Entries map[HashKey]KVPair
 }
 
-func (m *Map) Inspect() string {
-	var out strings.Builder
+func (m *Map) Inspect() string  { return inspect(m, map[Object]bool{}) }
+func (m *Map) Type() ObjectType { return OBJ_MAP }
+
+// inspect renders a value, tracking the lists and maps being rendered so that
+// one that contains itself is rendered as [...] or {...} where it recurs.
+func inspect(obj Object, visiting map[Object]bool) string {
+	switch obj := obj.(type) {
+	case *List:
+		if visiting[obj] {
+			return "[...]"
+		}
+		visiting[obj] = true
+		defer delete(visiting, obj)
+
+		es := make([]string, 0, len(obj.Elems))
+		for _, elem := range obj.Elems {
+			es = append(es, inspect(elem, visiting))
+		}
+
+		return "[" + strings.Join(es, ", ") + "]"
+	case *Map:
+		if visiting[obj] {
+			return "{...}"
+		}
+		visiting[obj] = true
+		defer delete(visiting, obj)
+
+		kvs := make([]string, 0, len(obj.Entries))
+		for _, pair := range obj.Pairs() {
+			kvs = append(kvs, fmt.Sprintf("%s:%s", pair.Key.Inspect(), inspect(pair.Value, visiting)))
+		}
+
+		return "{" + strings.Join(kvs, ", ") + "}"
+	default:
+		return obj.Inspect()
+	}
+}
 
-	kvs := []string{}
+// Pairs returns the entries of the map ordered by key: numbers in numeric
+// order, followed by strings in lexical order.
+func (m *Map) Pairs() []KVPair {
+	pairs := make([]KVPair, 0, len(m.Entries))
 	for _, pair := range m.Entries {
-		kvs = append(kvs, fmt.Sprintf("%s:%s", pair.Key.Inspect(), pair.Value.Inspect()))
+		pairs = append(pairs, pair)
 	}
 
-	out.WriteString(fmt.Sprintf("{%s}", strings.Join(kvs, ", ")))
-	return out.String()
+	sort.Slice(pairs, func(i, j int) bool {
+		return keyLess(pairs[i].Key, pairs[j].Key)
+	})
+
+	return pairs
+}
+
+// keyLess orders map keys, which are numbers or strings.
+func keyLess(a, b Object) bool {
+	if isNumeric(a) != isNumeric(b) {
+		return isNumeric(a)
+	}
+
+	if !isNumeric(a) {
+		return a.(*String).Value < b.(*String).Value
+	}
+
+	x, aInt := a.(*Integer)
+	y, bInt := b.(*Integer)
+	if aInt && bInt {
+		return x.Value < y.Value
+	}
+
+	return toFloat(a) < toFloat(b)
+}
+
+// Get returns the value of the string key, so that maps can be read like
+// records with `m.key`, or nil if there is no such key.
+func (m *Map) Get(key string) Object {
+	kv, ok := m.Entries[NewStringObject(key).Hash()]
+	if !ok {
+		return nil
+	}
+
+	return kv.Value
 }
-func (m *Map) Type() ObjectType { return OBJ_MAP }
 
 type Builtin struct {
 	Fn BuiltinFn
@@ -256,7 +529,7 @@ func (b *Builtin) Type() ObjectType { return OBJ_BUILTIN }
 type Null struct{}
 
 func (n *Null) Inspect() string  { return "null" }
-func (n *Null) Type() ObjectType { return OBJ_BOOLEAN }
+func (n *Null) Type() ObjectType { return OBJ_NULL }
 
 type ReturnVal struct {
 	Value Object
@@ -266,15 +539,134 @@ func (rv *ReturnVal) Inspect() string    { return rv.Value.Inspect() }
 func (rv *ReturnVal) Type() ObjectType   { return OBJ_RETURN }
 func NewReturnVal(val Object) *ReturnVal { return &ReturnVal{Value: val} }
 
+// Break signals that the innermost enclosing loop should stop.
+type Break struct{}
+
+func (b *Break) Inspect() string  { return "break" }
+func (b *Break) Type() ObjectType { return OBJ_BREAK }
+
+// Continue signals that the innermost enclosing loop should skip to its next
+// iteration.
+type Continue struct{}
+
+func (c *Continue) Inspect() string  { return "continue" }
+func (c *Continue) Type() ObjectType { return OBJ_CONTINUE }
+
+// TraceFrame is a function that was executing when an error was raised, and
+// the position it had reached.
+type TraceFrame struct {
+	Function string
+	Pos      token.Position
+}
+
+// ErrKind classifies an error so that handlers can tell failures apart.
+type ErrKind string
+
+const (
+	ERR_ERROR         ErrKind = "Error"
+	ERR_TYPE          ErrKind = "TypeError"
+	ERR_NAME          ErrKind = "NameError"
+	ERR_INDEX         ErrKind = "IndexError"
+	ERR_KEY           ErrKind = "KeyError"
+	ERR_IMPORT        ErrKind = "ImportError"
+	ERR_RECURSION     ErrKind = "RecursionError"
+	ERR_VALUE         ErrKind = "ValueError"
+	ERR_ZERO_DIVISION ErrKind = "ZeroDivisionError"
+	ERR_MATCH         ErrKind = "MatchError"
+
+	// the program ran out of its budget
+	ERR_STEP_LIMIT ErrKind = "StepLimitError"
+	ERR_CANCELLED  ErrKind = "CancelledError"
+)
+
 type Err struct {
-	Msg string
+	Kind ErrKind
+	Msg  string
+
+	// Value is the object passed to throw, if the error was thrown by a
+	// program rather than raised by the runtime.
+	Value Object
+
+	// Node is the AST node that raised the error, when known.
+	Node ast.Node
+
+	// Trace is the call stack at the time of the error, starting with the
+	// frame that raised it.
+	Trace []TraceFrame
 }
 
 func (e *Err) Inspect() string  { return "ERROR: " + e.Msg }
 func (e *Err) Type() ObjectType { return OBJ_ERR }
 
+// TRACEBACK_LIMIT is the number of calls shown by a traceback.
+const TRACEBACK_LIMIT = 20
+
+// Traceback formats the error with its call stack, most recent call last.
+func (e *Err) Traceback() string {
+	if len(e.Trace) == 0 {
+		return e.Inspect()
+	}
+
+	var out strings.Builder
+
+	out.WriteString("Traceback (most recent call last):\n")
+	for i := len(e.Trace) - 1; i >= 0; i-- {
+		// show only the outermost and innermost calls of a deep stack
+		if len(e.Trace) > TRACEBACK_LIMIT && i == len(e.Trace)-TRACEBACK_LIMIT/2-1 {
+			elided := len(e.Trace) - TRACEBACK_LIMIT
+			out.WriteString(fmt.Sprintf("  ... %d more calls ...\n", elided))
+			i -= elided - 1
+			continue
+		}
+
+		frame := e.Trace[i]
+		out.WriteString(fmt.Sprintf("  %s in %s\n", frame.Pos, frame.Function))
+	}
+	out.WriteString(e.Inspect())
+
+	return out.String()
+}
+
 func NewErr(format string, args ...interface{}) *Err {
-	return &Err{Msg: fmt.Sprintf(format, args...)}
+	return NewErrWithKind(ERR_ERROR, format, args...)
+}
+
+func NewErrWithKind(kind ErrKind, format string, args ...interface{}) *Err {
+	return &Err{Kind: kind, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Exception is an error that has been caught, exposed to the program as a
+// value with message, kind, trace and value properties.
+type Exception struct {
+	Err *Err
+}
+
+func (ex *Exception) Inspect() string  { return fmt.Sprintf("%s: %s", ex.Err.Kind, ex.Err.Msg) }
+func (ex *Exception) Type() ObjectType { return OBJ_EXCEPTION }
+
+// Get returns the named property of the exception, or nil if there is no such
+// property.
+func (ex *Exception) Get(key string) Object {
+	switch key {
+	case "message":
+		return NewStringObject(ex.Err.Msg)
+	case "kind":
+		return NewStringObject(string(ex.Err.Kind))
+	case "trace":
+		frames := make([]Object, 0, len(ex.Err.Trace))
+		for i := len(ex.Err.Trace) - 1; i >= 0; i-- {
+			frame := ex.Err.Trace[i]
+			frames = append(frames, NewStringObject(fmt.Sprintf("%s in %s", frame.Pos, frame.Function)))
+		}
+		return NewListObject(frames)
+	case "value":
+		if ex.Err.Value != nil {
+			return ex.Err.Value
+		}
+		return NewStringObject(ex.Err.Msg)
+	default:
+		return nil
+	}
 }
 
 func IsErr(obj Object) bool {
@@ -284,3 +676,17 @@ func IsErr(obj Object) bool {
 
 	return false
 }
+
+// IsAbrupt reports whether the object is an error, or the signal of a return,
+// break or continue statement. Expressions propagate these rather than using
+// them as values.
+func IsAbrupt(obj Object) bool {
+	if obj != nil {
+		switch obj.Type() {
+		case OBJ_ERR, OBJ_RETURN, OBJ_BREAK, OBJ_CONTINUE:
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,6 +1,7 @@
 package object_test
 
 import (
+	"math"
 	"testing"
 
 	"github.com/donovandicks/gomonkey/internal/object"
@@ -40,6 +41,36 @@ func TestObject_Hashable(t *testing.T) {
 			second: object.NewIntegerObject(2),
 			equal:  false,
 		},
+		{
+			name:   "floats: equal",
+			first:  object.NewFloatObject(1.5),
+			second: object.NewFloatObject(1.5),
+			equal:  true,
+		},
+		{
+			name:   "floats: signed zeros",
+			first:  object.NewFloatObject(0),
+			second: object.NewFloatObject(math.Copysign(0, -1)),
+			equal:  true,
+		},
+		{
+			name:   "floats: integral",
+			first:  object.NewFloatObject(2),
+			second: object.NewIntegerObject(2),
+			equal:  true,
+		},
+		{
+			name:   "floats: fractional",
+			first:  object.NewFloatObject(2.5),
+			second: object.NewIntegerObject(2),
+			equal:  false,
+		},
+		{
+			name:   "floats: unequal",
+			first:  object.NewFloatObject(1.5),
+			second: object.NewFloatObject(2.5),
+			equal:  false,
+		},
 		{
 			name:   "different types",
 			first:  object.NewIntegerObject(1),
@@ -62,3 +93,47 @@ func TestObject_Hashable(t *testing.T) {
 		})
 	}
 }
+
+func TestObject_FloatInspect(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		value    float64
+		expected string
+	}{
+		{value: 3.14, expected: "3.14"},
+		{value: 2, expected: "2.0"},
+		{value: -0.5, expected: "-0.5"},
+		{value: 1000000, expected: "1000000.0"},
+		{value: 1e-9, expected: "1e-09"},
+		{value: 1e21, expected: "1e+21"},
+		{value: math.Inf(1), expected: "+Inf"},
+	}
+
+	for _, testCase := range cases {
+		tc := testCase
+
+		t.Run(tc.expected, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, object.NewFloatObject(tc.value).Inspect())
+		})
+	}
+}
+
+func TestObject_CyclicInspect(t *testing.T) {
+	t.Parallel()
+
+	list := object.NewListObject([]object.Object{object.NewIntegerObject(1)})
+	list.Elems = append(list.Elems, list)
+
+	self := &object.Map{Entries: map[object.HashKey]object.KVPair{}}
+	key := object.NewStringObject("self")
+	self.Entries[key.Hash()] = object.NewKVPair(key, self)
+
+	shared := object.NewListObject([]object.Object{object.NewIntegerObject(1)})
+
+	assert.Equal(t, "[1, [...]]", list.Inspect())
+	assert.Equal(t, "{self:{...}}", self.Inspect())
+	assert.Equal(t, "[[1], [1]]", object.NewListObject([]object.Object{shared, shared}).Inspect())
+}
@@ -0,0 +1,413 @@
+package object
+
+import "math"
+
+// The operations below are shared by the tree-walking interpreter and the
+// bytecode VM so that both engines produce the same results.
+
+func bangOp(right Object) Object {
+	switch right {
+	case TrueBool:
+		return FalseBool
+	case FalseBool:
+		return TrueBool
+	case NullObject:
+		return TrueBool
+	default:
+		return FalseBool
+	}
+}
+
+func minusOp(right Object) Object {
+	switch right := right.(type) {
+	case *Integer:
+		return NewIntegerObject(-right.Value)
+	case *Float:
+		return NewFloatObject(-right.Value)
+	default:
+		return NewErrWithKind(ERR_TYPE, "invalid operator '-' for type %s", right.Type())
+	}
+}
+
+func bitNotOp(right Object) Object {
+	i, ok := right.(*Integer)
+	if !ok {
+		return NewErrWithKind(ERR_TYPE, "invalid operator '~' for type %s", right.Type())
+	}
+
+	return NewIntegerObject(^i.Value)
+}
+
+// PrefixOp applies a prefix operator to its operand.
+func PrefixOp(operator string, right Object) Object {
+	switch operator {
+	case "!":
+		return bangOp(right)
+	case "-":
+		return minusOp(right)
+	case "~":
+		return bitNotOp(right)
+	default:
+		return NewErrWithKind(ERR_TYPE, "unknown operator '%s' for type %s", operator, right.Type())
+	}
+}
+
+func integerInfixOp(operator string, left, right Object) Object {
+	l := left.(*Integer).Value
+	r := right.(*Integer).Value
+
+	switch operator {
+	case "+":
+		return NewIntegerObject(l + r)
+	case "-":
+		return NewIntegerObject(l - r)
+	case "*":
+		return NewIntegerObject(l * r)
+	case "/", "%":
+		if r == 0 {
+			return errZeroDivision(operator)
+		}
+
+		if operator == "/" {
+			return NewIntegerObject(l / r)
+		}
+		return NewIntegerObject(l % r)
+	case "**":
+		if r < 0 {
+			return NewFloatObject(math.Pow(float64(l), float64(r)))
+		}
+		return NewIntegerObject(intPow(l, r))
+	case "&":
+		return NewIntegerObject(l & r)
+	case "|":
+		return NewIntegerObject(l | r)
+	case "^":
+		return NewIntegerObject(l ^ r)
+	case "<<", ">>":
+		if r < 0 {
+			return NewErrWithKind(ERR_VALUE, "negative shift count %d", r)
+		}
+
+		if operator == "<<" {
+			return NewIntegerObject(l << r)
+		}
+		return NewIntegerObject(l >> r)
+	case "<":
+		return BoolFromNative(l < r)
+	case ">":
+		return BoolFromNative(l > r)
+	case "<=":
+		return BoolFromNative(l <= r)
+	case ">=":
+		return BoolFromNative(l >= r)
+	case "==":
+		return BoolFromNative(l == r)
+	case "!=":
+		return BoolFromNative(l != r)
+	default:
+		return NewErrWithKind(ERR_TYPE, "unknown integer operator '%s' on integers %d, %d", operator, l, r)
+	}
+}
+
+// intPow raises the base to a non-negative exponent by repeated squaring.
+func intPow(base, exp int64) int64 {
+	res := int64(1)
+	for exp > 0 {
+		if exp&1 == 1 {
+			res *= base
+		}
+
+		base *= base
+		exp >>= 1
+	}
+
+	return res
+}
+
+func errZeroDivision(operator string) *Err {
+	if operator == "%" {
+		return NewErrWithKind(ERR_ZERO_DIVISION, "modulo by zero")
+	}
+
+	return NewErrWithKind(ERR_ZERO_DIVISION, "division by zero")
+}
+
+// isNumeric reports whether the object is an integer or a float.
+func isNumeric(obj Object) bool {
+	t := obj.Type()
+	return t == OBJ_INTEGER || t == OBJ_FLOAT
+}
+
+// toFloat converts a numeric object to a native float, promoting integers.
+func toFloat(obj Object) float64 {
+	if i, ok := obj.(*Integer); ok {
+		return float64(i.Value)
+	}
+
+	return obj.(*Float).Value
+}
+
+func floatInfixOp(operator string, l, r float64) Object {
+	switch operator {
+	case "+":
+		return NewFloatObject(l + r)
+	case "-":
+		return NewFloatObject(l - r)
+	case "*":
+		return NewFloatObject(l * r)
+	case "/", "%":
+		if r == 0 {
+			return errZeroDivision(operator)
+		}
+
+		if operator == "/" {
+			return NewFloatObject(l / r)
+		}
+		return NewFloatObject(math.Mod(l, r))
+	case "**":
+		return NewFloatObject(math.Pow(l, r))
+	case "<":
+		return BoolFromNative(l < r)
+	case ">":
+		return BoolFromNative(l > r)
+	case "<=":
+		return BoolFromNative(l <= r)
+	case ">=":
+		return BoolFromNative(l >= r)
+	case "==":
+		return BoolFromNative(l == r)
+	case "!=":
+		return BoolFromNative(l != r)
+	default:
+		return NewErrWithKind(ERR_TYPE, "unknown float operator '%s' on floats %g, %g", operator, l, r)
+	}
+}
+
+func stringInfixOp(operator string, left, right Object) Object {
+	l := left.(*String).Value
+	r := right.(*String).Value
+
+	switch operator {
+	case "+":
+		return NewStringObject(l + r)
+	case "==":
+		return BoolFromNative(l == r)
+	case "!=":
+		return BoolFromNative(l != r)
+	default:
+		return NewErrWithKind(ERR_TYPE, "unknown string operator '%s' on strings %s, %s", operator, l, r)
+	}
+}
+
+func instanceOfOp(left, right Object) Object {
+	cls, ok := right.(*Class)
+	if !ok {
+		return NewErrWithKind(ERR_TYPE, "right operand of 'instanceof' must be a class, got %s", right.Type())
+	}
+
+	inst, ok := left.(*Instance)
+	return BoolFromNative(ok && inst.InstanceOf(cls))
+}
+
+// InfixOp applies a binary operator to its operands.
+func InfixOp(operator string, left, right Object) Object {
+	switch {
+	case operator == "instanceof":
+		return instanceOfOp(left, right)
+	case left.Type() == OBJ_INTEGER && right.Type() == OBJ_INTEGER:
+		return integerInfixOp(operator, left, right)
+	case isNumeric(left) && isNumeric(right):
+		// mixed arithmetic promotes the integer operand to a float
+		return floatInfixOp(operator, toFloat(left), toFloat(right))
+	case left.Type() == OBJ_STR && right.Type() == OBJ_STR:
+		return stringInfixOp(operator, left, right)
+	case operator == "==":
+		return BoolFromNative(left == right)
+	case operator == "!=":
+		return BoolFromNative(left != right)
+	case left.Type() != right.Type():
+		return NewErrWithKind(
+			ERR_TYPE,
+			"type error: cannot perform '%s' on %s, %s",
+			operator,
+			left.Type(),
+			right.Type(),
+		)
+	default:
+		return NewErrWithKind(
+			ERR_TYPE,
+			"unknown operator '%s' for types %s, %s",
+			operator,
+			left.Type(),
+			right.Type(),
+		)
+	}
+}
+
+func listIndex(left, index Object) Object {
+	l := left.(*List)
+	idx := index.(*Integer).Value
+
+	pos := idx
+	if pos < 0 {
+		pos += int64(len(l.Elems))
+	}
+
+	if pos < 0 || pos >= int64(len(l.Elems)) {
+		return NewErrWithKind(ERR_INDEX, "index out of bounds: %d", idx)
+	}
+
+	return l.Elems[pos]
+}
+
+func mapIndex(left, index Object) Object {
+	l := left.(*Map)
+	key, _ := index.(HashableObject)
+
+	kv, ok := l.Entries[key.Hash()]
+	if !ok {
+		return NewErrWithKind(ERR_KEY, "no key found for %s (hash=%d)", key.Inspect(), key.Hash().Value)
+	}
+
+	return kv.Value
+}
+
+// Index looks up the element of a list or the value of a map.
+func Index(left, index Object) Object {
+	switch left.Type() {
+	case OBJ_LIST:
+		if index.Type() != OBJ_INTEGER {
+			return NewErrWithKind(ERR_TYPE, "cannot index list using non-integer type %s", index.Type())
+		}
+		return listIndex(left, index)
+	case OBJ_MAP:
+		if !IsHashable(index) {
+			return NewErrWithKind(ERR_TYPE, "cannot index map using non-hashable type %s", index.Type())
+		}
+		return mapIndex(left, index)
+	default:
+		return NewErrWithKind(ERR_TYPE, "cannot index %s object", left.Type())
+	}
+}
+
+// OptionalIndex looks up the element of a list or the value of a map like
+// Index, but gives null rather than raising if the left operand is null or
+// has no such element or key.
+func OptionalIndex(left, index Object) Object {
+	if left == NullObject {
+		return NullObject
+	}
+
+	return Optional(Index(left, index))
+}
+
+// Optional turns the error raised by looking up a missing element, key or
+// property into null, passing any other result through.
+func Optional(res Object) Object {
+	if err, ok := res.(*Err); ok && (err.Kind == ERR_INDEX || err.Kind == ERR_KEY) {
+		return NullObject
+	}
+
+	return res
+}
+
+// GetProperty looks up the named property of the object.
+func GetProperty(obj Object, name string) Object {
+	props, ok := obj.(PropertyObject)
+	if !ok {
+		return NewErrWithKind(ERR_TYPE, "object %s has no properties", obj.Type())
+	}
+
+	val := props.Get(name)
+	if val == nil {
+		return NewErrWithKind(ERR_TYPE, "object %s has no property %s", obj.Type(), name)
+	}
+
+	return val
+}
+
+// OptionalProperty looks up the named property of the object like
+// GetProperty, but gives null rather than raising if the object is null or
+// has no such property.
+func OptionalProperty(obj Object, name string) Object {
+	if obj == NullObject {
+		return NullObject
+	}
+
+	if props, ok := obj.(PropertyObject); ok && props.Get(name) == nil {
+		return NullObject
+	}
+
+	return GetProperty(obj, name)
+}
+
+// SetProperty assigns the value to the named property of the object,
+// returning the value.
+func SetProperty(obj Object, name string, val Object) Object {
+	settable, ok := obj.(SettableObject)
+	if !ok {
+		return NewErrWithKind(ERR_TYPE, "cannot assign property %s of %s object", name, obj.Type())
+	}
+
+	return settable.SetProperty(name, val)
+}
+
+// SetIndex assigns the value to the element of a list or the key of a map,
+// returning the value.
+func SetIndex(left, index, val Object) Object {
+	switch left := left.(type) {
+	case *List:
+		i, ok := index.(*Integer)
+		if !ok {
+			return NewErrWithKind(ERR_TYPE, "cannot index list using non-integer type %s", index.Type())
+		}
+
+		pos := i.Value
+		if pos < 0 {
+			pos += int64(len(left.Elems))
+		}
+
+		if pos < 0 || pos >= int64(len(left.Elems)) {
+			return NewErrWithKind(ERR_INDEX, "index out of bounds: %d", i.Value)
+		}
+
+		left.Elems[pos] = val
+	case *Map:
+		key, ok := index.(HashableObject)
+		if !ok {
+			return NewErrWithKind(ERR_TYPE, "cannot index map using non-hashable type %s", index.Type())
+		}
+
+		left.Entries[key.Hash()] = NewKVPair(key, val)
+	default:
+		return NewErrWithKind(ERR_TYPE, "cannot assign to index of %s object", left.Type())
+	}
+
+	return val
+}
+
+// IterElems returns the elements visited when iterating over the object:
+// the elements of a list, the keys of a map in the order given by Map.Pairs,
+// or the characters of a string.
+func IterElems(obj Object) ([]Object, bool) {
+	switch obj := obj.(type) {
+	case *List:
+		elems := make([]Object, len(obj.Elems))
+		copy(elems, obj.Elems)
+		return elems, true
+	case *Map:
+		keys := make([]Object, 0, len(obj.Entries))
+		for _, pair := range obj.Pairs() {
+			keys = append(keys, pair.Key)
+		}
+		return keys, true
+	case *String:
+		chars := make([]Object, 0, len(obj.Value))
+		for _, ch := range obj.Value {
+			chars = append(chars, NewStringObject(string(ch)))
+		}
+		return chars, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,143 @@
+package object
+
+import "github.com/donovandicks/gomonkey/internal/ast"
+
+// Pattern is the pattern of a match arm, held in the constants of compiled
+// bytecode.
+type Pattern struct {
+	Pattern ast.Pattern
+}
+
+func (p *Pattern) Inspect() string  { return p.Pattern.String() }
+func (p *Pattern) Type() ObjectType { return OBJ_PATTERN }
+
+// Match matches the value against the pattern, calling bind with each name the
+// pattern binds in the order listed by ast.Bindings. A value that does not fit
+// the pattern gives a MatchError saying why.
+//
+// Class patterns look up their class in the environment, which may be nil if
+// the pattern has none.
+func Match(pat ast.Pattern, val Object, env *Environment, bind func(name string, val Object)) *Err {
+	switch pat := pat.(type) {
+	case *ast.BindingPattern:
+		if !pat.IsWildcard() {
+			bind(pat.Name.Value, val)
+		}
+	case *ast.LiteralPattern:
+		if InfixOp("==", literalValue(pat.Value), val) != TrueBool {
+			return NewErrWithKind(ERR_MATCH, "expected %s, got %s", pat.String(), val.Inspect())
+		}
+	case *ast.ListPattern:
+		return matchList(pat, val, env, bind)
+	case *ast.MapPattern:
+		return matchMap(pat, val, env, bind)
+	case *ast.ClassPattern:
+		var obj Object
+		if env != nil {
+			obj, _ = env.Get(pat.Class.Value)
+		}
+
+		if obj == nil {
+			return NewErrWithKind(ERR_NAME, "undefined variable '%s'", pat.Class.Value)
+		}
+
+		cls, ok := obj.(*Class)
+		if !ok {
+			return NewErrWithKind(ERR_TYPE, "cannot match instances of non-class type %s", obj.Type())
+		}
+
+		if inst, ok := val.(*Instance); !ok || !inst.InstanceOf(cls) {
+			return NewErrWithKind(ERR_MATCH, "expected an instance of %s, got %s", cls.Name.Value, val.Type())
+		}
+
+		return matchMap(pat.Fields, val, env, bind)
+	}
+
+	return nil
+}
+
+func matchList(pat *ast.ListPattern, val Object, env *Environment, bind func(string, Object)) *Err {
+	list, ok := val.(*List)
+	if !ok {
+		return NewErrWithKind(ERR_MATCH, "expected a list, got %s", val.Type())
+	}
+
+	n := len(pat.Elems)
+	if pat.Rest == nil && len(list.Elems) != n {
+		return NewErrWithKind(ERR_MATCH, "expected a list of %d elements, got %d", n, len(list.Elems))
+	}
+
+	if len(list.Elems) < n {
+		return NewErrWithKind(ERR_MATCH, "expected a list of at least %d elements, got %d", n, len(list.Elems))
+	}
+
+	for i, elem := range pat.Elems {
+		if err := Match(elem, list.Elems[i], env, bind); err != nil {
+			return err
+		}
+	}
+
+	if pat.Rest != nil && pat.Rest.Value != "_" {
+		rest := make([]Object, len(list.Elems)-n)
+		copy(rest, list.Elems[n:])
+		bind(pat.Rest.Value, NewListObject(rest))
+	}
+
+	return nil
+}
+
+// matchMap matches the keys of a map, or the properties of any other object
+// that has them.
+func matchMap(pat *ast.MapPattern, val Object, env *Environment, bind func(string, Object)) *Err {
+	m, isMap := val.(*Map)
+	props, hasProps := val.(PropertyObject)
+	if !isMap && !hasProps {
+		return NewErrWithKind(ERR_MATCH, "expected a map, got %s", val.Type())
+	}
+
+	for i, key := range pat.Keys {
+		k := literalValue(key)
+
+		var v Object
+		if isMap {
+			hashable, ok := k.(HashableObject)
+			if !ok {
+				return NewErrWithKind(ERR_TYPE, "cannot index map using non-hashable type %s", k.Type())
+			}
+
+			if kv, ok := m.Entries[hashable.Hash()]; ok {
+				v = kv.Value
+			}
+		} else if name, ok := k.(*String); ok {
+			v = props.Get(name.Value)
+		}
+
+		if v == nil {
+			return NewErrWithKind(ERR_MATCH, "missing key %s", k.Inspect())
+		}
+
+		if err := Match(pat.Values[i], v, env, bind); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// literalValue returns the value of a literal in a pattern.
+func literalValue(expr ast.Expression) Object {
+	switch expr := expr.(type) {
+	case *ast.IntegerLiteral:
+		return NewIntegerObject(expr.Value)
+	case *ast.FloatLiteral:
+		return NewFloatObject(expr.Value)
+	case *ast.StringLiteral:
+		return NewStringObject(expr.Value)
+	case *ast.Boolean:
+		return BoolFromNative(expr.Value)
+	case *ast.PrefixExpression:
+		return minusOp(literalValue(expr.Right))
+	default:
+		return NullObject
+	}
+}
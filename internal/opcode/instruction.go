@@ -3,11 +3,21 @@ package opcode
 import (
 	"encoding/binary"
 	"fmt"
+	"sort"
 	"strings"
+
+	"github.com/donovandicks/gomonkey/internal/token"
 )
 
 type Instructions []byte
 
+// MaxOperand returns the largest operand that fits in the width.
+func MaxOperand(width int) int {
+	return 1<<(8*width) - 1
+}
+
+// NewInstruction encodes the instruction. It panics if an operand does not fit
+// in its width, which callers must check with MaxOperand.
 func NewInstruction(code OpCode, operands []int) []byte {
 	opWidths := code.OperandWidth()
 	if code.Name() == nil || opWidths == nil {
@@ -25,7 +35,13 @@ func NewInstruction(code OpCode, operands []int) []byte {
 	offset := 1
 	for i, op := range operands {
 		width := opWidths[i]
+		if op < 0 || op > MaxOperand(width) {
+			panic(fmt.Sprintf("operand %d of %s does not fit in %d byte(s)", op, *code.Name(), width))
+		}
+
 		switch width {
+		case 1:
+			instr[offset] = byte(op)
 		case 2:
 			binary.BigEndian.PutUint16(instr[offset:], uint16(op))
 		}
@@ -44,11 +60,12 @@ func (instr Instructions) String() string {
 		opWidths := opCode.OperandWidth()
 		opName := opCode.Name()
 		if opWidths == nil || opName == nil {
-			fmt.Fprintf(&out, "ERROR: undefined opcode %b", instr[i])
+			fmt.Fprintf(&out, "ERROR: undefined opcode %b\n", instr[i])
+			i += 1
 			continue
 		}
 
-		operands, read := instr.Operands(opWidths)
+		operands, read := instr[i+1:].Operands(opWidths)
 		fmt.Fprintf(&out, "%04d %s\n", i, instr.Format(*opName, opWidths, operands))
 
 		i += 1 + read // incr by the opcode and the subsequent bytes read
@@ -60,26 +77,34 @@ func (instr Instructions) String() string {
 func (instr Instructions) Format(opName string, opWidths []int, operands []int) string {
 	opCount := len(opWidths)
 
-	if len(opWidths) != opCount {
+	if len(operands) != opCount {
 		return fmt.Sprintf("ERROR: opcount %d does not match number of operands %d", opCount, len(operands))
 	}
 
 	switch opCount {
+	case 0:
+		return opName
 	case 1:
 		return fmt.Sprintf("%s %d", opName, operands[0])
+	case 2:
+		return fmt.Sprintf("%s %d %d", opName, operands[0], operands[1])
 	default:
 		return fmt.Sprintf("ERROR: unimplemented for %d operands", opCount)
 	}
 }
 
+// Operands decodes the operands at the start of the instructions, returning
+// them along with the number of bytes read.
 func (instr Instructions) Operands(opWidths []int) ([]int, int) {
 	operands := make([]int, len(opWidths))
 	offset := 0
 
 	for i, width := range opWidths {
 		switch width {
+		case 1:
+			operands[i] = int(ReadUint8(instr[offset:]))
 		case 2:
-			operands[i] = int(binary.BigEndian.Uint16(instr[offset:]))
+			operands[i] = int(ReadUint16(instr[offset:]))
 		}
 
 		offset += width
@@ -87,3 +112,37 @@ func (instr Instructions) Operands(opWidths []int) ([]int, int) {
 
 	return operands, offset
 }
+
+// ReadUint8 decodes a one byte operand.
+func ReadUint8(instr Instructions) uint8 {
+	return instr[0]
+}
+
+// ReadUint16 decodes a two byte big endian operand.
+func ReadUint16(instr Instructions) uint16 {
+	return binary.BigEndian.Uint16(instr)
+}
+
+// SourceMapping attributes the instructions starting at the offset to a
+// position in the source.
+type SourceMapping struct {
+	Offset int
+	Pos    token.Position
+}
+
+// SourceMap locates the source of a sequence of instructions. Mappings are
+// ordered by offset, and each covers the instructions up to the next one.
+type SourceMap []SourceMapping
+
+// Lookup returns the source position of the instruction at the offset.
+func (sm SourceMap) Lookup(offset int) token.Position {
+	idx := sort.Search(len(sm), func(i int) bool {
+		return sm[i].Offset > offset
+	})
+
+	if idx == 0 {
+		return token.Position{}
+	}
+
+	return sm[idx-1].Pos
+}
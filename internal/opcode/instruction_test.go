@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/donovandicks/gomonkey/internal/opcode"
+	"github.com/donovandicks/gomonkey/internal/token"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,7 +23,28 @@ func TestInstruction_New(t *testing.T) {
 			oc:          opcode.OpConstant,
 			operands:    []int{65534},
 			expected:    []byte{byte(opcode.OpConstant), 255, 254},
-			expectedStr: "0000 OpConstant 65534",
+			expectedStr: "0000 OP_CONST 65534\n",
+		},
+		{
+			name:        "no operands",
+			oc:          opcode.OpAdd,
+			operands:    []int{},
+			expected:    []byte{byte(opcode.OpAdd)},
+			expectedStr: "0000 OP_ADD\n",
+		},
+		{
+			name:        "one byte operand",
+			oc:          opcode.OpGetLocal,
+			operands:    []int{255},
+			expected:    []byte{byte(opcode.OpGetLocal), 255},
+			expectedStr: "0000 OP_GET_LOCAL 255\n",
+		},
+		{
+			name:        "multiple operands",
+			oc:          opcode.OpClosure,
+			operands:    []int{65534, 255},
+			expected:    []byte{byte(opcode.OpClosure), 255, 254, 255},
+			expectedStr: "0000 OP_CLOSURE 65534 255\n",
 		},
 	}
 
@@ -34,6 +56,46 @@ func TestInstruction_New(t *testing.T) {
 			instr := opcode.NewInstruction(tc.oc, tc.operands)
 
 			assert.Equal(t, tc.expected, instr)
+			assert.Equal(t, tc.expectedStr, opcode.Instructions(instr).String())
 		})
 	}
 }
+
+func TestInstruction_NewOperandTooLarge(t *testing.T) {
+	t.Parallel()
+
+	assert.PanicsWithValue(t, "operand 256 of OP_GET_LOCAL does not fit in 1 byte(s)", func() {
+		opcode.NewInstruction(opcode.OpGetLocal, []int{256})
+	})
+	assert.Panics(t, func() { opcode.NewInstruction(opcode.OpConstant, []int{65536}) })
+}
+
+func TestInstructions_String(t *testing.T) {
+	t.Parallel()
+
+	instrs := opcode.Instructions{}
+	instrs = append(instrs, opcode.NewInstruction(opcode.OpConstant, []int{1})...)
+	instrs = append(instrs, opcode.NewInstruction(opcode.OpSetLocal, []int{2})...)
+	instrs = append(instrs, opcode.NewInstruction(opcode.OpPop, nil)...)
+
+	expected := "0000 OP_CONST 1\n0003 OP_SET_LOCAL 2\n0005 OP_POP\n"
+	assert.Equal(t, expected, instrs.String())
+}
+
+func TestSourceMap_Lookup(t *testing.T) {
+	t.Parallel()
+
+	first := token.Position{Line: 1, Column: 1}
+	second := token.Position{Line: 2, Column: 5}
+
+	sm := opcode.SourceMap{
+		{Offset: 0, Pos: first},
+		{Offset: 4, Pos: second},
+	}
+
+	assert.Equal(t, first, sm.Lookup(0))
+	assert.Equal(t, first, sm.Lookup(3))
+	assert.Equal(t, second, sm.Lookup(4))
+	assert.Equal(t, second, sm.Lookup(10))
+	assert.Equal(t, token.Position{}, sm.Lookup(-1))
+}
@@ -4,17 +4,191 @@ type OpCode byte
 
 const (
 	OpConstant OpCode = iota
+	OpPop
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpMod
+	OpPow
+	OpBitAnd
+	OpBitOr
+	OpBitXor
+	OpShiftLeft
+	OpShiftRight
+	OpTrue
+	OpFalse
+	OpNull
+	OpEqual
+	OpNotEqual
+	OpGreaterThan
+	OpGreaterEqual
+	OpLessThan
+	OpLessEqual
+	OpMinus
+	OpBang
+	OpBitNot
+	OpJumpNotTruthy
+	OpJump
+	OpJumpNull
+	OpGetGlobal
+	OpSetGlobal
+	OpGetLocal
+	OpSetLocal
+	OpDefineLocal
+	OpGetBuiltin
+	OpGetFree
+	OpSetFree
+	OpCaptureLocal
+	OpCaptureFree
+	OpCurrentClosure
+	OpList
+	OpMap
+	OpIndex
+	OpOptionalIndex
+	OpSetIndex
+	OpDup
+	OpIter
+	OpIterNext
+	OpMark
+	OpUnwind
+	OpCall
+	OpReturnValue
+	OpReturn
+	OpClosure
+	OpModule
+	OpGetProperty
+	OpOptionalProperty
+	OpSetProperty
+	OpMatch
+	OpDestructure
 )
 
 var (
 	opNames = map[OpCode]string{
-		OpConstant: "OP_CONST",
+		OpConstant:         "OP_CONST",
+		OpPop:              "OP_POP",
+		OpAdd:              "OP_ADD",
+		OpSub:              "OP_SUB",
+		OpMul:              "OP_MUL",
+		OpDiv:              "OP_DIV",
+		OpMod:              "OP_MOD",
+		OpPow:              "OP_POW",
+		OpBitAnd:           "OP_BIT_AND",
+		OpBitOr:            "OP_BIT_OR",
+		OpBitXor:           "OP_BIT_XOR",
+		OpShiftLeft:        "OP_SHL",
+		OpShiftRight:       "OP_SHR",
+		OpTrue:             "OP_TRUE",
+		OpFalse:            "OP_FALSE",
+		OpNull:             "OP_NULL",
+		OpEqual:            "OP_EQ",
+		OpNotEqual:         "OP_NE",
+		OpGreaterThan:      "OP_GT",
+		OpGreaterEqual:     "OP_GE",
+		OpLessThan:         "OP_LT",
+		OpLessEqual:        "OP_LE",
+		OpMinus:            "OP_MINUS",
+		OpBang:             "OP_BANG",
+		OpBitNot:           "OP_BIT_NOT",
+		OpJumpNotTruthy:    "OP_JUMP_NOT_TRUTHY",
+		OpJump:             "OP_JUMP",
+		OpJumpNull:         "OP_JUMP_NULL",
+		OpGetGlobal:        "OP_GET_GLOBAL",
+		OpSetGlobal:        "OP_SET_GLOBAL",
+		OpGetLocal:         "OP_GET_LOCAL",
+		OpSetLocal:         "OP_SET_LOCAL",
+		OpDefineLocal:      "OP_DEFINE_LOCAL",
+		OpGetBuiltin:       "OP_GET_BUILTIN",
+		OpGetFree:          "OP_GET_FREE",
+		OpSetFree:          "OP_SET_FREE",
+		OpCaptureLocal:     "OP_CAPTURE_LOCAL",
+		OpCaptureFree:      "OP_CAPTURE_FREE",
+		OpCurrentClosure:   "OP_CURRENT_CLOSURE",
+		OpList:             "OP_LIST",
+		OpMap:              "OP_MAP",
+		OpIndex:            "OP_INDEX",
+		OpOptionalIndex:    "OP_OPTIONAL_INDEX",
+		OpSetIndex:         "OP_SET_INDEX",
+		OpDup:              "OP_DUP",
+		OpIter:             "OP_ITER",
+		OpIterNext:         "OP_ITER_NEXT",
+		OpMark:             "OP_MARK",
+		OpUnwind:           "OP_UNWIND",
+		OpCall:             "OP_CALL",
+		OpReturnValue:      "OP_RETURN_VALUE",
+		OpReturn:           "OP_RETURN",
+		OpClosure:          "OP_CLOSURE",
+		OpModule:           "OP_MODULE",
+		OpGetProperty:      "OP_GET_PROPERTY",
+		OpOptionalProperty: "OP_OPTIONAL_PROPERTY",
+		OpSetProperty:      "OP_SET_PROPERTY",
+		OpMatch:            "OP_MATCH",
+		OpDestructure:      "OP_DESTRUCTURE",
 	}
 
 	// opWidths is an array with the number of bytes required for each operand
 	// corresponding to the index of the array
 	opWidths = map[OpCode][]int{
-		OpConstant: {2},
+		OpConstant:         {2}, // index into the constant pool
+		OpPop:              {},
+		OpAdd:              {},
+		OpSub:              {},
+		OpMul:              {},
+		OpDiv:              {},
+		OpMod:              {},
+		OpPow:              {},
+		OpBitAnd:           {},
+		OpBitOr:            {},
+		OpBitXor:           {},
+		OpShiftLeft:        {},
+		OpShiftRight:       {},
+		OpTrue:             {},
+		OpFalse:            {},
+		OpNull:             {},
+		OpEqual:            {},
+		OpNotEqual:         {},
+		OpGreaterThan:      {},
+		OpGreaterEqual:     {},
+		OpLessThan:         {},
+		OpLessEqual:        {},
+		OpMinus:            {},
+		OpBang:             {},
+		OpBitNot:           {},
+		OpJumpNotTruthy:    {2}, // instruction offset to jump to
+		OpJump:             {2}, // instruction offset to jump to
+		OpJumpNull:         {2}, // instruction offset to jump to, leaving the null on the stack
+		OpGetGlobal:        {2}, // index of the global binding
+		OpSetGlobal:        {2}, // index of the global binding
+		OpGetLocal:         {1}, // index of the local binding
+		OpSetLocal:         {1}, // index of the local binding
+		OpDefineLocal:      {1}, // index of the local binding, which gets fresh storage
+		OpGetBuiltin:       {1}, // index of the builtin function
+		OpGetFree:          {1}, // index of the free variable
+		OpSetFree:          {1}, // index of the free variable
+		OpCaptureLocal:     {1}, // index of the local binding to share with a closure
+		OpCaptureFree:      {1}, // index of the free variable to share with a closure
+		OpCurrentClosure:   {},
+		OpList:             {2}, // number of elements
+		OpMap:              {2}, // number of keys and values
+		OpIndex:            {},
+		OpOptionalIndex:    {},
+		OpSetIndex:         {},
+		OpDup:              {1}, // number of values to duplicate
+		OpIter:             {},
+		OpIterNext:         {2}, // instruction offset to jump to once exhausted
+		OpMark:             {1}, // index of the local that records the height of the stack
+		OpUnwind:           {1}, // index of the local that records the height of the stack
+		OpCall:             {1}, // number of arguments
+		OpReturnValue:      {},
+		OpReturn:           {},
+		OpClosure:          {2, 1}, // constant index of the function, number of free variables
+		OpModule:           {2},    // number of exports
+		OpGetProperty:      {2},    // constant index of the property name
+		OpOptionalProperty: {2},    // constant index of the property name
+		OpSetProperty:      {2},    // constant index of the property name
+		OpMatch:            {2},    // constant index of the pattern
+		OpDestructure:      {2},    // constant index of the pattern
 	}
 )
 
@@ -3,9 +3,20 @@ package parser
 import (
 	"fmt"
 
+	"github.com/donovandicks/gomonkey/internal/diagnostic"
 	"github.com/donovandicks/gomonkey/internal/token"
 )
 
+const (
+	CODE_UNEXPECTED_TOKEN   diagnostic.Code = "unexpected-token"
+	CODE_INVALID_LITERAL    diagnostic.Code = "invalid-literal"
+	CODE_MISSING_EXPRESSION diagnostic.Code = "missing-expression"
+	CODE_MISSING_OPENER     diagnostic.Code = "missing-opener"
+	CODE_MISSING_CLOSER     diagnostic.Code = "missing-closer"
+	CODE_ILLEGAL_TOKEN      diagnostic.Code = "illegal-token"
+	CODE_DUPLICATE_BINDING  diagnostic.Code = "duplicate-binding"
+)
+
 type ErrNextTokenInvalid struct {
 	expected token.TokenType
 	actual   token.TokenType
@@ -15,6 +26,8 @@ func (e ErrNextTokenInvalid) Error() string {
 	return fmt.Sprintf("expected next token to be %s, got %s instead", e.expected, e.actual)
 }
 
+func (e ErrNextTokenInvalid) Code() diagnostic.Code { return CODE_UNEXPECTED_TOKEN }
+
 type ErrParseError struct {
 	expected string
 	actual   string
@@ -24,26 +37,73 @@ func (e ErrParseError) Error() string {
 	return fmt.Sprintf("error attempting to parse %s as a valid %s", e.actual, e.expected)
 }
 
+func (e ErrParseError) Code() diagnostic.Code { return CODE_INVALID_LITERAL }
+
 type ErrNoPrefixParser struct {
-	operator string
+	actual token.TokenType
 }
 
 func (e ErrNoPrefixParser) Error() string {
-	return fmt.Sprintf("no prefix parser found for %s", e.operator)
+	return fmt.Sprintf("expected an expression, got %s instead", e.actual)
 }
 
+func (e ErrNoPrefixParser) Code() diagnostic.Code { return CODE_MISSING_EXPRESSION }
+
 type ErrMissingOpener struct {
 	expected string
+	actual   token.TokenType
 }
 
 func (e ErrMissingOpener) Error() string {
-	return fmt.Sprintf("missing opening '%s'", e.expected)
+	return fmt.Sprintf("missing opening '%s', got %s instead", e.expected, e.actual)
 }
 
+func (e ErrMissingOpener) Code() diagnostic.Code { return CODE_MISSING_OPENER }
+
 type ErrMissingCloser struct {
 	expected string
+	actual   token.TokenType
 }
 
 func (e ErrMissingCloser) Error() string {
-	return fmt.Sprintf("missing closing '%s'", e.expected)
+	return fmt.Sprintf("missing closing '%s', got %s instead", e.expected, e.actual)
+}
+
+func (e ErrMissingCloser) Code() diagnostic.Code { return CODE_MISSING_CLOSER }
+
+type ErrIllegalToken struct {
+	reason string
+}
+
+func (e ErrIllegalToken) Error() string {
+	return e.reason
+}
+
+func (e ErrIllegalToken) Code() diagnostic.Code { return CODE_ILLEGAL_TOKEN }
+
+type ErrDuplicateBinding struct {
+	name string
+}
+
+func (e ErrDuplicateBinding) Error() string {
+	return fmt.Sprintf("name '%s' is bound more than once in the pattern", e.name)
+}
+
+func (e ErrDuplicateBinding) Code() diagnostic.Code { return CODE_DUPLICATE_BINDING }
+
+// missingDelimiter returns the punctuation whose absence caused the error, if
+// inserting it is a plausible fix.
+func missingDelimiter(e error) (string, bool) {
+	switch e := e.(type) {
+	case ErrMissingOpener:
+		return e.expected, true
+	case ErrMissingCloser:
+		return e.expected, true
+	case ErrNextTokenInvalid:
+		if e.expected == token.SEMICOLON || e.expected == token.COLON {
+			return string(e.expected), true
+		}
+	}
+
+	return "", false
 }
@@ -1,9 +1,11 @@
 package parser
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/donovandicks/gomonkey/internal/ast"
+	"github.com/donovandicks/gomonkey/internal/diagnostic"
 	"github.com/donovandicks/gomonkey/internal/lexer"
 	"github.com/donovandicks/gomonkey/internal/token"
 )
@@ -19,7 +21,12 @@ type Parser struct {
 	l         *lexer.Lexer
 	currToken token.Token
 	nextToken token.Token
-	errors    []string
+	errors    []diagnostic.Diagnostic
+
+	// panicking is set when an error is reported and cleared once the parser
+	// has skipped to the start of the next statement. Errors reported in
+	// between are consequences of the first one and are dropped.
+	panicking bool
 
 	prefixParseFns PrefixParseFnMap
 	infixParseFns  InfixParseFnMap
@@ -34,17 +41,23 @@ func NewParser(l *lexer.Lexer) *Parser {
 
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INST, p.parseIdentifier)
+	p.registerPrefix(token.SUPER, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
+	p.registerPrefix(token.TILDE, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
+	p.registerPrefix(token.NULL, p.parseNull)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
 	p.registerPrefix(token.LBRACK, p.parseListLiteral)
 	p.registerPrefix(token.LBRACE, p.parseMapLiteral)
+	p.registerPrefix(token.ILLEGAL, p.parseIllegal)
 
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
@@ -54,9 +67,28 @@ func NewParser(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.NE, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LE, p.parseInfixExpression)
+	p.registerInfix(token.GE, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
+	p.registerInfix(token.POW, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	p.registerInfix(token.OR, p.parseInfixExpression)
+	p.registerInfix(token.NULLISH, p.parseInfixExpression)
+	p.registerInfix(token.AMPERSAND, p.parseInfixExpression)
+	p.registerInfix(token.PIPE, p.parseInfixExpression)
+	p.registerInfix(token.CARET, p.parseInfixExpression)
+	p.registerInfix(token.SHL, p.parseInfixExpression)
+	p.registerInfix(token.SHR, p.parseInfixExpression)
+	p.registerInfix(token.INSTANCEOF, p.parseInfixExpression)
 	p.registerInfix(token.ASSIGN, p.parseInfixExpression)
+	p.registerInfix(token.PLUS_ASSIGN, p.parseInfixExpression)
+	p.registerInfix(token.MINUS_ASSIGN, p.parseInfixExpression)
+	p.registerInfix(token.STAR_ASSIGN, p.parseInfixExpression)
+	p.registerInfix(token.FSLASH_ASSIGN, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT_ASSIGN, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.DOT, p.parseCallExpression)
+	p.registerInfix(token.QUESTION_DOT, p.parseCallExpression)
 	p.registerInfix(token.LBRACK, p.parseIndexExpression)
 
 	p.readToken()
@@ -73,17 +105,94 @@ func (p *Parser) registerInfix(t token.TokenType, fn infixParseFn) {
 	p.infixParseFns[t] = fn
 }
 
-func (p *Parser) Errors() []string {
+func (p *Parser) Errors() []diagnostic.Diagnostic {
 	return p.errors
 }
 
-func (p *Parser) addError(e error) {
-	p.errors = append(p.errors, e.Error())
+// addError records a parse error at the offending token.
+func (p *Parser) addError(tok token.Token, e error) {
+	if p.panicking {
+		return
+	}
+
+	p.panicking = true
+
+	diag := diagnostic.Diagnostic{
+		Severity: diagnostic.SEVERITY_ERROR,
+		Range:    diagnostic.Range{Start: tok.Pos, End: tok.End},
+		Message:  e.Error(),
+	}
+
+	if coded, ok := e.(interface{ Code() diagnostic.Code }); ok {
+		diag.Code = coded.Code()
+	}
+
+	if text, ok := missingDelimiter(e); ok {
+		// the delimiter belongs right after the last token that was read
+		at := diagnostic.Range{Start: p.currToken.End, End: p.currToken.End}
+		diag.Fixes = append(diag.Fixes, diagnostic.Fix{
+			Message:     fmt.Sprintf("insert '%s'", text),
+			Range:       at,
+			Replacement: text,
+		})
+	}
+
+	p.errors = append(p.errors, diag)
+}
+
+// startsStatement reports whether a token of the type can only begin a new
+// statement.
+func startsStatement(t token.TokenType) bool {
+	switch t {
+	case token.LET, token.RETURN, token.WHILE, token.FOR, token.IF,
+		token.BREAK, token.CONTINUE, token.CLASS, token.FUNCTION,
+		token.THROW, token.TRY, token.IMPORT, token.EXPORT:
+		return true
+	default:
+		return false
+	}
+}
+
+// synchronize recovers from a parse error by discarding tokens up to the end
+// of the failed statement: a ';', or the token before a '}' or a keyword that
+// starts the next statement. Braced blocks are skipped as a whole.
+func (p *Parser) synchronize() {
+	p.panicking = false
+
+	depth := 0
+	for p.currToken.Type != token.EOF {
+		switch p.currToken.Type {
+		case token.LBRACE:
+			depth += 1
+		case token.RBRACE:
+			if depth > 0 {
+				depth -= 1
+			}
+		case token.SEMICOLON:
+			if depth == 0 {
+				return
+			}
+		}
+
+		if depth == 0 {
+			next := p.nextToken.Type
+			if next == token.RBRACE || next == token.EOF || startsStatement(next) {
+				return
+			}
+		}
+
+		p.readToken()
+	}
 }
 
 func (p *Parser) readToken() {
 	p.currToken = p.nextToken
 	p.nextToken = p.l.NextToken()
+
+	// comments are trivia and never take part in the grammar
+	for p.nextToken.Type == token.COMMENT {
+		p.nextToken = p.l.NextToken()
+	}
 }
 
 func (p *Parser) peekPrecedence() OperatorPrecedence {
@@ -106,14 +215,31 @@ func (p *Parser) expectNext(t token.TokenType) bool {
 	return p.nextToken.Type == t
 }
 
-func (p *Parser) parseIdentifier() ast.Expression { return ast.NewIdentifier(p.currToken.Literal) }
+func (p *Parser) parseIdentifier() ast.Expression {
+	ident := ast.NewIdentifier(p.currToken.Literal)
+	ident.Token.Pos, ident.Token.End = p.currToken.Pos, p.currToken.End
+	return ident
+}
 
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.currToken}
 
 	val, err := strconv.ParseInt(p.currToken.Literal, 0, 64)
 	if err != nil {
-		p.addError(ErrParseError{actual: p.currToken.Literal, expected: "integer"})
+		p.addError(p.currToken, ErrParseError{actual: p.currToken.Literal, expected: "integer"})
+		return nil
+	}
+
+	lit.Value = val
+	return lit
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.currToken}
+
+	val, err := strconv.ParseFloat(p.currToken.Literal, 64)
+	if err != nil {
+		p.addError(p.currToken, ErrParseError{actual: p.currToken.Literal, expected: "float"})
 		return nil
 	}
 
@@ -121,6 +247,12 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	return lit
 }
 
+// parseIllegal reports the problem the lexer found with the current token.
+func (p *Parser) parseIllegal() ast.Expression {
+	p.addError(p.currToken, ErrIllegalToken{reason: p.currToken.Literal})
+	return nil
+}
+
 func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.currToken, Value: p.currToken.Literal}
 }
@@ -144,6 +276,7 @@ func (p *Parser) parseListElements(end token.TokenType) []ast.Expression {
 	}
 
 	if !p.expectNext(end) {
+		p.addError(p.nextToken, ErrMissingCloser{expected: string(end), actual: p.nextToken.Type})
 		return nil
 	}
 
@@ -154,6 +287,7 @@ func (p *Parser) parseListElements(end token.TokenType) []ast.Expression {
 func (p *Parser) parseListLiteral() ast.Expression {
 	lit := &ast.ListLiteral{Token: p.currToken}
 	lit.Elems = p.parseListElements(token.RBRACK)
+	lit.Rbrack = p.currToken.Pos
 	return lit
 }
 
@@ -166,7 +300,7 @@ func (p *Parser) parseMapLiteral() ast.Expression {
 
 		key := p.parseExpression(LOWEST)
 		if !p.expectNext(token.COLON) {
-			p.addError(ErrNextTokenInvalid{expected: token.COMMA, actual: p.nextToken.Type})
+			p.addError(p.nextToken, ErrNextTokenInvalid{expected: token.COLON, actual: p.nextToken.Type})
 			return nil
 		}
 
@@ -176,7 +310,7 @@ func (p *Parser) parseMapLiteral() ast.Expression {
 
 		m.Entries[key] = val
 		if !p.expectNext(token.RBRACE) && !p.expectNext(token.COMMA) {
-			p.addError(ErrNextTokenInvalid{expected: token.RBRACE, actual: p.nextToken.Type})
+			p.addError(p.nextToken, ErrNextTokenInvalid{expected: token.RBRACE, actual: p.nextToken.Type})
 			return nil
 		}
 
@@ -190,6 +324,7 @@ func (p *Parser) parseMapLiteral() ast.Expression {
 	}
 
 	p.readToken()
+	m.Rbrace = p.currToken.Pos
 	return m
 }
 
@@ -197,6 +332,10 @@ func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{Token: p.currToken, Value: p.currToken.Type == token.TRUE}
 }
 
+func (p *Parser) parseNull() ast.Expression {
+	return &ast.NullLiteral{Token: p.currToken}
+}
+
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	expr := &ast.PrefixExpression{
 		Token:    p.currToken,
@@ -221,10 +360,17 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	operator := p.currToken
 	prec := p.currPrecedence()
 
+	isAssignment := prec == ASSIGN
+	if operator.Type == token.POW || isAssignment {
+		// exponentiation and assignment are right associative: 2 ** 3 ** 2 is
+		// 2 ** (3 ** 2), and a = b = c is a = (b = c)
+		prec -= 1
+	}
+
 	p.readToken()
 	right := p.parseExpression(prec)
 
-	if operator.Type == token.ASSIGN {
+	if isAssignment {
 		return p.parseAssignmentExpression(operator, left, right)
 	}
 
@@ -241,14 +387,13 @@ func (p *Parser) parseGroupedExpression() ast.Expression {
 
 	expr := p.parseExpression(LOWEST)
 
-	p.readToken() // advance to the next token after the expression
-
-	if p.currToken.Type != token.RPAREN {
+	if !p.expectNext(token.RPAREN) {
 		// expression was parsed but group did not close
-		p.addError(ErrMissingCloser{expected: ")"})
+		p.addError(p.nextToken, ErrMissingCloser{expected: ")", actual: p.nextToken.Type})
 		return nil
 	}
 
+	p.readToken() // advance to the ')'
 	return expr
 }
 
@@ -256,7 +401,7 @@ func (p *Parser) parseWhileStatement() ast.Statement {
 	stmt := &ast.WhileStatement{Token: p.currToken}
 
 	if !p.expectNext(token.LPAREN) {
-		p.addError(ErrMissingOpener{expected: "("})
+		p.addError(p.nextToken, ErrMissingOpener{expected: "(", actual: p.nextToken.Type})
 		return nil
 	}
 
@@ -266,14 +411,110 @@ func (p *Parser) parseWhileStatement() ast.Statement {
 	stmt.Condition = p.parseExpression(LOWEST)
 
 	if !p.expectNext(token.RPAREN) {
-		p.addError(ErrMissingCloser{expected: ")"})
+		p.addError(p.nextToken, ErrMissingCloser{expected: ")", actual: p.nextToken.Type})
 		return nil
 	}
 
 	p.readToken() // advance to closing paren
 
 	if !p.expectNext(token.LBRACE) {
-		p.addError(ErrMissingOpener{expected: "{"})
+		p.addError(p.nextToken, ErrMissingOpener{expected: "{", actual: p.nextToken.Type})
+		return nil
+	}
+
+	p.readToken()
+
+	stmt.Block = p.parseBlockStatement()
+
+	return stmt
+}
+
+// parseForStatement parses both forms of the for loop.
+//
+// A C-style loop has the form `for (<init>; <condition>; <post>) { ... }`, where
+// each of the clauses may be omitted. An iterating loop has the form
+// `for (<ident> in <expression>) { ... }`.
+func (p *Parser) parseForStatement() ast.Statement {
+	tok := p.currToken
+
+	if !p.expectNext(token.LPAREN) {
+		p.addError(p.nextToken, ErrMissingOpener{expected: "(", actual: p.nextToken.Type})
+		return nil
+	}
+
+	p.readToken() // advance to '('
+	p.readToken() // advance to the first token of the loop header
+
+	if p.currToken.Type == token.IDENT && p.expectNext(token.IN) {
+		return p.parseForInStatement(tok)
+	}
+
+	stmt := &ast.ForStatement{Token: tok}
+
+	if p.currToken.Type != token.SEMICOLON {
+		// the init statement consumes its own trailing ';'
+		stmt.Init = p.parseStatement()
+		if p.currToken.Type != token.SEMICOLON {
+			p.addError(p.nextToken, ErrNextTokenInvalid{expected: token.SEMICOLON, actual: p.nextToken.Type})
+			return nil
+		}
+	}
+
+	if !p.expectNext(token.SEMICOLON) {
+		p.readToken() // advance to the condition
+		stmt.Condition = p.parseExpression(LOWEST)
+
+		if !p.expectNext(token.SEMICOLON) {
+			p.addError(p.nextToken, ErrNextTokenInvalid{expected: token.SEMICOLON, actual: p.nextToken.Type})
+			return nil
+		}
+	}
+
+	p.readToken() // advance to the second ';'
+
+	if !p.expectNext(token.RPAREN) {
+		p.readToken() // advance to the post expression
+		stmt.Post = p.parseExpression(LOWEST)
+
+		if !p.expectNext(token.RPAREN) {
+			p.addError(p.nextToken, ErrMissingCloser{expected: ")", actual: p.nextToken.Type})
+			return nil
+		}
+	}
+
+	p.readToken() // advance to the closing paren
+
+	if !p.expectNext(token.LBRACE) {
+		p.addError(p.nextToken, ErrMissingOpener{expected: "{", actual: p.nextToken.Type})
+		return nil
+	}
+
+	p.readToken()
+
+	stmt.Block = p.parseBlockStatement()
+
+	return stmt
+}
+
+func (p *Parser) parseForInStatement(tok token.Token) ast.Statement {
+	stmt := &ast.ForInStatement{Token: tok}
+
+	stmt.Var = &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
+
+	p.readToken() // advance to 'in'
+	p.readToken() // advance to the iterable
+
+	stmt.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectNext(token.RPAREN) {
+		p.addError(p.nextToken, ErrMissingCloser{expected: ")", actual: p.nextToken.Type})
+		return nil
+	}
+
+	p.readToken() // advance to the closing paren
+
+	if !p.expectNext(token.LBRACE) {
+		p.addError(p.nextToken, ErrMissingOpener{expected: "{", actual: p.nextToken.Type})
 		return nil
 	}
 
@@ -288,7 +529,7 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	expr := &ast.IfExpression{Token: p.currToken}
 
 	if !p.expectNext(token.LPAREN) {
-		p.addError(ErrMissingOpener{expected: "("})
+		p.addError(p.nextToken, ErrMissingOpener{expected: "(", actual: p.nextToken.Type})
 		return nil
 	}
 
@@ -298,14 +539,14 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	expr.Condition = p.parseExpression(LOWEST)
 
 	if !p.expectNext(token.RPAREN) {
-		p.addError(ErrMissingCloser{expected: ")"})
+		p.addError(p.nextToken, ErrMissingCloser{expected: ")", actual: p.nextToken.Type})
 		return nil
 	}
 
 	p.readToken() // advance to the ')'
 
 	if !p.expectNext(token.LBRACE) {
-		p.addError(ErrMissingOpener{expected: "{"})
+		p.addError(p.nextToken, ErrMissingOpener{expected: "{", actual: p.nextToken.Type})
 		return nil
 	}
 
@@ -316,8 +557,19 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	if p.expectNext(token.ELSE) {
 		p.readToken() // advance to the 'else'
 
+		if p.expectNext(token.IF) {
+			p.readToken() // advance to the 'if'
+
+			expr.Alternative = p.parseElseIf()
+			if expr.Alternative == nil {
+				return nil
+			}
+
+			return expr
+		}
+
 		if !p.expectNext(token.LBRACE) {
-			p.addError(ErrMissingOpener{expected: "{"})
+			p.addError(p.nextToken, ErrMissingOpener{expected: "{", actual: p.nextToken.Type})
 			return nil
 		}
 
@@ -329,56 +581,363 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return expr
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
+// parseElseIf parses the if expression following an `else` as the block of the
+// else branch, holding nothing but that if expression.
+func (p *Parser) parseElseIf() *ast.BlockStatement {
+	tok := p.currToken
+
+	next, ok := p.parseIfExpression().(*ast.IfExpression)
+	if !ok {
+		return nil
+	}
+
+	last := next.Alternative
+	if last == nil {
+		last = next.Consequence
+	}
+
+	return &ast.BlockStatement{
+		Token:      tok,
+		Statements: []ast.Statement{&ast.ExpressionStatement{Token: tok, Expression: next}},
+		Lbrace:     tok.Pos,
+		Rbrace:     last.Rbrace,
+	}
+}
+
+func (p *Parser) parseMatchExpression() ast.Expression {
+	expr := &ast.MatchExpression{Token: p.currToken}
+
+	if !p.expectNext(token.LPAREN) {
+		p.addError(p.nextToken, ErrMissingOpener{expected: "(", actual: p.nextToken.Type})
+		return nil
+	}
+
+	p.readToken() // advance to the '('
+	p.readToken() // advance to the expression after '('
+
+	expr.Subject = p.parseExpression(LOWEST)
+
+	if !p.expectNext(token.RPAREN) {
+		p.addError(p.nextToken, ErrMissingCloser{expected: ")", actual: p.nextToken.Type})
+		return nil
+	}
+
+	p.readToken() // advance to the ')'
+
+	if !p.expectNext(token.LBRACE) {
+		p.addError(p.nextToken, ErrMissingOpener{expected: "{", actual: p.nextToken.Type})
+		return nil
+	}
+
+	p.readToken() // advance to the '{'
+
+	for !p.expectNext(token.RBRACE) {
+		p.readToken() // advance to the pattern
+
+		arm := p.parseMatchArm()
+		if arm == nil {
+			return nil
+		}
+
+		expr.Arms = append(expr.Arms, arm)
+
+		// arms are separated by commas, which are optional after a block
+		if p.expectNext(token.COMMA) {
+			p.readToken()
+		} else if _, ok := arm.Body.(*ast.BlockStatement); !ok && !p.expectNext(token.RBRACE) {
+			p.addError(p.nextToken, ErrMissingCloser{expected: "}", actual: p.nextToken.Type})
+			return nil
+		}
+	}
+
+	p.readToken() // advance to the '}'
+	expr.Rbrace = p.currToken.Pos
+	return expr
+}
+
+// parseMatchArm parses `pattern if guard => body`, where the guard is
+// optional. A body starting with '{' is a block rather than a map literal.
+func (p *Parser) parseMatchArm() *ast.MatchArm {
+	arm := &ast.MatchArm{Pattern: p.parsePattern()}
+	if arm.Pattern == nil || !p.checkBindings(arm.Pattern) {
+		return nil
+	}
+
+	if p.expectNext(token.IF) {
+		p.readToken() // advance to the 'if'
+		p.readToken() // advance to the guard
+
+		arm.Guard = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectNext(token.ARROW) {
+		p.addError(p.nextToken, ErrNextTokenInvalid{expected: token.ARROW, actual: p.nextToken.Type})
+		return nil
+	}
+
+	p.readToken() // advance to the '=>'
+	p.readToken() // advance to the body
+
+	if p.currToken.Type == token.LBRACE {
+		arm.Body = p.parseBlockStatement()
+	} else {
+		arm.Body = &ast.ExpressionStatement{Token: p.currToken, Expression: p.parseExpression(LOWEST)}
+	}
+
+	return arm
+}
+
+// parsePattern parses the pattern starting at the current token.
+func (p *Parser) parsePattern() ast.Pattern {
+	switch p.currToken.Type {
+	case token.INT, token.FLOAT, token.STRING, token.TRUE, token.FALSE, token.NULL:
+		return &ast.LiteralPattern{Value: p.prefixParseFns[p.currToken.Type]()}
+	case token.MINUS:
+		if !p.expectNext(token.INT) && !p.expectNext(token.FLOAT) {
+			p.addError(p.nextToken, ErrParseError{expected: "number", actual: p.nextToken.Literal})
+			return nil
+		}
+
+		return &ast.LiteralPattern{Value: p.parsePrefixExpression()}
+	case token.IDENT:
+		name := p.parseIdentifier().(*ast.Identifier)
+		if !p.expectNext(token.LBRACE) {
+			return &ast.BindingPattern{Name: name}
+		}
+
+		p.readToken() // advance to the '{'
+
+		if fields := p.parseMapPattern(); fields != nil {
+			return &ast.ClassPattern{Class: name, Fields: fields}
+		}
+	case token.LBRACK:
+		if pat := p.parseListPattern(); pat != nil {
+			return pat
+		}
+	case token.LBRACE:
+		if pat := p.parseMapPattern(); pat != nil {
+			return pat
+		}
+	default:
+		p.addError(p.currToken, ErrParseError{expected: "pattern", actual: p.currToken.Literal})
+	}
+
+	return nil
+}
+
+func (p *Parser) parseListPattern() *ast.ListPattern {
+	pat := &ast.ListPattern{Token: p.currToken}
+
+	for !p.expectNext(token.RBRACK) {
+		p.readToken() // advance to the element
+
+		if p.currToken.Type == token.ELLIPSIS {
+			if !p.expectNext(token.IDENT) {
+				p.addError(p.nextToken, ErrNextTokenInvalid{expected: token.IDENT, actual: p.nextToken.Type})
+				return nil
+			}
+
+			p.readToken() // advance to the name of the rest
+			pat.Rest = p.parseIdentifier().(*ast.Identifier)
+
+			// the rest is always the last element
+			if !p.expectNext(token.RBRACK) {
+				p.addError(p.nextToken, ErrMissingCloser{expected: "]", actual: p.nextToken.Type})
+				return nil
+			}
+
+			break
+		}
+
+		elem := p.parsePattern()
+		if elem == nil {
+			return nil
+		}
+
+		pat.Elems = append(pat.Elems, elem)
+
+		if !p.expectNext(token.RBRACK) && !p.expectNext(token.COMMA) {
+			p.addError(p.nextToken, ErrMissingCloser{expected: "]", actual: p.nextToken.Type})
+			return nil
+		}
+
+		if p.expectNext(token.COMMA) {
+			p.readToken() // advance to the comma
+		}
+	}
+
+	p.readToken() // advance to the ']'
+	pat.Rbrack = p.currToken.Pos
+	return pat
+}
+
+func (p *Parser) parseMapPattern() *ast.MapPattern {
+	pat := &ast.MapPattern{Token: p.currToken}
+
+	for !p.expectNext(token.RBRACE) {
+		p.readToken() // advance to the key
+
+		var key ast.Expression
+		switch p.currToken.Type {
+		case token.IDENT:
+			// a bare name is both the key and the name it is bound to
+			key = &ast.StringLiteral{Token: p.currToken, Value: p.currToken.Literal}
+			if !p.expectNext(token.COLON) {
+				pat.Keys = append(pat.Keys, key)
+				pat.Values = append(pat.Values, &ast.BindingPattern{Name: p.parseIdentifier().(*ast.Identifier)})
+			}
+		case token.STRING, token.INT, token.TRUE, token.FALSE:
+			key = p.prefixParseFns[p.currToken.Type]()
+			if !p.expectNext(token.COLON) {
+				p.addError(p.nextToken, ErrNextTokenInvalid{expected: token.COLON, actual: p.nextToken.Type})
+				return nil
+			}
+		default:
+			p.addError(p.currToken, ErrParseError{expected: "key", actual: p.currToken.Literal})
+			return nil
+		}
+
+		if p.expectNext(token.COLON) {
+			p.readToken() // advance to the colon
+			p.readToken() // advance to the pattern
+
+			val := p.parsePattern()
+			if val == nil {
+				return nil
+			}
+
+			pat.Keys = append(pat.Keys, key)
+			pat.Values = append(pat.Values, val)
+		}
+
+		if !p.expectNext(token.RBRACE) && !p.expectNext(token.COMMA) {
+			p.addError(p.nextToken, ErrMissingCloser{expected: "}", actual: p.nextToken.Type})
+			return nil
+		}
+
+		if p.expectNext(token.COMMA) {
+			p.readToken() // advance to the comma
+		}
+	}
+
+	p.readToken() // advance to the '}'
+	pat.Rbrace = p.currToken.Pos
+	return pat
+}
+
+// checkBindings reports an error if the pattern binds a name more than once.
+func (p *Parser) checkBindings(pat ast.Pattern) bool {
+	seen := make(map[string]bool)
+	for _, name := range ast.Bindings(pat) {
+		if seen[name.Value] {
+			p.addError(name.Token, ErrDuplicateBinding{name: name.Value})
+			return false
+		}
+
+		seen[name.Value] = true
+	}
+
+	return true
+}
+
+// parseFunctionParameters parses the parameters of a function. A parameter
+// written as a list or map pattern is passed under a hidden name and
+// destructured by the returned let statements, which go at the start of the
+// function body.
+func (p *Parser) parseFunctionParameters() ([]*ast.Identifier, []ast.Statement) {
 	var idents []*ast.Identifier
+	var lets []ast.Statement
 
 	if p.expectNext(token.RPAREN) {
 		p.readToken() // advance to the closing ')'
-		return idents
+		return idents, lets
 	}
 
-	p.readToken()
+	// names bound by patterns must not clash with any other parameter
+	seen := make(map[string]bool)
 
-	ident := &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
-	idents = append(idents, ident)
+	for {
+		if !p.expectNext(token.IDENT) && !p.expectNext(token.LBRACK) && !p.expectNext(token.LBRACE) {
+			p.addError(p.nextToken, ErrNextTokenInvalid{expected: token.IDENT, actual: p.nextToken.Type})
+			return nil, nil
+		}
 
-	for p.expectNext(token.COMMA) {
-		p.readToken() // advance to the ','
-		p.readToken() // advance to the next ident
+		p.readToken() // advance to the ident or pattern
+
+		if p.currToken.Type == token.IDENT {
+			ident := &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
+			seen[ident.Value] = true
+			idents = append(idents, ident)
+		} else {
+			open := p.currToken
+
+			pat := p.parsePattern()
+			if pat == nil {
+				return nil, nil
+			}
+
+			for _, name := range ast.Bindings(pat) {
+				if seen[name.Value] {
+					p.addError(name.Token, ErrDuplicateBinding{name: name.Value})
+					return nil, nil
+				}
+
+				seen[name.Value] = true
+			}
+
+			// a pattern is never a valid name, so the hidden name can only
+			// clash with another parameter written as the same pattern
+			hidden := &ast.Identifier{Token: open, Value: pat.String()}
+			if seen[hidden.Value] {
+				hidden.Value += "#" + strconv.Itoa(len(idents))
+			}
+
+			seen[hidden.Value] = true
+			idents = append(idents, hidden)
+
+			let := token.NewKeyword("let")
+			let.Pos, let.End = open.Pos, open.Pos
+			lets = append(lets, &ast.LetStatement{Token: let, Pattern: pat, Value: hidden})
+		}
 
-		ident := &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
-		idents = append(idents, ident)
+		if !p.expectNext(token.COMMA) {
+			break
+		}
+
+		p.readToken() // advance to the ','
 	}
 
 	if !p.expectNext(token.RPAREN) {
-		p.addError(ErrMissingCloser{expected: ")"})
-		return nil
+		p.addError(p.nextToken, ErrMissingCloser{expected: ")", actual: p.nextToken.Type})
+		return nil, nil
 	}
 
 	p.readToken()
-	return idents
+	return idents, lets
 }
 
 func (p *Parser) parseFunctionLiteral() ast.Expression {
 	fn := &ast.FunctionLiteral{Token: p.currToken}
 
 	if !p.expectNext(token.LPAREN) {
-		p.addError(ErrMissingOpener{expected: "("})
+		p.addError(p.nextToken, ErrMissingOpener{expected: "(", actual: p.nextToken.Type})
 		return nil
 	}
 
 	p.readToken() // advance to the '('
 
-	fn.Parameters = p.parseFunctionParameters()
+	params, lets := p.parseFunctionParameters()
+	fn.Parameters = params
 
 	// Currently on the ')' if one was present
 	if !p.expectNext(token.LBRACE) {
-		p.addError(ErrMissingOpener{expected: "{"})
+		p.addError(p.nextToken, ErrMissingOpener{expected: "{", actual: p.nextToken.Type})
 		return nil
 	}
 
 	p.readToken() // advance to the '{'
 	fn.Body = p.parseBlockStatement()
+	fn.Body.Statements = append(lets, fn.Body.Statements...)
 
 	return fn
 }
@@ -389,10 +948,25 @@ func (p *Parser) parseCallExpression(callable ast.Expression) ast.Expression {
 	case token.LPAREN:
 		expr := &ast.CallExpression{Token: curr, Function: callable}
 		expr.Arguments = p.parseListElements(token.RPAREN)
+		expr.Rparen = p.currToken.Pos
 		return expr
+	case token.QUESTION_DOT:
+		if p.expectNext(token.LBRACK) {
+			p.readToken()
+
+			expr, ok := p.parseIndexExpression(callable).(*ast.IndexExpression)
+			if !ok {
+				return nil
+			}
+
+			expr.Token = curr
+			return expr
+		}
+
+		fallthrough
 	case token.DOT:
 		if !p.expectNext(token.IDENT) {
-			p.addError(ErrNextTokenInvalid{expected: token.IDENT})
+			p.addError(p.nextToken, ErrNextTokenInvalid{expected: token.IDENT, actual: p.nextToken.Type})
 			return nil
 		}
 
@@ -406,7 +980,7 @@ func (p *Parser) parseCallExpression(callable ast.Expression) ast.Expression {
 			Right: property,
 		}
 	default:
-		p.addError(ErrParseError{expected: "callable", actual: callable.TokenLiteral()})
+		p.addError(p.currToken, ErrParseError{expected: "callable", actual: callable.TokenLiteral()})
 		return nil
 	}
 }
@@ -419,22 +993,24 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	expr.Index = p.parseExpression(LOWEST)
 
 	if !p.expectNext(token.RBRACK) {
+		p.addError(p.nextToken, ErrMissingCloser{expected: "]", actual: p.nextToken.Type})
 		return nil
 	}
 
 	p.readToken()
+	expr.Rbrack = p.currToken.Pos
 	return expr
 }
 
 func (p *Parser) parseExpression(precedence OperatorPrecedence) ast.Expression {
 	prefix := p.prefixParseFns[p.currToken.Type]
 	if prefix == nil {
-		p.addError(ErrNoPrefixParser{operator: p.currToken.Literal})
+		p.addError(p.currToken, ErrNoPrefixParser{actual: p.currToken.Type})
 		return nil
 	}
 
 	leftExp := prefix()
-	for !p.expectNext(token.SEMICOLON) && precedence < p.peekPrecedence() {
+	for !p.panicking && !p.expectNext(token.SEMICOLON) && precedence < p.peekPrecedence() {
 		infix := p.infixParseFns[p.nextToken.Type]
 		if infix == nil {
 			return leftExp
@@ -451,17 +1027,28 @@ func (p *Parser) parseExpression(precedence OperatorPrecedence) ast.Expression {
 func (p *Parser) parseLetStatement() ast.Statement {
 	stmt := &ast.LetStatement{Token: p.currToken}
 
-	if !p.expectNext(token.IDENT) {
-		p.addError(ErrNextTokenInvalid{expected: token.IDENT, actual: p.nextToken.Type})
+	if !p.expectNext(token.IDENT) && !p.expectNext(token.LBRACK) && !p.expectNext(token.LBRACE) {
+		p.addError(p.nextToken, ErrNextTokenInvalid{expected: token.IDENT, actual: p.nextToken.Type})
 		return nil
 	}
 
 	p.readToken()
 
-	stmt.Name = &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
+	switch pat := p.parsePattern().(type) {
+	case nil:
+		return nil
+	case *ast.BindingPattern:
+		stmt.Name = pat.Name
+	default:
+		if !p.checkBindings(pat) {
+			return nil
+		}
+
+		stmt.Pattern = pat
+	}
 
 	if !p.expectNext(token.ASSIGN) {
-		p.addError(ErrNextTokenInvalid{expected: token.ASSIGN, actual: p.nextToken.Type})
+		p.addError(p.nextToken, ErrNextTokenInvalid{expected: token.ASSIGN, actual: p.nextToken.Type})
 		return nil
 	}
 
@@ -484,7 +1071,109 @@ func (p *Parser) parseReturnStatement() ast.Statement {
 
 	stmt.Value = p.parseExpression(LOWEST)
 
-	for p.currToken.Type != token.SEMICOLON {
+	if p.expectNext(token.SEMICOLON) {
+		p.readToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseThrowStatement() ast.Statement {
+	stmt := &ast.ThrowStatement{Token: p.currToken}
+
+	p.readToken()
+
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.expectNext(token.SEMICOLON) {
+		p.readToken()
+	}
+
+	return stmt
+}
+
+// parseTryStatement parses a statement of the form
+// `try { ... } catch (<ident>) { ... } finally { ... }`, where either the
+// catch or the finally clause may be omitted.
+func (p *Parser) parseTryStatement() ast.Statement {
+	stmt := &ast.TryStatement{Token: p.currToken}
+
+	if !p.expectNext(token.LBRACE) {
+		p.addError(p.nextToken, ErrMissingOpener{expected: "{", actual: p.nextToken.Type})
+		return nil
+	}
+
+	p.readToken() // advance to the '{'
+	stmt.Block = p.parseBlockStatement()
+
+	if !p.expectNext(token.CATCH) && !p.expectNext(token.FINALLY) {
+		p.addError(p.nextToken, ErrNextTokenInvalid{expected: token.CATCH, actual: p.nextToken.Type})
+		return nil
+	}
+
+	if p.expectNext(token.CATCH) {
+		p.readToken() // advance to the 'catch'
+
+		if !p.expectNext(token.LPAREN) {
+			p.addError(p.nextToken, ErrMissingOpener{expected: "(", actual: p.nextToken.Type})
+			return nil
+		}
+
+		p.readToken() // advance to the '('
+
+		if !p.expectNext(token.IDENT) {
+			p.addError(p.nextToken, ErrNextTokenInvalid{expected: token.IDENT, actual: p.nextToken.Type})
+			return nil
+		}
+
+		p.readToken() // advance to the ident
+		stmt.Param = &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
+
+		if !p.expectNext(token.RPAREN) {
+			p.addError(p.nextToken, ErrMissingCloser{expected: ")", actual: p.nextToken.Type})
+			return nil
+		}
+
+		p.readToken() // advance to the ')'
+
+		if !p.expectNext(token.LBRACE) {
+			p.addError(p.nextToken, ErrMissingOpener{expected: "{", actual: p.nextToken.Type})
+			return nil
+		}
+
+		p.readToken() // advance to the '{'
+		stmt.Catch = p.parseBlockStatement()
+	}
+
+	if p.expectNext(token.FINALLY) {
+		p.readToken() // advance to the 'finally'
+
+		if !p.expectNext(token.LBRACE) {
+			p.addError(p.nextToken, ErrMissingOpener{expected: "{", actual: p.nextToken.Type})
+			return nil
+		}
+
+		p.readToken() // advance to the '{'
+		stmt.Finally = p.parseBlockStatement()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseBreakStatement() ast.Statement {
+	stmt := &ast.BreakStatement{Token: p.currToken}
+
+	if p.expectNext(token.SEMICOLON) {
+		p.readToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseContinueStatement() ast.Statement {
+	stmt := &ast.ContinueStatement{Token: p.currToken}
+
+	if p.expectNext(token.SEMICOLON) {
 		p.readToken()
 	}
 
@@ -504,28 +1193,38 @@ func (p *Parser) parseExpressionStatement() ast.Statement {
 }
 
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
+	lbrace := p.currToken.Pos
+
 	p.readToken() // advance past the opening '{'
 
 	block := ast.NewBlock(p.currToken)
+	block.Lbrace = lbrace
 
 	for p.currToken.Type != token.RBRACE && p.currToken.Type != token.EOF {
 		stmt := p.parseStatement()
-		if stmt != nil {
+		if p.panicking {
+			p.synchronize()
+		} else if stmt != nil {
 			block.Statements = append(block.Statements, stmt)
 		}
 
 		p.readToken()
 	}
 
+	block.Rbrace = p.currToken.Pos
 	return block
 }
 
 func (p *Parser) parseFunctionStatement() ast.Statement {
 	fn := &ast.FunctionStatement{Token: token.NewKeyword("fn")}
+	if p.currToken.Type == token.FUNCTION {
+		// methods declared in a class body do not start with 'fn'
+		fn.Token = p.currToken
+	}
 
 	// expect the name of the function
 	if !p.expectNext(token.IDENT) {
-		p.addError(ErrNextTokenInvalid{expected: token.IDENT, actual: p.nextToken.Type})
+		p.addError(p.nextToken, ErrNextTokenInvalid{expected: token.IDENT, actual: p.nextToken.Type})
 		return nil
 	}
 
@@ -533,7 +1232,7 @@ func (p *Parser) parseFunctionStatement() ast.Statement {
 
 	name, ok := p.parseIdentifier().(*ast.Identifier)
 	if !ok {
-		p.addError(ErrParseError{expected: token.IDENT, actual: p.currToken.Literal})
+		p.addError(p.currToken, ErrParseError{expected: token.IDENT, actual: p.currToken.Literal})
 		return nil
 	}
 
@@ -541,24 +1240,26 @@ func (p *Parser) parseFunctionStatement() ast.Statement {
 
 	p.readToken() // advance to the '(' around the parameters
 
-	fn.Parameters = p.parseFunctionParameters()
+	params, lets := p.parseFunctionParameters()
+	fn.Parameters = params
 
 	// expect to begin the function body
 	if !p.expectNext(token.LBRACE) {
-		p.addError(ErrMissingOpener{expected: "{"})
+		p.addError(p.nextToken, ErrMissingOpener{expected: "{", actual: p.nextToken.Type})
 		return nil
 	}
 
 	p.readToken() // advance to the opening brace
 
 	fn.Body = p.parseBlockStatement()
+	fn.Body.Statements = append(lets, fn.Body.Statements...)
 	return fn
 }
 
 func (p *Parser) parseClassStatement() ast.Statement {
 	cs := &ast.ClassStatement{Token: p.currToken}
 	if !p.expectNext(token.IDENT) {
-		p.addError(ErrNextTokenInvalid{expected: token.IDENT, actual: p.nextToken.Type})
+		p.addError(p.nextToken, ErrNextTokenInvalid{expected: token.IDENT, actual: p.nextToken.Type})
 		return nil
 	}
 
@@ -566,14 +1267,26 @@ func (p *Parser) parseClassStatement() ast.Statement {
 
 	name, ok := p.parseIdentifier().(*ast.Identifier)
 	if !ok {
-		p.addError(ErrParseError{expected: token.IDENT, actual: string(name.Token.Type)})
+		p.addError(p.currToken, ErrParseError{expected: token.IDENT, actual: string(name.Token.Type)})
 		return nil
 	}
 
 	cs.Name = name
 
+	if p.expectNext(token.EXTENDS) {
+		p.readToken() // advance to the 'extends'
+
+		if !p.expectNext(token.IDENT) {
+			p.addError(p.nextToken, ErrNextTokenInvalid{expected: token.IDENT, actual: p.nextToken.Type})
+			return nil
+		}
+
+		p.readToken() // advance to the parent class name
+		cs.Parent = &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
+	}
+
 	if !p.expectNext(token.LBRACE) {
-		p.addError(ErrMissingOpener{expected: "{"})
+		p.addError(p.nextToken, ErrMissingOpener{expected: "{", actual: p.nextToken.Type})
 		return nil
 	}
 
@@ -590,15 +1303,127 @@ func (p *Parser) parseClassStatement() ast.Statement {
 	}
 
 	if !p.expectNext(token.RBRACE) {
-		p.addError(ErrMissingCloser{expected: "}"})
+		p.addError(p.nextToken, ErrMissingCloser{expected: "}", actual: p.nextToken.Type})
 		return nil
 	}
 
 	p.readToken() // consume the closing brace
+	cs.Rbrace = p.currToken.Pos
 
 	return cs
 }
 
+// expectContextual reports whether the next token is the identifier used as
+// a keyword in the current context, such as the 'as' of an import.
+func (p *Parser) expectContextual(keyword string) bool {
+	return p.expectNext(token.IDENT) && p.nextToken.Literal == keyword
+}
+
+// parseImportStatement parses a statement of the form
+// `import "<path>" as <ident>` or `import { <ident> [as <ident>], ... } from "<path>"`.
+func (p *Parser) parseImportStatement() ast.Statement {
+	stmt := &ast.ImportStatement{Token: p.currToken}
+
+	if p.expectNext(token.LBRACE) {
+		p.readToken() // advance to the '{'
+
+		for !p.expectNext(token.RBRACE) {
+			if !p.expectNext(token.IDENT) {
+				p.addError(p.nextToken, ErrNextTokenInvalid{expected: token.IDENT, actual: p.nextToken.Type})
+				return nil
+			}
+
+			p.readToken() // advance to the name
+			name := &ast.ImportName{Name: &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}}
+
+			if p.expectContextual("as") {
+				p.readToken() // advance to the 'as'
+
+				if !p.expectNext(token.IDENT) {
+					p.addError(p.nextToken, ErrNextTokenInvalid{expected: token.IDENT, actual: p.nextToken.Type})
+					return nil
+				}
+
+				p.readToken() // advance to the alias
+				name.Alias = &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
+			}
+
+			stmt.Names = append(stmt.Names, name)
+
+			if !p.expectNext(token.COMMA) {
+				break
+			}
+
+			p.readToken() // advance to the ','
+		}
+
+		if !p.expectNext(token.RBRACE) {
+			p.addError(p.nextToken, ErrMissingCloser{expected: "}", actual: p.nextToken.Type})
+			return nil
+		}
+
+		p.readToken() // advance to the '}'
+
+		if !p.expectContextual("from") {
+			p.addError(p.nextToken, ErrNextTokenInvalid{expected: "from", actual: p.nextToken.Type})
+			return nil
+		}
+
+		p.readToken() // advance to the 'from'
+	}
+
+	if !p.expectNext(token.STRING) {
+		p.addError(p.nextToken, ErrNextTokenInvalid{expected: token.STRING, actual: p.nextToken.Type})
+		return nil
+	}
+
+	p.readToken() // advance to the path
+	stmt.Path = &ast.StringLiteral{Token: p.currToken, Value: p.currToken.Literal}
+
+	if stmt.Names == nil {
+		if !p.expectContextual("as") {
+			p.addError(p.nextToken, ErrNextTokenInvalid{expected: "as", actual: p.nextToken.Type})
+			return nil
+		}
+
+		p.readToken() // advance to the 'as'
+
+		if !p.expectNext(token.IDENT) {
+			p.addError(p.nextToken, ErrNextTokenInvalid{expected: token.IDENT, actual: p.nextToken.Type})
+			return nil
+		}
+
+		p.readToken() // advance to the alias
+		stmt.Alias = &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
+	}
+
+	if p.expectNext(token.SEMICOLON) {
+		p.readToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseExportStatement() ast.Statement {
+	stmt := &ast.ExportStatement{Token: p.currToken}
+
+	switch p.nextToken.Type {
+	case token.LET, token.FUNCTION, token.CLASS:
+		p.readToken() // advance to the declaration
+	default:
+		p.addError(p.nextToken, ErrNextTokenInvalid{expected: token.LET, actual: p.nextToken.Type})
+		return nil
+	}
+
+	decl := p.parseStatement()
+	if decl == nil || p.panicking {
+		return nil
+	}
+
+	stmt.Statement = decl
+	return stmt
+}
+
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.currToken.Type {
 	case token.LET:
@@ -607,10 +1432,24 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseReturnStatement()
 	case token.WHILE:
 		return p.parseWhileStatement()
+	case token.FOR:
+		return p.parseForStatement()
+	case token.BREAK:
+		return p.parseBreakStatement()
+	case token.CONTINUE:
+		return p.parseContinueStatement()
 	case token.CLASS:
 		return p.parseClassStatement()
+	case token.THROW:
+		return p.parseThrowStatement()
+	case token.TRY:
+		return p.parseTryStatement()
 	case token.FUNCTION:
 		return p.parseFunctionStatement()
+	case token.IMPORT:
+		return p.parseImportStatement()
+	case token.EXPORT:
+		return p.parseExportStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -621,7 +1460,9 @@ func (p *Parser) ParseProgram() *ast.Program {
 
 	for p.currToken.Type != token.EOF {
 		stmt := p.parseStatement()
-		if stmt != nil {
+		if p.panicking {
+			p.synchronize()
+		} else if stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
 		p.readToken()
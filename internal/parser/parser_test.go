@@ -1,9 +1,11 @@
 package parser_test
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/donovandicks/gomonkey/internal/ast"
+	"github.com/donovandicks/gomonkey/internal/diagnostic"
 	"github.com/donovandicks/gomonkey/internal/lexer"
 	"github.com/donovandicks/gomonkey/internal/parser"
 	"github.com/donovandicks/gomonkey/internal/token"
@@ -11,6 +13,35 @@ import (
 	"golang.org/x/exp/maps"
 )
 
+// clearPositions zeroes every source position reachable from v so that the
+// expected trees in these tests can be written without locations.
+func clearPositions(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			clearPositions(v.Elem())
+		}
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(token.Position{}) {
+			v.Set(reflect.Zero(v.Type()))
+			return
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			clearPositions(v.Field(i))
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			clearPositions(v.Index(i))
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			clearPositions(key)
+			clearPositions(v.MapIndex(key))
+		}
+	}
+}
+
 func TestParser(t *testing.T) {
 	t.Parallel()
 
@@ -46,6 +77,19 @@ func TestParser(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "valid float literal",
+			input: "2.5;",
+			expected: []ast.Statement{
+				&ast.ExpressionStatement{
+					Token: token.NewFloat("2.5"),
+					Expression: &ast.FloatLiteral{
+						Token: token.NewFloat("2.5"),
+						Value: 2.5,
+					},
+				},
+			},
+		},
 		{
 			name:  "prefix bang",
 			input: "!5;",
@@ -240,21 +284,30 @@ func TestParser(t *testing.T) {
 			name:  "invalid let statement: missing assignment operator",
 			input: "let x 5;",
 			expectedErrs: []string{
-				"expected next token to be =, got INT instead",
+				"1:7: expected next token to be =, got INT instead",
 			},
 		},
 		{
 			name:  "invalid let statement: missing identifier",
 			input: "let = 10;",
 			expectedErrs: []string{
-				"expected next token to be IDENT, got = instead",
-				"no prefix parser found for =",
+				"1:5: expected next token to be IDENT, got = instead",
 			},
 		},
 		{
 			name:         "invalid let statement: missing identifier and assigner",
 			input:        "let 10;",
-			expectedErrs: []string{"expected next token to be IDENT, got INT instead"},
+			expectedErrs: []string{"1:5: expected next token to be IDENT, got INT instead"},
+		},
+		{
+			name:         "invalid string: unterminated",
+			input:        `let s = "abc`,
+			expectedErrs: []string{"1:9: unterminated string literal"},
+		},
+		{
+			name:         "invalid string: bad escape",
+			input:        `"\x41";`,
+			expectedErrs: []string{`1:1: invalid escape sequence '\x'`},
 		},
 		{
 			name:  "valid boolean literal",
@@ -608,6 +661,161 @@ func TestParser(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "for statement: all clauses",
+			input: "for (let i = 0; i < 3; i = i + 1) { x; }",
+			expected: []ast.Statement{
+				&ast.ForStatement{
+					Token: token.NewKeyword("for"),
+					Init: &ast.LetStatement{
+						Token: token.NewKeyword("let"),
+						Name:  ast.NewIdentifier("i"),
+						Value: ast.NewIntegerLiteral(0),
+					},
+					Condition: &ast.InfixExpression{
+						Token:    token.TokenLT,
+						Left:     ast.NewIdentifier("i"),
+						Operator: "<",
+						Right:    ast.NewIntegerLiteral(3),
+					},
+					Post: &ast.AssignmentExpression{
+						Token: token.TokenAssign,
+						Left:  ast.NewIdentifier("i"),
+						Right: &ast.InfixExpression{
+							Token:    token.TokenPlus,
+							Left:     ast.NewIdentifier("i"),
+							Operator: "+",
+							Right:    ast.NewIntegerLiteral(1),
+						},
+					},
+					Block: &ast.BlockStatement{
+						Token: token.NewIdent("x"),
+						Statements: []ast.Statement{
+							&ast.ExpressionStatement{
+								Token:      token.NewIdent("x"),
+								Expression: ast.NewIdentifier("x"),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "for statement: empty clauses",
+			input: "for (;;) { x; }",
+			expected: []ast.Statement{
+				&ast.ForStatement{
+					Token: token.NewKeyword("for"),
+					Block: &ast.BlockStatement{
+						Token: token.NewIdent("x"),
+						Statements: []ast.Statement{
+							&ast.ExpressionStatement{
+								Token:      token.NewIdent("x"),
+								Expression: ast.NewIdentifier("x"),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "for statement: expression init",
+			input: "for (i = 0; i < 3;) { x; }",
+			expected: []ast.Statement{
+				&ast.ForStatement{
+					Token: token.NewKeyword("for"),
+					Init: &ast.ExpressionStatement{
+						Token: token.NewIdent("i"),
+						Expression: &ast.AssignmentExpression{
+							Token: token.TokenAssign,
+							Left:  ast.NewIdentifier("i"),
+							Right: ast.NewIntegerLiteral(0),
+						},
+					},
+					Condition: &ast.InfixExpression{
+						Token:    token.TokenLT,
+						Left:     ast.NewIdentifier("i"),
+						Operator: "<",
+						Right:    ast.NewIntegerLiteral(3),
+					},
+					Block: &ast.BlockStatement{
+						Token: token.NewIdent("x"),
+						Statements: []ast.Statement{
+							&ast.ExpressionStatement{
+								Token:      token.NewIdent("x"),
+								Expression: ast.NewIdentifier("x"),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "for in statement: identifier iterable",
+			input: "for (x in xs) { x; }",
+			expected: []ast.Statement{
+				&ast.ForInStatement{
+					Token:    token.NewKeyword("for"),
+					Var:      ast.NewIdentifier("x"),
+					Iterable: ast.NewIdentifier("xs"),
+					Block: &ast.BlockStatement{
+						Token: token.NewIdent("x"),
+						Statements: []ast.Statement{
+							&ast.ExpressionStatement{
+								Token:      token.NewIdent("x"),
+								Expression: ast.NewIdentifier("x"),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "for in statement: list literal iterable",
+			input: "for (x in [1, 2]) { x; }",
+			expected: []ast.Statement{
+				&ast.ForInStatement{
+					Token: token.NewKeyword("for"),
+					Var:   ast.NewIdentifier("x"),
+					Iterable: &ast.ListLiteral{
+						Token: token.TokenLBrack,
+						Elems: []ast.Expression{
+							ast.NewIntegerLiteral(1),
+							ast.NewIntegerLiteral(2),
+						},
+					},
+					Block: &ast.BlockStatement{
+						Token: token.NewIdent("x"),
+						Statements: []ast.Statement{
+							&ast.ExpressionStatement{
+								Token:      token.NewIdent("x"),
+								Expression: ast.NewIdentifier("x"),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "while statement: break and continue",
+			input: "while (true) { continue; break }",
+			expected: []ast.Statement{
+				&ast.WhileStatement{
+					Token: token.NewKeyword("while"),
+					Condition: &ast.Boolean{
+						Token: token.NewKeyword("true"),
+						Value: true,
+					},
+					Block: &ast.BlockStatement{
+						Token: token.NewKeyword("continue"),
+						Statements: []ast.Statement{
+							&ast.ContinueStatement{Token: token.NewKeyword("continue")},
+							&ast.BreakStatement{Token: token.NewKeyword("break")},
+						},
+					},
+				},
+			},
+		},
 		{
 			name:  "assignment expression",
 			input: "let x = 0; x = x + 1;",
@@ -881,6 +1089,191 @@ func TestParser(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "class statement: extends",
+			input: "class Child extends Parent {}",
+			expected: []ast.Statement{
+				&ast.ClassStatement{
+					Token:  token.NewKeyword("class"),
+					Name:   ast.NewIdentifier("Child"),
+					Parent: ast.NewIdentifier("Parent"),
+				},
+			},
+		},
+		{
+			name:  "conditional expression with else if",
+			input: "if (a) { 1 } else if (b) { 2 }",
+			expected: []ast.Statement{
+				&ast.ExpressionStatement{
+					Token: token.NewKeyword("if"),
+					Expression: &ast.IfExpression{
+						Token:     token.NewKeyword("if"),
+						Condition: ast.NewIdentifier("a"),
+						Consequence: &ast.BlockStatement{
+							Token: token.NewInt("1"),
+							Statements: []ast.Statement{
+								&ast.ExpressionStatement{Token: token.NewInt("1"), Expression: ast.NewIntegerLiteral(1)},
+							},
+						},
+						Alternative: &ast.BlockStatement{
+							Token: token.NewKeyword("if"),
+							Statements: []ast.Statement{
+								&ast.ExpressionStatement{
+									Token: token.NewKeyword("if"),
+									Expression: &ast.IfExpression{
+										Token:     token.NewKeyword("if"),
+										Condition: ast.NewIdentifier("b"),
+										Consequence: &ast.BlockStatement{
+											Token: token.NewInt("2"),
+											Statements: []ast.Statement{
+												&ast.ExpressionStatement{Token: token.NewInt("2"), Expression: ast.NewIntegerLiteral(2)},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "match expression",
+			input: "match (x) { [a, ...rest] if a > 0 => a, {name} => { name } Point{x: 0} => 0, -1 => null, _ => x }",
+			expected: []ast.Statement{
+				&ast.ExpressionStatement{
+					Token: token.NewKeyword("match"),
+					Expression: &ast.MatchExpression{
+						Token:   token.NewKeyword("match"),
+						Subject: ast.NewIdentifier("x"),
+						Arms: []*ast.MatchArm{
+							{
+								Pattern: &ast.ListPattern{
+									Token: token.TokenLBrack,
+									Elems: []ast.Pattern{&ast.BindingPattern{Name: ast.NewIdentifier("a")}},
+									Rest:  ast.NewIdentifier("rest"),
+								},
+								Guard: &ast.InfixExpression{
+									Token:    token.TokenGT,
+									Left:     ast.NewIdentifier("a"),
+									Operator: ">",
+									Right:    ast.NewIntegerLiteral(0),
+								},
+								Body: &ast.ExpressionStatement{Token: token.NewIdent("a"), Expression: ast.NewIdentifier("a")},
+							},
+							{
+								Pattern: &ast.MapPattern{
+									Token:  token.TokenLBrace,
+									Keys:   []ast.Expression{&ast.StringLiteral{Token: token.NewIdent("name"), Value: "name"}},
+									Values: []ast.Pattern{&ast.BindingPattern{Name: ast.NewIdentifier("name")}},
+								},
+								Body: &ast.BlockStatement{
+									Token: token.NewIdent("name"),
+									Statements: []ast.Statement{
+										&ast.ExpressionStatement{Token: token.NewIdent("name"), Expression: ast.NewIdentifier("name")},
+									},
+								},
+							},
+							{
+								Pattern: &ast.ClassPattern{
+									Class: ast.NewIdentifier("Point"),
+									Fields: &ast.MapPattern{
+										Token:  token.TokenLBrace,
+										Keys:   []ast.Expression{&ast.StringLiteral{Token: token.NewIdent("x"), Value: "x"}},
+										Values: []ast.Pattern{&ast.LiteralPattern{Value: ast.NewIntegerLiteral(0)}},
+									},
+								},
+								Body: &ast.ExpressionStatement{Token: token.NewInt("0"), Expression: ast.NewIntegerLiteral(0)},
+							},
+							{
+								Pattern: &ast.LiteralPattern{
+									Value: &ast.PrefixExpression{Token: token.TokenMinus, Operator: "-", Right: ast.NewIntegerLiteral(1)},
+								},
+								Body: &ast.ExpressionStatement{
+									Token:      token.NewKeyword("null"),
+									Expression: &ast.NullLiteral{Token: token.NewKeyword("null")},
+								},
+							},
+							{
+								Pattern: &ast.BindingPattern{Name: ast.NewIdentifier("_")},
+								Body:    &ast.ExpressionStatement{Token: token.NewIdent("x"), Expression: ast.NewIdentifier("x")},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "destructuring let statement",
+			input: "let [a, {b}, ...rest] = x;",
+			expected: []ast.Statement{
+				&ast.LetStatement{
+					Token: token.NewKeyword("let"),
+					Pattern: &ast.ListPattern{
+						Token: token.TokenLBrack,
+						Elems: []ast.Pattern{
+							&ast.BindingPattern{Name: ast.NewIdentifier("a")},
+							&ast.MapPattern{
+								Token:  token.TokenLBrace,
+								Keys:   []ast.Expression{&ast.StringLiteral{Token: token.NewIdent("b"), Value: "b"}},
+								Values: []ast.Pattern{&ast.BindingPattern{Name: ast.NewIdentifier("b")}},
+							},
+						},
+						Rest: ast.NewIdentifier("rest"),
+					},
+					Value: ast.NewIdentifier("x"),
+				},
+			},
+		},
+		{
+			name:  "destructured function parameters",
+			input: "let f = fn([a, b], c) { a };",
+			expected: []ast.Statement{
+				&ast.LetStatement{
+					Token: token.NewKeyword("let"),
+					Name:  ast.NewIdentifier("f"),
+					Value: &ast.FunctionLiteral{
+						Token: token.NewKeyword("fn"),
+						Parameters: []*ast.Identifier{
+							{Token: token.TokenLBrack, Value: "[a, b]"},
+							ast.NewIdentifier("c"),
+						},
+						Body: &ast.BlockStatement{
+							Token: token.NewIdent("a"),
+							Statements: []ast.Statement{
+								&ast.LetStatement{
+									Token: token.NewKeyword("let"),
+									Pattern: &ast.ListPattern{
+										Token: token.TokenLBrack,
+										Elems: []ast.Pattern{
+											&ast.BindingPattern{Name: ast.NewIdentifier("a")},
+											&ast.BindingPattern{Name: ast.NewIdentifier("b")},
+										},
+									},
+									Value: &ast.Identifier{Token: token.TokenLBrack, Value: "[a, b]"},
+								},
+								&ast.ExpressionStatement{Token: token.NewIdent("a"), Expression: ast.NewIdentifier("a")},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "instanceof expression",
+			input: "a instanceof B",
+			expected: []ast.Statement{
+				&ast.ExpressionStatement{
+					Token: token.NewIdent("a"),
+					Expression: &ast.InfixExpression{
+						Token:    token.NewKeyword("instanceof"),
+						Left:     ast.NewIdentifier("a"),
+						Operator: "instanceof",
+						Right:    ast.NewIdentifier("B"),
+					},
+				},
+			},
+		},
 		{
 			name: "class statement: one method",
 			input: `
@@ -1011,10 +1404,11 @@ func TestParser(t *testing.T) {
 
 			program := p.ParseProgram()
 			assert.NotNil(t, program)
+			clearPositions(reflect.ValueOf(program))
 			if tc.expectedErrs == nil {
 				assert.Nil(t, p.Errors())
 			} else {
-				assert.Equal(t, tc.expectedErrs, p.Errors())
+				assert.Equal(t, tc.expectedErrs, errorStrings(p.Errors()))
 			}
 
 			if tc.expected != nil {
@@ -1075,6 +1469,7 @@ func TestParser_MapLiteral(t *testing.T) {
 
 			program := p.ParseProgram()
 			assert.NotNil(t, program)
+			clearPositions(reflect.ValueOf(program))
 
 			stmt := program.Statements[0]
 			s, ok := stmt.(*ast.ExpressionStatement)
@@ -1107,6 +1502,71 @@ func TestParser_OperatorPrecedence(t *testing.T) {
 			input:    "!-a",
 			expected: "(!(-a))",
 		},
+		{
+			name:     "logical operators",
+			input:    "a || b && c",
+			expected: "(a || (b && c))",
+		},
+		{
+			name:     "logical operators with comparisons",
+			input:    "a == b && c <= d",
+			expected: "((a == b) && (c <= d))",
+		},
+		{
+			name:     "bitwise operators bind tighter than comparisons",
+			input:    "a & b == c",
+			expected: "((a & b) == c)",
+		},
+		{
+			name:     "bitwise operators",
+			input:    "a | b ^ c & d",
+			expected: "(a | (b ^ (c & d)))",
+		},
+		{
+			name:     "shifts",
+			input:    "a << 1 + 2 >> b",
+			expected: "((a << (1 + 2)) >> b)",
+		},
+		{
+			name:     "modulo",
+			input:    "a * b % c",
+			expected: "((a * b) % c)",
+		},
+		{
+			name:     "exponentiation is right associative",
+			input:    "2 ** 3 ** 2",
+			expected: "(2 ** (3 ** 2))",
+		},
+		{
+			name:     "exponentiation binds tighter than unary",
+			input:    "-2 ** -2",
+			expected: "(-(2 ** (-2)))",
+		},
+		{
+			name:     "compound assignment",
+			input:    "a += b * 2",
+			expected: "(a += (b * 2))",
+		},
+		{
+			name:     "assignment is right associative",
+			input:    "a = b -= c",
+			expected: "(a = (b -= c))",
+		},
+		{
+			name:     "index assignment",
+			input:    `m["k"] %= 2`,
+			expected: "((m[k]) %= 2)",
+		},
+		{
+			name:     "double negation",
+			input:    "1--x",
+			expected: "(1 - (-x))",
+		},
+		{
+			name:     "bitwise not",
+			input:    "~a & b",
+			expected: "((~a) & b)",
+		},
 		{
 			name:     "multiple addition",
 			input:    "a + b + c",
@@ -1177,6 +1637,21 @@ func TestParser_OperatorPrecedence(t *testing.T) {
 			input:    "object.property.method()",
 			expected: "((object.property).method)()",
 		},
+		{
+			name:     "optional access",
+			input:    "config?.server?.[\"port\"] + 1",
+			expected: "(((config?.server)?.[port]) + 1)",
+		},
+		{
+			name:     "null coalescing binds looser than logical or",
+			input:    "a ?? b || c ?? null",
+			expected: "((a ?? (b || c)) ?? null)",
+		},
+		{
+			name:     "null coalescing binds tighter than assignment",
+			input:    "x = a?.b ?? 1",
+			expected: "(x = ((a?.b) ?? 1))",
+		},
 	}
 
 	for _, testCase := range cases {
@@ -1190,8 +1665,310 @@ func TestParser_OperatorPrecedence(t *testing.T) {
 
 			program := p.ParseProgram()
 			assert.NotNil(t, program)
+			clearPositions(reflect.ValueOf(program))
+
+			assert.Equal(t, tc.expected, program.String())
+		})
+	}
+}
+
+func TestParser_Positions(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		input string
+		start string
+		end   string
+	}{
+		{
+			name:  "let statement",
+			input: "let x = 1 + 2;",
+			start: "1:1",
+			end:   "1:14",
+		},
+		{
+			name:  "infix expression starts at its left operand",
+			input: "  a * b",
+			start: "1:3",
+			end:   "1:8",
+		},
+		{
+			name:  "call expression ends after the closing paren",
+			input: "add(1, 2)",
+			start: "1:1",
+			end:   "1:10",
+		},
+		{
+			name:  "index expression",
+			input: "xs[0]",
+			start: "1:1",
+			end:   "1:6",
+		},
+		{
+			name:  "multi-line function statement",
+			input: "fn add(x, y) {\n  x + y;\n}",
+			start: "1:1",
+			end:   "3:2",
+		},
+		{
+			name:  "while statement",
+			input: "while (true) {\n\tx = x + 1;\n}",
+			start: "1:1",
+			end:   "3:2",
+		},
+		{
+			name:  "class statement",
+			input: "class A {\n  f() { 1 }\n}",
+			start: "1:1",
+			end:   "3:2",
+		},
+	}
+
+	for _, testCase := range cases {
+		tc := testCase
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := parser.NewParser(lexer.NewLexer(tc.input))
+			program := p.ParseProgram()
+			assert.Nil(t, p.Errors())
+			assert.Len(t, program.Statements, 1)
+
+			stmt := program.Statements[0]
+			assert.Equal(t, tc.start, stmt.Pos().String())
+			assert.Equal(t, tc.end, stmt.End().String())
+		})
+	}
+}
+
+func TestParser_ErrorRecovery(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name         string
+		input        string
+		expectedErrs []string
+		expected     string
+	}{
+		{
+			name:  "one error per statement",
+			input: "let = 1 + ;\nlet y = 2;\nlet z 3;\nz;",
+			expectedErrs: []string{
+				"1:5: expected next token to be IDENT, got = instead",
+				"3:7: expected next token to be =, got INT instead",
+			},
+			expected: "let y = 2;z",
+		},
+		{
+			name:  "syncs to the next keyword",
+			input: "let x = (1 + 2\nlet y = 3\nreturn y",
+			expectedErrs: []string{
+				"2:1: missing closing ')', got LET instead",
+			},
+			expected: "let y = 3;return y;",
+		},
+		{
+			name:  "errors inside blocks",
+			input: "fn f(a) {\n  let = a;\n  a + ;\n  a\n}\nf(1)",
+			expectedErrs: []string{
+				"2:7: expected next token to be IDENT, got = instead",
+				"3:7: expected an expression, got ; instead",
+			},
+			expected: "fn f(a) af(1)",
+		},
+		{
+			name:  "skips unparsed blocks",
+			input: "if x) { let = 1; }\nlet y = 2;",
+			expectedErrs: []string{
+				"1:4: missing opening '(', got IDENT instead",
+			},
+			expected: "let y = 2;",
+		},
+		{
+			name:  "duplicate bindings in a pattern",
+			input: "match (x) { [a, a] => a }\nlet y = 2;",
+			expectedErrs: []string{
+				"1:17: name 'a' is bound more than once in the pattern",
+				// recovery stops short of the closing brace of the match
+				"1:25: expected an expression, got } instead",
+			},
+			expected: "let y = 2;",
+		},
+		{
+			name:  "duplicate bindings in a let statement",
+			input: "let [a, {a}] = x;\nlet y = 2;",
+			expectedErrs: []string{
+				"1:10: name 'a' is bound more than once in the pattern",
+			},
+			expected: "let y = 2;",
+		},
+		{
+			name:  "duplicate parameters",
+			input: "fn f(a, [b, a]) { a }\nlet y = 2;",
+			expectedErrs: []string{
+				"1:13: name 'a' is bound more than once in the pattern",
+			},
+			expected: "let y = 2;",
+		},
+		{
+			name:  "match arm without an arrow",
+			input: "match (x) { 1 2 }\nlet y = 2;",
+			expectedErrs: []string{
+				"1:15: expected next token to be =>, got INT instead",
+				"1:17: expected an expression, got } instead",
+			},
+			expected: "let y = 2;",
+		},
+		{
+			name:         "return at end of input",
+			input:        "return",
+			expectedErrs: []string{"1:7: expected an expression, got EOF instead"},
+		},
+		{
+			name:         "unclosed list",
+			input:        "[1, 2; 3",
+			expectedErrs: []string{"1:6: missing closing ']', got ; instead"},
+			expected:     "3",
+		},
+		{
+			name:         "invalid parameter",
+			input:        "fn f(a, 1) { a }",
+			expectedErrs: []string{"1:9: expected next token to be IDENT, got INT instead"},
+		},
+		{
+			name:     "try with catch and finally",
+			input:    "try { throw \"boom\"; } catch (e) { e } finally { 1 }",
+			expected: "try { throw boom; } catch (e) { e } finally { 1 }",
+		},
+		{
+			name:     "try with only finally",
+			input:    "try { x } finally { y }\nz",
+			expected: "try { x } finally { y }z",
+		},
+		{
+			name:         "try without handler",
+			input:        "try { x }\nlet y = 1;",
+			expectedErrs: []string{"2:1: expected next token to be CATCH, got LET instead"},
+			expected:     "let y = 1;",
+		},
+		{
+			name:     "import as",
+			input:    "import \"lib/math\" as m\nm",
+			expected: "import \"lib/math\" as m;m",
+		},
+		{
+			name:     "selective import",
+			input:    "import { a, b as c } from \"lib\";",
+			expected: "import { a, b as c } from \"lib\";",
+		},
+		{
+			name:     "export",
+			input:    "export let x = 1; export fn f() { x } export class C {}",
+			expected: "export let x = 1;export fn f() xexport class C {\n\n}",
+		},
+		{
+			name:         "import without alias",
+			input:        "import \"lib\";\nlet y = 1;",
+			expectedErrs: []string{"1:13: expected next token to be as, got ; instead"},
+			expected:     "let y = 1;",
+		},
+		{
+			name:         "selective import without from",
+			input:        "import { a } \"lib\"",
+			expectedErrs: []string{"1:14: expected next token to be from, got STRING instead"},
+		},
+		{
+			name:         "export of an expression",
+			input:        "export 1 + 2",
+			expectedErrs: []string{"1:8: expected next token to be LET, got INT instead"},
+		},
+		{
+			name:         "catch without parameter",
+			input:        "try { x } catch { y }",
+			expectedErrs: []string{"1:17: missing opening '(', got { instead"},
+		},
+	}
+
+	for _, testCase := range cases {
+		tc := testCase
 
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := parser.NewParser(lexer.NewLexer(tc.input))
+			program := p.ParseProgram()
+
+			assert.Equal(t, tc.expectedErrs, errorStrings(p.Errors()))
 			assert.Equal(t, tc.expected, program.String())
 		})
 	}
 }
+
+func TestParser_Diagnostics(t *testing.T) {
+	t.Parallel()
+
+	input := "let x = [1, 2;\nlet y = 2"
+
+	p := parser.NewParser(lexer.NewFileLexer("a.monkey", input))
+	p.ParseProgram()
+
+	pos := func(offset, line, column int) token.Position {
+		return token.Position{File: "a.monkey", Offset: offset, Line: line, Column: column}
+	}
+
+	expected := []diagnostic.Diagnostic{
+		{
+			Severity: diagnostic.SEVERITY_ERROR,
+			Code:     parser.CODE_MISSING_CLOSER,
+			Range:    diagnostic.Range{Start: pos(13, 1, 14), End: pos(14, 1, 15)},
+			Message:  "missing closing ']', got ; instead",
+			Fixes: []diagnostic.Fix{
+				{
+					Message:     "insert ']'",
+					Range:       diagnostic.Range{Start: pos(13, 1, 14), End: pos(13, 1, 14)},
+					Replacement: "]",
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, p.Errors())
+
+	rendered := "a.monkey:1:14: error[missing-closer]: missing closing ']', got ; instead\n" +
+		"1 | let x = [1, 2;\n" +
+		"  |              ^\n" +
+		"help: insert ']'\n"
+	assert.Equal(t, rendered, p.Errors()[0].Render(input))
+}
+
+func TestParser_SkipsComments(t *testing.T) {
+	t.Parallel()
+
+	input := `
+	// compute a sum
+	let x = 1 + /* inline */ 2; // trailing
+	`
+
+	for _, l := range []*lexer.Lexer{lexer.NewLexer(input), lexer.NewLexer(input).WithComments()} {
+		p := parser.NewParser(l)
+		program := p.ParseProgram()
+
+		assert.Nil(t, p.Errors())
+		assert.Equal(t, "let x = (1 + 2);", program.String())
+	}
+}
+
+func errorStrings(diags []diagnostic.Diagnostic) []string {
+	if diags == nil {
+		return nil
+	}
+
+	errs := make([]string, 0, len(diags))
+	for _, diag := range diags {
+		errs = append(errs, diag.Error())
+	}
+
+	return errs
+}
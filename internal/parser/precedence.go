@@ -13,26 +13,53 @@ const (
 	_ OperatorPrecedence = iota
 	LOWEST
 	ASSIGN
+	COALESCE
+	LOGICAL_OR
+	LOGICAL_AND
 	EQUALS
 	LESSGREATER
+	BITWISE_OR
+	BITWISE_XOR
+	BITWISE_AND
+	SHIFT
 	SUM
 	PRODUCT
 	PREFIX
+	POWER
 	CALL
 	INDEX
 )
 
 var Precedence PrecedenceTable = PrecedenceTable{
-	token.EQ:     EQUALS,
-	token.ASSIGN: ASSIGN,
-	token.NE:     EQUALS,
-	token.LT:     LESSGREATER,
-	token.GT:     LESSGREATER,
-	token.PLUS:   SUM,
-	token.MINUS:  SUM,
-	token.FSLASH: PRODUCT,
-	token.STAR:   PRODUCT,
-	token.LPAREN: CALL,
-	token.DOT:    CALL,
-	token.LBRACK: INDEX,
+	token.EQ:             EQUALS,
+	token.ASSIGN:         ASSIGN,
+	token.PLUS_ASSIGN:    ASSIGN,
+	token.MINUS_ASSIGN:   ASSIGN,
+	token.STAR_ASSIGN:    ASSIGN,
+	token.FSLASH_ASSIGN:  ASSIGN,
+	token.PERCENT_ASSIGN: ASSIGN,
+	token.NE:             EQUALS,
+	token.LT:             LESSGREATER,
+	token.GT:             LESSGREATER,
+	token.LE:             LESSGREATER,
+	token.GE:             LESSGREATER,
+	token.INSTANCEOF:     LESSGREATER,
+	token.PLUS:           SUM,
+	token.MINUS:          SUM,
+	token.FSLASH:         PRODUCT,
+	token.STAR:           PRODUCT,
+	token.PERCENT:        PRODUCT,
+	token.POW:            POWER,
+	token.AND:            LOGICAL_AND,
+	token.OR:             LOGICAL_OR,
+	token.NULLISH:        COALESCE,
+	token.PIPE:           BITWISE_OR,
+	token.CARET:          BITWISE_XOR,
+	token.AMPERSAND:      BITWISE_AND,
+	token.SHL:            SHIFT,
+	token.SHR:            SHIFT,
+	token.LPAREN:         CALL,
+	token.DOT:            CALL,
+	token.QUESTION_DOT:   CALL,
+	token.LBRACK:         INDEX,
 }
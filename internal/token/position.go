@@ -0,0 +1,45 @@
+package token
+
+import "fmt"
+
+// Position describes a location in the source text.
+type Position struct {
+	File   string `json:"file,omitempty"` // the file name, if any
+	Offset int    `json:"offset"`         // byte offset, starting at 0
+	Line   int    `json:"line"`           // line number, starting at 1
+	Column int    `json:"column"`         // column number in bytes, starting at 1
+}
+
+// IsValid reports whether the position refers to a real source location.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// Add returns the position n bytes after p on the same line.
+func (p Position) Add(n int) Position {
+	if !p.IsValid() {
+		return p
+	}
+
+	p.Offset += n
+	p.Column += n
+	return p
+}
+
+// String formats the position as file:line:column, omitting any unknown
+// parts.
+func (p Position) String() string {
+	s := p.File
+	if p.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+
+	if s == "" {
+		s = "-"
+	}
+
+	return s
+}
@@ -3,85 +3,159 @@ package token
 type TokenType string
 
 const (
-	ILLEGAL   TokenType = "ILLEGAL"
-	EOF                 = "EOF"
-	IDENT               = "IDENT"
-	INT                 = "INT"
-	ASSIGN              = "="
-	PLUS                = "+"
-	MINUS               = "-"
-	STAR                = "*"
-	FSLASH              = "/"
-	BANG                = "!"
-	LT                  = "<"
-	GT                  = ">"
-	DOT                 = "."
-	EQ                  = "=="
-	NE                  = "!="
-	COMMA               = ","
-	SEMICOLON           = ";"
-	LPAREN              = "("
-	RPAREN              = ")"
-	LBRACE              = "{"
-	RBRACE              = "}"
-	LBRACK              = "["
-	RBRACK              = "]"
-	COLON               = ":"
-	FUNCTION            = "FUNCTION"
-	LET                 = "LET"
-	RETURN              = "RETURN"
-	IF                  = "IF"
-	ELSE                = "ELSE"
-	TRUE                = "TRUE"
-	FALSE               = "FALSE"
-	STRING              = "STRING"
-	WHILE               = "WHILE"
-	FOR                 = "FOR"
-	CLASS               = "CLASS"
-	INST                = "INSTANCE"
+	ILLEGAL        TokenType = "ILLEGAL"
+	EOF                      = "EOF"
+	COMMENT                  = "COMMENT"
+	IDENT                    = "IDENT"
+	INT                      = "INT"
+	FLOAT                    = "FLOAT"
+	ASSIGN                   = "="
+	PLUS                     = "+"
+	MINUS                    = "-"
+	STAR                     = "*"
+	FSLASH                   = "/"
+	BANG                     = "!"
+	LT                       = "<"
+	GT                       = ">"
+	LE                       = "<="
+	GE                       = ">="
+	PERCENT                  = "%"
+	POW                      = "**"
+	AND                      = "&&"
+	OR                       = "||"
+	AMPERSAND                = "&"
+	PIPE                     = "|"
+	CARET                    = "^"
+	TILDE                    = "~"
+	SHL                      = "<<"
+	SHR                      = ">>"
+	PLUS_ASSIGN              = "+="
+	MINUS_ASSIGN             = "-="
+	STAR_ASSIGN              = "*="
+	FSLASH_ASSIGN            = "/="
+	PERCENT_ASSIGN           = "%="
+	DOT                      = "."
+	ELLIPSIS                 = "..."
+	ARROW                    = "=>"
+	QUESTION_DOT             = "?."
+	NULLISH                  = "??"
+	EQ                       = "=="
+	NE                       = "!="
+	COMMA                    = ","
+	SEMICOLON                = ";"
+	LPAREN                   = "("
+	RPAREN                   = ")"
+	LBRACE                   = "{"
+	RBRACE                   = "}"
+	LBRACK                   = "["
+	RBRACK                   = "]"
+	COLON                    = ":"
+	FUNCTION                 = "FUNCTION"
+	LET                      = "LET"
+	RETURN                   = "RETURN"
+	IF                       = "IF"
+	ELSE                     = "ELSE"
+	TRUE                     = "TRUE"
+	FALSE                    = "FALSE"
+	NULL                     = "NULL"
+	STRING                   = "STRING"
+	WHILE                    = "WHILE"
+	FOR                      = "FOR"
+	IN                       = "IN"
+	BREAK                    = "BREAK"
+	CONTINUE                 = "CONTINUE"
+	CLASS                    = "CLASS"
+	THROW                    = "THROW"
+	TRY                      = "TRY"
+	CATCH                    = "CATCH"
+	FINALLY                  = "FINALLY"
+	INST                     = "INSTANCE"
+	EXTENDS                  = "EXTENDS"
+	SUPER                    = "SUPER"
+	INSTANCEOF               = "INSTANCEOF"
+	IMPORT                   = "IMPORT"
+	EXPORT                   = "EXPORT"
+	MATCH                    = "MATCH"
 )
 
 var (
 	Keywords = map[string]TokenType{
-		"fn":     FUNCTION,
-		"let":    LET,
-		"return": RETURN,
-		"if":     IF,
-		"else":   ELSE,
-		"true":   TRUE,
-		"false":  FALSE,
-		"while":  WHILE,
-		"for":    FOR,
-		"class":  CLASS,
-		"inst":   INST,
+		"fn":         FUNCTION,
+		"let":        LET,
+		"return":     RETURN,
+		"if":         IF,
+		"else":       ELSE,
+		"true":       TRUE,
+		"false":      FALSE,
+		"null":       NULL,
+		"while":      WHILE,
+		"for":        FOR,
+		"in":         IN,
+		"break":      BREAK,
+		"continue":   CONTINUE,
+		"class":      CLASS,
+		"throw":      THROW,
+		"try":        TRY,
+		"catch":      CATCH,
+		"finally":    FINALLY,
+		"inst":       INST,
+		"extends":    EXTENDS,
+		"super":      SUPER,
+		"instanceof": INSTANCEOF,
+		"import":     IMPORT,
+		"export":     EXPORT,
+		"match":      MATCH,
 	}
 
-	TokenEOF    Token = Token{Type: EOF, Literal: ""}
-	TokenSemi         = Token{Type: SEMICOLON, Literal: ";"}
-	TokenLParen       = Token{Type: LPAREN, Literal: "("}
-	TokenRParen       = Token{Type: RPAREN, Literal: ")"}
-	TokenComma        = Token{Type: COMMA, Literal: ","}
-	TokenPlus         = Token{Type: PLUS, Literal: "+"}
-	TokenMinus        = Token{Type: MINUS, Literal: "-"}
-	TokenFSlash       = Token{Type: FSLASH, Literal: "/"}
-	TokenStar         = Token{Type: STAR, Literal: "*"}
-	TokenLT           = Token{Type: LT, Literal: "<"}
-	TokenGT           = Token{Type: GT, Literal: ">"}
-	TokenDot          = Token{Type: DOT, Literal: "."}
-	TokenLBrace       = Token{Type: LBRACE, Literal: "{"}
-	TokenRBrace       = Token{Type: RBRACE, Literal: "}"}
-	TokenLBrack       = Token{Type: LBRACK, Literal: "["}
-	TokenRBrack       = Token{Type: RBRACK, Literal: "]"}
-	TokenColon        = Token{Type: COLON, Literal: ":"}
-	TokenAssign       = Token{Type: ASSIGN, Literal: "="}
-	TokenEQ           = Token{Type: EQ, Literal: "=="}
-	TokenBang         = Token{Type: BANG, Literal: "!"}
-	TokenNE           = Token{Type: NE, Literal: "!="}
+	TokenEOF           Token = Token{Type: EOF, Literal: ""}
+	TokenSemi                = Token{Type: SEMICOLON, Literal: ";"}
+	TokenLParen              = Token{Type: LPAREN, Literal: "("}
+	TokenRParen              = Token{Type: RPAREN, Literal: ")"}
+	TokenComma               = Token{Type: COMMA, Literal: ","}
+	TokenPlus                = Token{Type: PLUS, Literal: "+"}
+	TokenMinus               = Token{Type: MINUS, Literal: "-"}
+	TokenFSlash              = Token{Type: FSLASH, Literal: "/"}
+	TokenStar                = Token{Type: STAR, Literal: "*"}
+	TokenLT                  = Token{Type: LT, Literal: "<"}
+	TokenGT                  = Token{Type: GT, Literal: ">"}
+	TokenLE                  = Token{Type: LE, Literal: "<="}
+	TokenGE                  = Token{Type: GE, Literal: ">="}
+	TokenPercent             = Token{Type: PERCENT, Literal: "%"}
+	TokenPow                 = Token{Type: POW, Literal: "**"}
+	TokenAnd                 = Token{Type: AND, Literal: "&&"}
+	TokenOr                  = Token{Type: OR, Literal: "||"}
+	TokenAmpersand           = Token{Type: AMPERSAND, Literal: "&"}
+	TokenPipe                = Token{Type: PIPE, Literal: "|"}
+	TokenCaret               = Token{Type: CARET, Literal: "^"}
+	TokenTilde               = Token{Type: TILDE, Literal: "~"}
+	TokenSHL                 = Token{Type: SHL, Literal: "<<"}
+	TokenSHR                 = Token{Type: SHR, Literal: ">>"}
+	TokenPlusAssign          = Token{Type: PLUS_ASSIGN, Literal: "+="}
+	TokenMinusAssign         = Token{Type: MINUS_ASSIGN, Literal: "-="}
+	TokenStarAssign          = Token{Type: STAR_ASSIGN, Literal: "*="}
+	TokenFSlashAssign        = Token{Type: FSLASH_ASSIGN, Literal: "/="}
+	TokenPercentAssign       = Token{Type: PERCENT_ASSIGN, Literal: "%="}
+	TokenDot                 = Token{Type: DOT, Literal: "."}
+	TokenEllipsis            = Token{Type: ELLIPSIS, Literal: "..."}
+	TokenArrow               = Token{Type: ARROW, Literal: "=>"}
+	TokenQuestionDot         = Token{Type: QUESTION_DOT, Literal: "?."}
+	TokenNullish             = Token{Type: NULLISH, Literal: "??"}
+	TokenLBrace              = Token{Type: LBRACE, Literal: "{"}
+	TokenRBrace              = Token{Type: RBRACE, Literal: "}"}
+	TokenLBrack              = Token{Type: LBRACK, Literal: "["}
+	TokenRBrack              = Token{Type: RBRACK, Literal: "]"}
+	TokenColon               = Token{Type: COLON, Literal: ":"}
+	TokenAssign              = Token{Type: ASSIGN, Literal: "="}
+	TokenEQ                  = Token{Type: EQ, Literal: "=="}
+	TokenBang                = Token{Type: BANG, Literal: "!"}
+	TokenNE                  = Token{Type: NE, Literal: "!="}
 )
 
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Position // position of the first byte of the token
+	End     Position // position immediately after the last byte of the token
 }
 
 func New(tokenType TokenType, literal byte) Token {
@@ -99,6 +173,7 @@ func NewKeyword(kw string) Token {
 
 func NewIdent(val string) Token { return Token{Type: IDENT, Literal: val} }
 func NewInt(val string) Token   { return Token{Type: INT, Literal: val} }
+func NewFloat(val string) Token { return Token{Type: FLOAT, Literal: val} }
 func NewStr(val string) Token   { return Token{Type: STRING, Literal: val} }
 
 func LookupIdent(ident string) TokenType {
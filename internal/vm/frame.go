@@ -0,0 +1,21 @@
+package vm
+
+import (
+	"github.com/donovandicks/gomonkey/internal/object"
+	"github.com/donovandicks/gomonkey/internal/opcode"
+)
+
+// Frame holds the execution state of a single function call.
+type Frame struct {
+	cl *object.Closure
+	ip int
+	bp int // stack pointer before the call, where the frame's locals begin
+}
+
+func NewFrame(cl *object.Closure, bp int) *Frame {
+	return &Frame{cl: cl, ip: -1, bp: bp}
+}
+
+func (f *Frame) Instrs() opcode.Instructions {
+	return f.cl.Fn.Instrs
+}
@@ -0,0 +1,553 @@
+package vm
+
+import (
+	"github.com/donovandicks/gomonkey/internal/compiler"
+	"github.com/donovandicks/gomonkey/internal/object"
+	"github.com/donovandicks/gomonkey/internal/opcode"
+)
+
+const (
+	StackSize   = 2048 // the initial size of the stack, which grows as needed
+	GlobalsSize = 65536
+)
+
+// iterator walks the elements of an iterable during a for-in loop. It only
+// ever lives on the stack.
+type iterator struct {
+	elems []object.Object
+	pos   int
+}
+
+func (it *iterator) Inspect() string         { return "iterator" }
+func (it *iterator) Type() object.ObjectType { return "ITERATOR" }
+
+// cell holds a variable that closures have captured, so that the function
+// that defined it and the closures share it. Cells only live in the slots of
+// locals and the free variables of closures, and are never values of their
+// own.
+type cell struct {
+	value object.Object
+}
+
+func (c *cell) Inspect() string         { return "cell" }
+func (c *cell) Type() object.ObjectType { return "CELL" }
+
+// mark records the height of the stack inside a loop, above the base of the
+// frame, which `break` and `continue` unwind it to. It only lives in the slot
+// of a hidden local.
+type mark struct {
+	height int
+}
+
+func (m *mark) Inspect() string         { return "mark" }
+func (m *mark) Type() object.ObjectType { return "MARK" }
+
+// deref returns the value of the variable held in a slot.
+func deref(obj object.Object) object.Object {
+	if c, ok := obj.(*cell); ok {
+		return c.value
+	}
+
+	return obj
+}
+
+type VM struct {
+	consts  []object.Object
+	globals []object.Object
+
+	stack []object.Object
+	sp    int // next free slot; the top of the stack is stack[sp-1]
+
+	frames []*Frame
+	fp     int // number of active frames
+
+	last   object.Object // the value of the last expression statement
+	halted bool
+
+	budget *object.Budget
+}
+
+func New(bytecode *compiler.Bytecode) *VM {
+	mainFn := &object.CompiledFunction{
+		Instrs:    bytecode.Instrs,
+		NumLocals: bytecode.NumLocals,
+		SourceMap: bytecode.SourceMap,
+	}
+
+	frames := []*Frame{NewFrame(&object.Closure{Fn: mainFn}, 0)}
+
+	vm := &VM{
+		consts:  bytecode.Consts,
+		globals: make([]object.Object, GlobalsSize),
+		stack:   make([]object.Object, StackSize),
+		frames:  frames,
+		fp:      1,
+	}
+
+	// reserve the slots for the locals of the main function
+	vm.grow(mainFn.NumLocals)
+	vm.sp = mainFn.NumLocals
+
+	return vm
+}
+
+// NewWithGlobals creates a VM whose globals start with the values, indexed as
+// the compiler defined them.
+func NewWithGlobals(bytecode *compiler.Bytecode, globals []object.Object) *VM {
+	vm := New(bytecode)
+	copy(vm.globals, globals)
+	return vm
+}
+
+// SetBudget limits how long the program runs, one step per instruction.
+func (vm *VM) SetBudget(budget *object.Budget) *VM {
+	vm.budget = budget
+	return vm
+}
+
+func (vm *VM) currentFrame() *Frame {
+	return vm.frames[vm.fp-1]
+}
+
+func (vm *VM) pushFrame(f *Frame) *object.Err {
+	// the main frame does not count towards the depth of calls
+	if vm.fp > vm.budget.MaxDepth() {
+		return object.ErrRecursion()
+	}
+
+	if vm.fp == len(vm.frames) {
+		vm.frames = append(vm.frames, f)
+	} else {
+		vm.frames[vm.fp] = f
+	}
+
+	vm.fp += 1
+	return nil
+}
+
+func (vm *VM) popFrame() *Frame {
+	vm.fp -= 1
+	return vm.frames[vm.fp]
+}
+
+// grow makes room on the stack for n slots.
+func (vm *VM) grow(n int) {
+	if n > len(vm.stack) {
+		vm.stack = append(vm.stack, make([]object.Object, max(n, 2*len(vm.stack))-len(vm.stack))...)
+	}
+}
+
+func (vm *VM) push(obj object.Object) *object.Err {
+	vm.grow(vm.sp + 1)
+
+	vm.stack[vm.sp] = obj
+	vm.sp += 1
+	return nil
+}
+
+// peek returns the top of the stack without popping it, or nil if the stack
+// is empty.
+func (vm *VM) peek() object.Object {
+	if vm.sp == 0 {
+		return nil
+	}
+
+	return vm.stack[vm.sp-1]
+}
+
+// typeOf names the type of a value for errors, which may be about a value
+// that is missing.
+func typeOf(obj object.Object) object.ObjectType {
+	if obj == nil {
+		return "nothing"
+	}
+
+	return obj.Type()
+}
+
+func (vm *VM) pop() object.Object {
+	obj := vm.stack[vm.sp-1]
+	vm.sp -= 1
+	return obj
+}
+
+// pushResult pushes the result of an operation, or returns it if the
+// operation failed.
+func (vm *VM) pushResult(obj object.Object) *object.Err {
+	if err, ok := obj.(*object.Err); ok {
+		return err
+	}
+
+	return vm.push(obj)
+}
+
+// readOperand decodes an operand of the given width following the current
+// instruction and advances past it.
+func (vm *VM) readOperand(width int) int {
+	frame := vm.currentFrame()
+	instrs := frame.Instrs()[frame.ip+1:]
+	frame.ip += width
+
+	if width == 1 {
+		return int(opcode.ReadUint8(instrs))
+	}
+
+	return int(opcode.ReadUint16(instrs))
+}
+
+func (vm *VM) buildMap(n int) object.Object {
+	pairs := make(map[object.HashKey]object.KVPair, n/2)
+
+	for i := vm.sp - n; i < vm.sp; i += 2 {
+		key := vm.stack[i]
+		hashable, ok := key.(object.HashableObject)
+		if !ok {
+			return object.NewErrWithKind(object.ERR_TYPE, "cannot use unhashable type %s as hash key", key.Type())
+		}
+
+		pairs[hashable.Hash()] = object.KVPair{Key: key, Value: vm.stack[i+1]}
+	}
+
+	return &object.Map{Entries: pairs}
+}
+
+// buildModule pops the name of a module followed by its n exports, as pairs of
+// names and values, and returns the module.
+func (vm *VM) buildModule(n int) *object.Module {
+	base := vm.sp - 2*n - 1
+
+	mod := &object.Module{
+		Name:    vm.stack[base].(*object.String).Value,
+		Exports: make(map[string]object.Object, n),
+	}
+
+	for i := base + 1; i < vm.sp; i += 2 {
+		mod.Exports[vm.stack[i].(*object.String).Value] = vm.stack[i+1]
+	}
+
+	vm.sp = base
+	return mod
+}
+
+// match matches the value against the pattern, pushing the values bound by
+// the pattern followed by true if it matches, or just false if it does not.
+// A strict match pushes only the bound values and fails if the value does not
+// match.
+func (vm *VM) match(pat *object.Pattern, val object.Object, strict bool) *object.Err {
+	var bound []object.Object
+	err := object.Match(pat.Pattern, val, nil, func(_ string, val object.Object) {
+		bound = append(bound, val)
+	})
+	if err != nil {
+		if err.Kind == object.ERR_MATCH && !strict {
+			return vm.push(object.FalseBool)
+		}
+
+		return err
+	}
+
+	for _, obj := range bound {
+		if err := vm.push(obj); err != nil {
+			return err
+		}
+	}
+
+	if strict {
+		return nil
+	}
+
+	return vm.push(object.TrueBool)
+}
+
+func (vm *VM) call(argc int) *object.Err {
+	callee := vm.stack[vm.sp-1-argc]
+
+	switch callee := callee.(type) {
+	case *object.Closure:
+		if argc != callee.Fn.NumParams {
+			return object.NewErrWithKind(object.ERR_TYPE, "wrong number of arguments: want=%d, got=%d", callee.Fn.NumParams, argc)
+		}
+
+		bp := vm.sp - argc
+		if err := vm.pushFrame(NewFrame(callee, bp)); err != nil {
+			return err
+		}
+		vm.grow(bp + callee.Fn.NumLocals)
+
+		// reserve the slots for the function's locals above its arguments
+		vm.sp = bp + callee.Fn.NumLocals
+		return nil
+	case *object.Builtin:
+		args := make([]object.Object, argc)
+		copy(args, vm.stack[vm.sp-argc:vm.sp])
+
+		vm.sp -= argc + 1
+		return vm.pushResult(callee.Fn(args...))
+	default:
+		return object.NewErrWithKind(object.ERR_TYPE, "undefined callable '%s'", callee.Type())
+	}
+}
+
+// returnValue leaves the current function, replacing its callee and arguments
+// on the stack with the returned value. Returning from the top level halts the
+// program.
+func (vm *VM) returnValue(val object.Object) *object.Err {
+	if vm.fp == 1 {
+		vm.last = val
+		vm.halted = true
+		return nil
+	}
+
+	frame := vm.popFrame()
+	vm.sp = frame.bp - 1
+
+	return vm.push(val)
+}
+
+func (vm *VM) pushClosure(constIdx, numFree int) *object.Err {
+	fn, ok := vm.consts[constIdx].(*object.CompiledFunction)
+	if !ok {
+		return object.NewErr("not a function: %s", vm.consts[constIdx].Inspect())
+	}
+
+	free := make([]object.Object, numFree)
+	copy(free, vm.stack[vm.sp-numFree:vm.sp])
+	vm.sp -= numFree
+
+	return vm.push(&object.Closure{Fn: fn, Free: free})
+}
+
+func (vm *VM) jump(target int) {
+	// the loop increments ip before decoding the next instruction
+	vm.currentFrame().ip = target - 1
+}
+
+// infixOps maps the binary opcodes to the operator they apply.
+var infixOps = map[opcode.OpCode]string{
+	opcode.OpAdd:          "+",
+	opcode.OpSub:          "-",
+	opcode.OpMul:          "*",
+	opcode.OpDiv:          "/",
+	opcode.OpMod:          "%",
+	opcode.OpPow:          "**",
+	opcode.OpBitAnd:       "&",
+	opcode.OpBitOr:        "|",
+	opcode.OpBitXor:       "^",
+	opcode.OpShiftLeft:    "<<",
+	opcode.OpShiftRight:   ">>",
+	opcode.OpEqual:        "==",
+	opcode.OpNotEqual:     "!=",
+	opcode.OpGreaterThan:  ">",
+	opcode.OpGreaterEqual: ">=",
+	opcode.OpLessThan:     "<",
+	opcode.OpLessEqual:    "<=",
+}
+
+func (vm *VM) step(code opcode.OpCode) *object.Err {
+	switch code {
+	case opcode.OpConstant:
+		return vm.push(vm.consts[vm.readOperand(2)])
+	case opcode.OpPop:
+		vm.last = vm.pop()
+	case opcode.OpTrue:
+		return vm.push(object.TrueBool)
+	case opcode.OpFalse:
+		return vm.push(object.FalseBool)
+	case opcode.OpNull:
+		return vm.push(object.NullObject)
+	case opcode.OpAdd, opcode.OpSub, opcode.OpMul, opcode.OpDiv, opcode.OpMod, opcode.OpPow,
+		opcode.OpBitAnd, opcode.OpBitOr, opcode.OpBitXor, opcode.OpShiftLeft, opcode.OpShiftRight,
+		opcode.OpEqual, opcode.OpNotEqual, opcode.OpGreaterThan, opcode.OpGreaterEqual, opcode.OpLessThan, opcode.OpLessEqual:
+		right := vm.pop()
+		left := vm.pop()
+		return vm.pushResult(object.InfixOp(infixOps[code], left, right))
+	case opcode.OpMinus:
+		return vm.pushResult(object.PrefixOp("-", vm.pop()))
+	case opcode.OpBang:
+		return vm.pushResult(object.PrefixOp("!", vm.pop()))
+	case opcode.OpBitNot:
+		return vm.pushResult(object.PrefixOp("~", vm.pop()))
+	case opcode.OpJump:
+		vm.jump(vm.readOperand(2))
+	case opcode.OpJumpNull:
+		target := vm.readOperand(2)
+		if vm.stack[vm.sp-1] == object.NullObject {
+			vm.jump(target)
+		}
+	case opcode.OpJumpNotTruthy:
+		target := vm.readOperand(2)
+		if !object.IsTruthy(vm.pop()) {
+			vm.jump(target)
+		}
+	case opcode.OpGetGlobal:
+		return vm.push(vm.globals[vm.readOperand(2)])
+	case opcode.OpSetGlobal:
+		vm.globals[vm.readOperand(2)] = vm.pop()
+
+		// statements such as let end by storing a variable and have no value,
+		// while expression statements end with a pop that sets it again
+		vm.last = nil
+	case opcode.OpGetLocal:
+		return vm.push(deref(vm.stack[vm.currentFrame().bp+vm.readOperand(1)]))
+	case opcode.OpSetLocal:
+		slot := &vm.stack[vm.currentFrame().bp+vm.readOperand(1)]
+		if c, ok := (*slot).(*cell); ok {
+			c.value = vm.pop()
+		} else {
+			*slot = vm.pop()
+		}
+		vm.last = nil
+	case opcode.OpDefineLocal:
+		// replacing the slot leaves any cell to the closures that captured it
+		vm.stack[vm.currentFrame().bp+vm.readOperand(1)] = vm.pop()
+		vm.last = nil
+	case opcode.OpGetBuiltin:
+		return vm.push(object.Builtins[vm.readOperand(1)].Builtin)
+	case opcode.OpGetFree:
+		return vm.push(deref(vm.currentFrame().cl.Free[vm.readOperand(1)]))
+	case opcode.OpSetFree:
+		vm.currentFrame().cl.Free[vm.readOperand(1)].(*cell).value = vm.pop()
+		vm.last = nil
+	case opcode.OpCaptureLocal:
+		slot := &vm.stack[vm.currentFrame().bp+vm.readOperand(1)]
+		if _, ok := (*slot).(*cell); !ok {
+			*slot = &cell{value: *slot}
+		}
+		return vm.push(*slot)
+	case opcode.OpCaptureFree:
+		return vm.push(vm.currentFrame().cl.Free[vm.readOperand(1)])
+	case opcode.OpCurrentClosure:
+		return vm.push(vm.currentFrame().cl)
+	case opcode.OpList:
+		n := vm.readOperand(2)
+		elems := make([]object.Object, n)
+		copy(elems, vm.stack[vm.sp-n:vm.sp])
+		vm.sp -= n
+		return vm.push(object.NewListObject(elems))
+	case opcode.OpMap:
+		n := vm.readOperand(2)
+		m := vm.buildMap(n)
+		vm.sp -= n
+		return vm.pushResult(m)
+	case opcode.OpModule:
+		n := vm.readOperand(2)
+		return vm.push(vm.buildModule(n))
+	case opcode.OpGetProperty:
+		name := vm.consts[vm.readOperand(2)].(*object.String).Value
+		return vm.pushResult(object.GetProperty(vm.pop(), name))
+	case opcode.OpOptionalProperty:
+		name := vm.consts[vm.readOperand(2)].(*object.String).Value
+		return vm.pushResult(object.OptionalProperty(vm.pop(), name))
+	case opcode.OpSetProperty:
+		name := vm.consts[vm.readOperand(2)].(*object.String).Value
+		val := vm.pop()
+		return vm.pushResult(object.SetProperty(vm.pop(), name, val))
+	case opcode.OpMatch:
+		return vm.match(vm.consts[vm.readOperand(2)].(*object.Pattern), vm.pop(), false)
+	case opcode.OpDestructure:
+		return vm.match(vm.consts[vm.readOperand(2)].(*object.Pattern), vm.pop(), true)
+	case opcode.OpIndex:
+		index := vm.pop()
+		left := vm.pop()
+		return vm.pushResult(object.Index(left, index))
+	case opcode.OpOptionalIndex:
+		index := vm.pop()
+		left := vm.pop()
+		return vm.pushResult(object.OptionalIndex(left, index))
+	case opcode.OpSetIndex:
+		val := vm.pop()
+		index := vm.pop()
+		left := vm.pop()
+		return vm.pushResult(object.SetIndex(left, index, val))
+	case opcode.OpDup:
+		n := vm.readOperand(1)
+		for _, obj := range vm.stack[vm.sp-n : vm.sp] {
+			if err := vm.push(obj); err != nil {
+				return err
+			}
+		}
+	case opcode.OpIter:
+		iterable := vm.pop()
+		elems, ok := object.IterElems(iterable)
+		if !ok {
+			return object.NewErrWithKind(object.ERR_TYPE, "cannot iterate over %s", iterable.Type())
+		}
+
+		return vm.push(&iterator{elems: elems})
+	case opcode.OpIterNext:
+		target := vm.readOperand(2)
+		it, ok := vm.peek().(*iterator)
+		if !ok {
+			// the compiler keeps the iterator on top of the stack during the loop
+			return object.NewErr("internal error: expected an iterator on the stack, got %s", typeOf(vm.peek()))
+		}
+
+		if it.pos >= len(it.elems) {
+			vm.jump(target)
+			return nil
+		}
+
+		it.pos += 1
+		return vm.push(it.elems[it.pos-1])
+	case opcode.OpMark:
+		frame := vm.currentFrame()
+		vm.stack[frame.bp+vm.readOperand(1)] = &mark{height: vm.sp - frame.bp}
+	case opcode.OpUnwind:
+		frame := vm.currentFrame()
+		vm.sp = frame.bp + vm.stack[frame.bp+vm.readOperand(1)].(*mark).height
+	case opcode.OpCall:
+		return vm.call(vm.readOperand(1))
+	case opcode.OpReturnValue:
+		return vm.returnValue(vm.pop())
+	case opcode.OpReturn:
+		return vm.returnValue(object.NullObject)
+	case opcode.OpClosure:
+		constIdx := vm.readOperand(2)
+		numFree := vm.readOperand(1)
+		return vm.pushClosure(constIdx, numFree)
+	default:
+		return object.NewErr("unknown opcode %d", code)
+	}
+
+	return nil
+}
+
+// trace records the call stack on an error raised by the current instruction.
+func (vm *VM) trace(err *object.Err) *object.Err {
+	for i := vm.fp - 1; i >= 0; i-- {
+		frame := vm.frames[i]
+
+		name := frame.cl.Fn.DisplayName()
+		if i == 0 {
+			name = object.MAIN_FUNCTION
+		}
+
+		err.Trace = append(err.Trace, object.TraceFrame{
+			Function: name,
+			Pos:      frame.cl.Fn.SourceMap.Lookup(frame.ip),
+		})
+	}
+
+	return err
+}
+
+// Run executes the bytecode and returns the result of the program: the value
+// of the last expression statement, the value of a top level return, or the
+// error that stopped execution.
+func (vm *VM) Run() object.Object {
+	for !vm.halted && vm.currentFrame().ip < len(vm.currentFrame().Instrs())-1 {
+		frame := vm.currentFrame()
+		frame.ip += 1
+
+		if err := vm.budget.Step(); err != nil {
+			return vm.trace(err)
+		}
+
+		code := opcode.OpCode(frame.Instrs()[frame.ip])
+		if err := vm.step(code); err != nil {
+			return vm.trace(err)
+		}
+	}
+
+	return vm.last
+}
@@ -0,0 +1,1024 @@
+package vm_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/donovandicks/gomonkey/internal/compiler"
+	"github.com/donovandicks/gomonkey/internal/interpreter"
+	"github.com/donovandicks/gomonkey/internal/lexer"
+	"github.com/donovandicks/gomonkey/internal/module"
+	"github.com/donovandicks/gomonkey/internal/object"
+	"github.com/donovandicks/gomonkey/internal/opcode"
+	"github.com/donovandicks/gomonkey/internal/parser"
+	"github.com/donovandicks/gomonkey/internal/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVM(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		input  string
+		output object.Object
+	}{
+		{
+			name:   "integer arithmetic",
+			input:  "50 / 2 * 2 + 10 - 5",
+			output: object.NewIntegerObject(55),
+		},
+		{
+			name:   "mixed arithmetic",
+			input:  "1 + 2.5 * 2",
+			output: object.NewFloatObject(6),
+		},
+		{
+			name:   "prefix operators",
+			input:  "-(5 + 5); !!5",
+			output: object.TrueBool,
+		},
+		{
+			name:   "comparisons",
+			input:  "(1 < 2) == (2 > 1)",
+			output: object.TrueBool,
+		},
+		{
+			name:   "ordering comparisons",
+			input:  "[1 <= 1, 2 <= 1, 1 >= 1, 1 >= 2, 1.5 <= 2, 2 >= 2.5]",
+			output: object.NewListObject([]object.Object{object.TrueBool, object.FalseBool, object.TrueBool, object.FalseBool, object.TrueBool, object.FalseBool}),
+		},
+		{
+			name:   "modulo",
+			input:  "[7 % 3, -7 % 3, 7.5 % 2]",
+			output: object.NewListObject([]object.Object{object.NewIntegerObject(1), object.NewIntegerObject(-1), object.NewFloatObject(1.5)}),
+		},
+		{
+			name:   "exponentiation",
+			input:  "[2 ** 10, 2 ** 3 ** 2, 2 ** -1, 4 ** 0.5, -2 ** 2]",
+			output: object.NewListObject([]object.Object{object.NewIntegerObject(1024), object.NewIntegerObject(512), object.NewFloatObject(0.5), object.NewFloatObject(2), object.NewIntegerObject(-4)}),
+		},
+		{
+			name:   "bitwise operators",
+			input:  "[6 & 3, 6 | 3, 6 ^ 3, ~6, 1 << 4, -16 >> 2]",
+			output: object.NewListObject([]object.Object{object.NewIntegerObject(2), object.NewIntegerObject(7), object.NewIntegerObject(5), object.NewIntegerObject(-7), object.NewIntegerObject(16), object.NewIntegerObject(-4)}),
+		},
+		{
+			name:   "logical operators",
+			input:  "[true && 1, true && 0 == 1, false || 1, false || false, !false && !false]",
+			output: object.NewListObject([]object.Object{object.TrueBool, object.FalseBool, object.TrueBool, object.FalseBool, object.TrueBool}),
+		},
+		{
+			name:   "logical operators short circuit",
+			input:  "let fail = fn() { 1 / 0 }; [false && fail(), true || fail()]",
+			output: object.NewListObject([]object.Object{object.FalseBool, object.TrueBool}),
+		},
+		{
+			name:   "compound assignment",
+			input:  "let x = 10; x += 5; x -= 3; x *= 4; x /= 6; x %= 5; x",
+			output: object.NewIntegerObject(3),
+		},
+		{
+			name:   "compound assignment is an expression",
+			input:  "let x = 1; let y = x += 1; [x, y]",
+			output: object.NewListObject([]object.Object{object.NewIntegerObject(2), object.NewIntegerObject(2)}),
+		},
+		{
+			name:   "double negation",
+			input:  "let x = 5; [--x, 1--x]",
+			output: object.NewListObject([]object.Object{object.NewIntegerObject(5), object.NewIntegerObject(6)}),
+		},
+		{
+			name:   "accumulate in a function",
+			input:  `let total = fn(xs) { let sum = 0; for (x in xs) { sum += x } sum }; total([1, 2, 3])`,
+			output: object.NewIntegerObject(6),
+		},
+		{
+			name:   "list index assignment",
+			input:  "let xs = [1, 2, 3]; xs[0] = 10; xs[-1] *= 2; xs[1] += 1; xs",
+			output: object.NewListObject([]object.Object{object.NewIntegerObject(10), object.NewIntegerObject(3), object.NewIntegerObject(6)}),
+		},
+		{
+			name:   "map index assignment",
+			input:  `let counts = {}; for (w in ["a", "b", "a"]) { counts[w] = 0 } for (w in ["a", "b", "a"]) { counts[w] += 1 } [counts["a"], counts["b"]]`,
+			output: object.NewListObject([]object.Object{object.NewIntegerObject(2), object.NewIntegerObject(1)}),
+		},
+		{
+			name:   "string concatenation",
+			input:  `"mon" + "key"`,
+			output: object.NewStringObject("monkey"),
+		},
+		{
+			name:   "conditionals",
+			input:  "if (1 > 2) { 10 } else { 20 }",
+			output: object.NewIntegerObject(20),
+		},
+		{
+			name:   "conditionals without alternative",
+			input:  "if (false) { 10 }",
+			output: object.NullObject,
+		},
+		{
+			name:   "globals and assignment",
+			input:  "let a = 1; let b = a + 1; b = b * 10; b",
+			output: object.NewIntegerObject(20),
+		},
+		{
+			name:   "lists",
+			input:  "[1, 2 * 2, 3][1]",
+			output: object.NewIntegerObject(4),
+		},
+		{
+			name:   "negative index",
+			input:  "[1, 2, 3][-1]",
+			output: object.NewIntegerObject(3),
+		},
+		{
+			name:   "maps",
+			input:  `let m = {"a": 1, 2: "b"}; m["a"] + len(m[2])`,
+			output: object.NewIntegerObject(2),
+		},
+		{
+			name:   "builtins",
+			input:  `len("four") + len([1, 2])`,
+			output: object.NewIntegerObject(6),
+		},
+		{
+			name:   "functions",
+			input:  "let add = fn(a, b) { let c = a + b; c }; add(1, 2) + add(3, 4)",
+			output: object.NewIntegerObject(10),
+		},
+		{
+			name:   "early return",
+			input:  "let f = fn() { if (true) { return 1; } 2 }; f()",
+			output: object.NewIntegerObject(1),
+		},
+		{
+			name:   "empty function body",
+			input:  "let f = fn() { }; f()",
+			output: object.NullObject,
+		},
+		{
+			name:   "top level return",
+			input:  "1; return 2; 3;",
+			output: object.NewIntegerObject(2),
+		},
+		{
+			name: "closures",
+			input: `
+			let adder = fn(x) { return fn(y) { x + y }; };
+			let addTwo = adder(2);
+			addTwo(3)`,
+			output: object.NewIntegerObject(5),
+		},
+		{
+			name: "nested closures",
+			input: `
+			let outer = fn(a) {
+				let middle = fn(b) {
+					return fn(c) { a + b + c };
+				};
+				middle
+			};
+			outer(1)(2)(3)`,
+			output: object.NewIntegerObject(6),
+		},
+		{
+			name: "recursive functions",
+			input: `
+			fn fib(n) {
+				if (n < 2) { return n; }
+				fib(n - 1) + fib(n - 2)
+			}
+			fib(15)`,
+			output: object.NewIntegerObject(610),
+		},
+		{
+			name: "recursive local functions",
+			input: `
+			let wrapper = fn() {
+				let countdown = fn(n) { if (n == 0) { 0 } else { countdown(n - 1) } };
+				countdown(3)
+			};
+			wrapper()`,
+			output: object.NewIntegerObject(0),
+		},
+		{
+			name:   "closures assign captured variables",
+			input:  "let mk = fn() { let c = 0; let inc = fn() { c = c + 1; c }; inc(); inc(); c }; mk()",
+			output: object.NewIntegerObject(2),
+		},
+		{
+			name: "closures share captured variables",
+			input: `
+			let counter = fn() {
+				let n = 0;
+				return {"inc": fn() { n += 1 }, "get": fn() { n }};
+			};
+			let c = counter();
+			c["inc"](); c["inc"]();
+			c["get"]()`,
+			output: object.NewIntegerObject(2),
+		},
+		{
+			name: "nested closures assign captured variables",
+			input: `
+			let f = fn() {
+				let n = 0;
+				let g = fn() { let h = fn() { n = n + 1 }; h(); h() };
+				g();
+				n
+			};
+			f()`,
+			output: object.NewIntegerObject(2),
+		},
+		{
+			name:   "closures see redefinitions",
+			input:  "let f = fn() { let x = 1; let g = fn() { x }; let x = 2; g() }; f()",
+			output: object.NewIntegerObject(2),
+		},
+		{
+			name:   "closures capture each iteration of a for-in loop",
+			input:  "let fs = {}; for (x in [1, 2, 3]) { fs[x] = fn() { x }; } fs[1]()",
+			output: object.NewIntegerObject(1),
+		},
+		{
+			name:   "closures capture each iteration of a while loop",
+			input:  "let fs = {}; let i = 0; while (i < 3) { let j = i; fs[i] = fn() { j }; i += 1; } fs[0]()",
+			output: object.NewIntegerObject(0),
+		},
+		{
+			name:   "closures capture each iteration of a loop in a function",
+			input:  "let f = fn() { let fs = {}; for (x in [1, 2, 3]) { fs[x] = fn() { x }; } fs[1]() + fs[3]() }; f()",
+			output: object.NewIntegerObject(4),
+		},
+		{
+			name:   "closures share the variable of a for loop",
+			input:  "let fs = {}; for (let i = 0; i < 3; i += 1) { fs[i] = fn() { i }; } fs[0]()",
+			output: object.NewIntegerObject(3),
+		},
+		{
+			name:   "while loops",
+			input:  "let i = 0; while (i < 10) { i = i + 1; if (i == 5) { break; } } i",
+			output: object.NewIntegerObject(5),
+		},
+		{
+			name: "for loops",
+			input: `
+			let sum = 0;
+			for (let i = 0; i < 5; i = i + 1) {
+				if (i == 2) { continue; }
+				sum = sum + i;
+			}
+			sum`,
+			output: object.NewIntegerObject(8),
+		},
+		{
+			name: "for-in loops",
+			input: `
+			let sum = 0;
+			for (x in [1, 2, 3, 4]) {
+				if (x == 4) { break; }
+				sum = sum + x;
+			}
+			sum`,
+			output: object.NewIntegerObject(6),
+		},
+		{
+			name: "loops inside functions",
+			input: `
+			let count = fn(s) {
+				let n = 0;
+				for (c in s) { n = n + 1; }
+				return n;
+			};
+			count("hello")`,
+			output: object.NewIntegerObject(5),
+		},
+		{
+			name:   "continue inside a list literal",
+			input:  "let n = 0; for (x in [1, 2, 3]) { let y = [1, if (x == 2) { continue; } else { 2 }]; n += 1; } n",
+			output: object.NewIntegerObject(2),
+		},
+		{
+			name:   "break inside an operand",
+			input:  "let n = 0; while (n < 5) { n = n + if (true) { break; } else { 1 }; } n",
+			output: object.NewIntegerObject(0),
+		},
+		{
+			name:   "break inside the arguments of a call in a function",
+			input:  "let f = fn() { let n = 0; for (x in [1, 2, 3]) { n = n + len([x, if (x == 2) { break; } else { 0 }]); } n }; f()",
+			output: object.NewIntegerObject(2),
+		},
+		{
+			name:   "break in a loop condition leaves the enclosing loop",
+			input:  "let n = 0; for (x in [1, 2, 3]) { while (if (x == 2) { break; } else { false }) { } n += 1; } n",
+			output: object.NewIntegerObject(1),
+		},
+		{
+			name:   "loop value",
+			input:  "while (false) { }",
+			output: object.NullObject,
+		},
+		{
+			name:   "type error",
+			input:  `1 + "a"`,
+			output: object.NewErr("type error: cannot perform '+' on INTEGER, STRING"),
+		},
+		{
+			name:   "index out of bounds",
+			input:  "[1][5]",
+			output: object.NewErr("index out of bounds: 5"),
+		},
+		{
+			name:   "unhashable map key",
+			input:  `{"a": 1}[[1]]`,
+			output: object.NewErr("cannot index map using non-hashable type LIST"),
+		},
+		{
+			name:   "str builtin",
+			input:  `str(1) + str("a") + str([true])`,
+			output: object.NewStringObject("1a[true]"),
+		},
+		{
+			name:   "block scoping: shadowing in if",
+			input:  "let x = 1; if (true) { let x = 2; x } ; x",
+			output: object.NewIntegerObject(1),
+		},
+		{
+			name:   "block scoping: assignment in if",
+			input:  "let x = 1; if (true) { x = 2; } ; x",
+			output: object.NewIntegerObject(2),
+		},
+		{
+			name:   "block scoping: shadowing in loop",
+			input:  "let f = fn() { let x = 0; for (i in [1, 2]) { let x = i; } x }; f()",
+			output: object.NewIntegerObject(0),
+		},
+		{
+			name:   "block scoping: loop variable shadows outer",
+			input:  "let i = 10; let n = 0; for (let i = 0; i < 3; i = i + 1) { n = n + i; } i + n",
+			output: object.NewIntegerObject(13),
+		},
+		{
+			name:   "assigning a global from a function",
+			input:  "let n = 0; let inc = fn() { n = n + 1; }; inc(); inc(); n",
+			output: object.NewIntegerObject(2),
+		},
+		{
+			name:   "null literal",
+			input:  "let x = null; x == null",
+			output: object.TrueBool,
+		},
+		{
+			name:   "null has its own type",
+			input:  "1 + null",
+			output: object.NewErr("type error: cannot perform '+' on INTEGER, NULL"),
+		},
+		{
+			name:   "null coalescing",
+			input:  "[null ?? 1, 0 ?? 1, false ?? 1]",
+			output: object.NewListObject([]object.Object{object.NewIntegerObject(1), object.NewIntegerObject(0), object.FalseBool}),
+		},
+		{
+			name:   "null coalescing does not evaluate the default",
+			input:  "let n = 0; let f = fn() { n = n + 1; }; 1 ?? f(); null ?? f(); n",
+			output: object.NewIntegerObject(1),
+		},
+		{
+			name:   "optional index of a missing key",
+			input:  `let config = {"name": "app"}; config?.["port"] ?? 8080`,
+			output: object.NewIntegerObject(8080),
+		},
+		{
+			name:   "optional index of a present key",
+			input:  `let config = {"port": 80}; config?.["port"] ?? 8080`,
+			output: object.NewIntegerObject(80),
+		},
+		{
+			name:   "optional index out of bounds",
+			input:  "[1, 2]?.[5]",
+			output: object.NullObject,
+		},
+		{
+			name:   "optional index short-circuits on null",
+			input:  "let n = 0; let f = fn() { n = n + 1; 0 }; null?.[f()]; n",
+			output: object.NewIntegerObject(0),
+		},
+		{
+			name:   "optional properties of nested maps",
+			input:  `let config = {"db": {"host": "localhost"}}; [config.db.host, config?.cache?.host ?? "none"]`,
+			output: object.NewListObject([]object.Object{object.NewStringObject("localhost"), object.NewStringObject("none")}),
+		},
+		{
+			name:   "optional access short-circuits the rest of the chain",
+			input:  "let n = 0; let g = fn() { n = n + 1 }; let m = null; [m?.a.b, m?.[0][1], m?.f(g()), n]",
+			output: object.NewListObject([]object.Object{object.NullObject, object.NullObject, object.NullObject, object.NewIntegerObject(0)}),
+		},
+		{
+			name:   "optional access only short-circuits on null",
+			input:  "let m = {}; m?.a.b",
+			output: object.NewErr("object NULL has no properties"),
+		},
+		{
+			name:   "optional access still raises type errors",
+			input:  "let x = 1; x?.[0]",
+			output: object.NewErr("cannot index INTEGER object"),
+		},
+		{
+			name:   "builtins without a result give null",
+			input:  `let x = print(); [x == null, str(x), x ?? 5]`,
+			output: object.NewListObject([]object.Object{object.TrueBool, object.NewStringObject("null"), object.NewIntegerObject(5)}),
+		},
+		{
+			name:   "empty blocks give null",
+			input:  "let y = if (true) { }; let z = if (true) { let a = 1; }; [y, z]",
+			output: object.NewListObject([]object.Object{object.NullObject, object.NullObject}),
+		},
+		{
+			name:   "else if",
+			input:  `let sign = fn(x) { if (x < 0) { "-" } else if (x == 0) { "0" } else { "+" } }; [sign(-2), sign(0), sign(2)]`,
+			output: object.NewListObject([]object.Object{object.NewStringObject("-"), object.NewStringObject("0"), object.NewStringObject("+")}),
+		},
+		{
+			name:   "string equality",
+			input:  `["a" == "a", "a" == "b", "a" != "b"]`,
+			output: object.NewListObject([]object.Object{object.TrueBool, object.FalseBool, object.TrueBool}),
+		},
+		{
+			name:  "match literals",
+			input: `let f = fn(x) { match (x) { 0 => "zero", -1 => "minus one", "a" => "letter", true => "yes", null => "none", _ => "other" } }; [f(0), f(-1), f("a"), f(true), f(null), f(2)]`,
+			output: object.NewListObject([]object.Object{
+				object.NewStringObject("zero"),
+				object.NewStringObject("minus one"),
+				object.NewStringObject("letter"),
+				object.NewStringObject("yes"),
+				object.NewStringObject("none"),
+				object.NewStringObject("other"),
+			}),
+		},
+		{
+			name:   "match lists",
+			input:  "let f = fn(xs) { match (xs) { [] => 0, [x] => x, [x, y, ...rest] => x + y + len(rest) } }; [f([]), f([5]), f([1, 2]), f([1, 2, 3, 4])]",
+			output: object.NewListObject([]object.Object{object.NewIntegerObject(0), object.NewIntegerObject(5), object.NewIntegerObject(3), object.NewIntegerObject(5)}),
+		},
+		{
+			name:   "match nested maps",
+			input:  `match ({"kind": "circle", "center": [1, 2]}) { {"kind": "square"} => 0, {kind: "circle", center: [x, y]} => x + y }`,
+			output: object.NewIntegerObject(3),
+		},
+		{
+			name:   "match guards",
+			input:  "let f = fn(n) { match (n) { x if x < 0 => -x, x if x > 9 => 9, x => x } }; [f(-3), f(12), f(4)]",
+			output: object.NewListObject([]object.Object{object.NewIntegerObject(3), object.NewIntegerObject(9), object.NewIntegerObject(4)}),
+		},
+		{
+			name:   "match block arms",
+			input:  "match ([1, 2]) { [a, b] => { let sum = a + b; sum * 2 } _ => 0 }",
+			output: object.NewIntegerObject(6),
+		},
+		{
+			name:   "match without a matching arm",
+			input:  "match (1) { 2 => 2 }",
+			output: object.NullObject,
+		},
+		{
+			name:   "match a map against a boolean key",
+			input:  `match ({"a": 1}) { {true: x} => x, _ => 0 }`,
+			output: object.NewErr("cannot index map using non-hashable type BOOLEAN"),
+		},
+		{
+			name:   "match bindings do not leak",
+			input:  "let x = 1; match (2) { x => x }; x",
+			output: object.NewIntegerObject(1),
+		},
+		{
+			name:   "destructure lists",
+			input:  "let [a, b, ...rest] = [1, 2, 3, 4]; [a, b, rest]",
+			output: object.NewListObject([]object.Object{object.NewIntegerObject(1), object.NewIntegerObject(2), object.NewListObject([]object.Object{object.NewIntegerObject(3), object.NewIntegerObject(4)})}),
+		},
+		{
+			name:   "destructure maps",
+			input:  `let {name, "years": age} = {"name": "ada", "years": 36}; [name, age]`,
+			output: object.NewListObject([]object.Object{object.NewStringObject("ada"), object.NewIntegerObject(36)}),
+		},
+		{
+			name:   "destructure nested patterns",
+			input:  `let {point: [x, _, z]} = {"point": [1, 2, 3]}; x + z`,
+			output: object.NewIntegerObject(4),
+		},
+		{
+			name:   "destructure function parameters",
+			input:  `let f = fn([a, b], {c}) { a + b + c }; f([1, 2], {"c": 3})`,
+			output: object.NewIntegerObject(6),
+		},
+		{
+			name:   "destructure parameters of a named function",
+			input:  "fn swap([a, b]) { [b, a] } swap([1, 2])",
+			output: object.NewListObject([]object.Object{object.NewIntegerObject(2), object.NewIntegerObject(1)}),
+		},
+		{
+			name:   "destructure too few elements",
+			input:  "let [a, b] = [1];",
+			output: object.NewErr("expected a list of 2 elements, got 1"),
+		},
+		{
+			name:   "destructure a missing key",
+			input:  `let {name, age} = {"name": "ada"};`,
+			output: object.NewErr("missing key age"),
+		},
+		{
+			name:   "destructure a boolean key",
+			input:  `let {true: x} = {"a": 1};`,
+			output: object.NewErr("cannot index map using non-hashable type BOOLEAN"),
+		},
+		{
+			name:   "destructure a parameter of the wrong type",
+			input:  "let f = fn([a]) { a }; f(1)",
+			output: object.NewErr("expected a list, got INTEGER"),
+		},
+		{
+			name:   "assigning a property of a map",
+			input:  `let m = {"x": 1}; m.x = 2`,
+			output: object.NewErr("cannot assign property x of MAP object"),
+		},
+		{
+			name:   "maps iterate in key order",
+			input:  `let m = {"b": 1, 10: 2, "a": 3, 2: 4}; let keys = ""; for (k in m) { keys += str(k) + "," } [keys, str(m)]`,
+			output: object.NewListObject([]object.Object{object.NewStringObject("2,10,a,b,"), object.NewStringObject("{2:4, 10:2, a:3, b:1}")}),
+		},
+		{
+			name:   "values that contain themselves",
+			input:  `let a = [0]; a[0] = a; let m = {}; m["self"] = m; let b = [1]; [str(a), str(m), str([b, b])]`,
+			output: object.NewListObject([]object.Object{object.NewStringObject("[[...]]"), object.NewStringObject("{self:{...}}"), object.NewStringObject("[[1], [1]]")}),
+		},
+		{
+			name:   "equal numbers are the same key",
+			input:  `let m = {2.0: "x"}; m[2] = "y"; let n = 0; for (k in m) { n += 1 } [m[2], m[2.0], n]`,
+			output: object.NewListObject([]object.Object{object.NewStringObject("y"), object.NewStringObject("y"), object.NewIntegerObject(1)}),
+		},
+		{
+			name:   "statements without a value",
+			input:  "1; let x = 5;",
+			output: nil,
+		},
+		{
+			name:   "less than keeps its operands",
+			input:  `"a" < "b"`,
+			output: object.NewErr("unknown string operator '<' on strings a, b"),
+		},
+		{
+			name:   "calling a non-function",
+			input:  "let x = 1; x()",
+			output: object.NewErr("undefined callable 'INTEGER'"),
+		},
+		{
+			name:   "iterating a non-iterable",
+			input:  "for (x in 1) { x }",
+			output: object.NewErr("cannot iterate over INTEGER"),
+		},
+	}
+
+	for _, testCase := range cases {
+		tc := testCase
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := parser.NewParser(lexer.NewLexer(tc.input))
+			program := p.ParseProgram()
+			assert.Empty(t, p.Errors())
+
+			c := compiler.NewCompiler()
+			err := c.Compile(program)
+			assert.Nil(t, err, "failed to compile: %v", err)
+
+			machine := vm.New(c.Bytecode())
+			assertObject(t, tc.output, machine.Run())
+
+			// both engines must agree
+			assertObject(t, tc.output, interpreter.Eval(program, object.NewEnv()))
+		})
+	}
+}
+
+func TestVM_Errors(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{
+			name:  "wrong number of arguments",
+			input: "let f = fn(a) { a }; f(1, 2)",
+			err:   "wrong number of arguments: want=1, got=2",
+		},
+		{
+			name:  "assign out of bounds",
+			input: "let xs = [1]; xs[1] = 2",
+			err:   "index out of bounds: 1",
+		},
+		{
+			name:  "assign to a string index",
+			input: `let s = "abc"; s[0] = "x"`,
+			err:   "cannot assign to index of STRING object",
+		},
+		{
+			name:  "compound assignment to a missing key",
+			input: `let m = {}; m["k"] += 1`,
+			err:   "no key found for k (hash=12638198195671924106)",
+		},
+		{
+			name:  "integer division by zero",
+			input: "let zero = 0; 1 / zero",
+			err:   "division by zero",
+		},
+		{
+			name:  "modulo by zero",
+			input: "5 % 0",
+			err:   "modulo by zero",
+		},
+		{
+			name:  "float division by zero",
+			input: "1.5 / 0",
+			err:   "division by zero",
+		},
+		{
+			name:  "negative shift",
+			input: "1 << -1",
+			err:   "negative shift count -1",
+		},
+		{
+			name:  "bitwise operators on floats",
+			input: "1.5 & 1",
+			err:   "unknown float operator '&' on floats 1.5, 1",
+		},
+		{
+			name:  "unbounded recursion",
+			input: "let f = fn() { f() }; f()",
+			err:   "maximum recursion depth exceeded",
+		},
+		{
+			name:  "deep recursion within the limit",
+			input: "let f = fn(n) { if (n == 0) { 1 + true } else { 1 + f(n - 1) } }; f(999)",
+			err:   "type error: cannot perform '+' on INTEGER, BOOLEAN",
+		},
+	}
+
+	for _, testCase := range cases {
+		tc := testCase
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := parser.NewParser(lexer.NewLexer(tc.input))
+			program := p.ParseProgram()
+
+			c := compiler.NewCompiler()
+			assert.Nil(t, c.Compile(program))
+
+			assertObject(t, object.NewErr(tc.err), vm.New(c.Bytecode()).Run())
+		})
+	}
+}
+
+func TestVM_MaxDepth(t *testing.T) {
+	t.Parallel()
+
+	program := parser.NewParser(lexer.NewLexer("let f = fn(n) { if (n == 0) { 0 } else { 1 + f(n - 1) } }; f(20)")).ParseProgram()
+
+	c := compiler.NewCompiler()
+	assert.Nil(t, c.Compile(program))
+
+	budget := object.NewBudget(context.Background(), 0).WithMaxDepth(20)
+	res := vm.New(c.Bytecode()).SetBudget(budget).Run()
+	if assert.IsType(t, &object.Err{}, res) {
+		err := res.(*object.Err)
+		assert.Equal(t, object.ERR_RECURSION, err.Kind)
+		assert.Len(t, err.Trace, 21)
+	}
+
+	budget = object.NewBudget(context.Background(), 0).WithMaxDepth(21)
+	assertObject(t, object.NewIntegerObject(20), vm.New(c.Bytecode()).SetBudget(budget).Run())
+}
+
+func TestVM_CorruptBytecode(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		instrs []opcode.Instructions
+		err    string
+	}{
+		{
+			name:   "iterating over a value",
+			instrs: []opcode.Instructions{opcode.NewInstruction(opcode.OpConstant, []int{0}), opcode.NewInstruction(opcode.OpIterNext, []int{0})},
+			err:    "internal error: expected an iterator on the stack, got INTEGER",
+		},
+		{
+			name:   "iterating over an empty stack",
+			instrs: []opcode.Instructions{opcode.NewInstruction(opcode.OpIterNext, []int{0})},
+			err:    "internal error: expected an iterator on the stack, got nothing",
+		},
+	}
+
+	for _, testCase := range cases {
+		tc := testCase
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			bytecode := &compiler.Bytecode{Consts: []object.Object{object.NewIntegerObject(1)}}
+			for _, instr := range tc.instrs {
+				bytecode.Instrs = append(bytecode.Instrs, instr...)
+			}
+
+			assertObject(t, object.NewErr(tc.err), vm.New(bytecode).Run())
+		})
+	}
+}
+
+func TestVM_Traceback(t *testing.T) {
+	t.Parallel()
+
+	input := `let inner = fn(x) {
+	x + true
+};
+fn outer() {
+	let z = 1;
+	inner(z)
+}
+outer();`
+
+	program := parser.NewParser(lexer.NewFileLexer("trace.monkey", input)).ParseProgram()
+
+	c := compiler.NewCompiler()
+	assert.Nil(t, c.Compile(program))
+
+	res := vm.New(c.Bytecode()).Run()
+	err, ok := res.(*object.Err)
+	if !assert.True(t, ok, "expected an error, got %v", res) {
+		return
+	}
+
+	expected := `Traceback (most recent call last):
+  trace.monkey:8:1 in <main>
+  trace.monkey:6:2 in outer
+  trace.monkey:2:2 in inner
+ERROR: type error: cannot perform '+' on INTEGER, BOOLEAN`
+	assert.Equal(t, expected, err.Traceback())
+
+	// the interpreter reports the same trace
+	evaled := interpreter.Eval(program, object.NewEnv())
+	assert.Equal(t, expected, evaled.(*object.Err).Traceback())
+}
+
+func TestVM_Budget(t *testing.T) {
+	t.Parallel()
+
+	program := parser.NewParser(lexer.NewLexer("let i = 0; while (true) { i = i + 1 }")).ParseProgram()
+
+	c := compiler.NewCompiler()
+	assert.Nil(t, c.Compile(program))
+
+	budget := object.NewBudget(context.Background(), 500)
+	res := vm.New(c.Bytecode()).SetBudget(budget).Run()
+	assertObject(t, object.NewErrWithKind(object.ERR_STEP_LIMIT, "step limit of 500 exceeded"), res)
+	assert.Equal(t, 501, budget.Steps())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res = vm.New(c.Bytecode()).SetBudget(object.NewBudget(ctx, 0)).Run()
+	assertObject(t, object.NewErrWithKind(object.ERR_CANCELLED, "context canceled"), res)
+}
+
+// writeModules writes the modules imported by the module tests to a temporary
+// directory, returning the directory and the search path to use.
+func writeModules(t *testing.T) (string, string) {
+	t.Helper()
+
+	files := map[string]string{
+		"lib/math.monkey": `
+			let square = fn(x) { x * x };
+			export let double = fn(x) { x * 2 };
+			export fn cube(x) { x * square(x) }
+			export let pi = 3;
+			export let [e, phi] = [2, 1];`,
+		"counter.monkey": `
+			let count = 0;
+			export fn next() { count = count + 1; count }`,
+		"uses_math.monkey": `
+			import { double } from "./lib/math";
+			export let quadruple = fn(x) { double(double(x)) };`,
+		"cycle_a.monkey":     `import "cycle_b" as b;`,
+		"cycle_b.monkey":     `import "cycle_a" as a;`,
+		"bad_syntax.monkey":  `export let x = ;`,
+		"bad_value.monkey":   `export let x = 1 + true;`,
+		"returns.monkey":     `return 1;`,
+		"nested.monkey":      `if (true) { export let y = 1; }`,
+		"vendor/util.monkey": `export let name = "util";`,
+	}
+
+	dir := t.TempDir()
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir, filepath.Join(dir, "vendor")
+}
+
+// runModule runs the program as the file main.monkey in the directory with
+// both engines, returning a compile error or the result of each.
+func runModule(t *testing.T, dir, searchPath, input string) (error, object.Object, object.Object) {
+	t.Helper()
+
+	p := parser.NewParser(lexer.NewFileLexer(filepath.Join(dir, "main.monkey"), input))
+	program := p.ParseProgram()
+	assert.Empty(t, p.Errors())
+
+	env := object.NewEnv().SetImporter(interpreter.NewImporter(module.NewLoader(searchPath)))
+	evaled := interpreter.Eval(program, env)
+
+	c := compiler.NewCompiler().WithLoader(module.NewLoader(searchPath))
+	if err := c.Compile(program); err != nil {
+		return err, nil, evaled
+	}
+
+	return nil, vm.New(c.Bytecode()).Run(), evaled
+}
+
+func TestVM_Modules(t *testing.T) {
+	t.Parallel()
+
+	dir, searchPath := writeModules(t)
+
+	cases := []struct {
+		name   string
+		input  string
+		output object.Object
+	}{
+		{
+			name:   "import as",
+			input:  `import "lib/math.monkey" as m; m.double(2) + m.cube(2) + m.pi`,
+			output: object.NewIntegerObject(15),
+		},
+		{
+			name:   "extension omitted",
+			input:  `import "lib/math" as m; m.pi`,
+			output: object.NewIntegerObject(3),
+		},
+		{
+			name:   "relative to the importing file",
+			input:  `import "./lib/math" as m; m.pi`,
+			output: object.NewIntegerObject(3),
+		},
+		{
+			name:   "selective import",
+			input:  `import { double, cube as c } from "lib/math"; double(c(2))`,
+			output: object.NewIntegerObject(16),
+		},
+		{
+			name:   "destructured exports",
+			input:  `import { e, phi } from "lib/math"; e + phi`,
+			output: object.NewIntegerObject(3),
+		},
+		{
+			name:   "module importing a module",
+			input:  `import { quadruple } from "uses_math"; quadruple(3)`,
+			output: object.NewIntegerObject(12),
+		},
+		{
+			name:   "modules are loaded once",
+			input:  `import "counter" as a; import { next } from "counter"; a.next(); next()`,
+			output: object.NewIntegerObject(2),
+		},
+		{
+			name:   "module names do not leak",
+			input:  `let count = 10; import "counter" as c; c.next(); count`,
+			output: object.NewIntegerObject(10),
+		},
+		{
+			name:   "search path",
+			input:  `import "util" as u; u.name`,
+			output: object.NewStringObject("util"),
+		},
+		{
+			name:   "unexported names are private",
+			input:  `import "lib/math" as m; m.square(2)`,
+			output: object.NewErr("object MODULE has no property square"),
+		},
+		{
+			name:   "error raised by a module",
+			input:  `import "bad_value" as b;`,
+			output: object.NewErr("type error: cannot perform '+' on INTEGER, BOOLEAN"),
+		},
+	}
+
+	for _, testCase := range cases {
+		tc := testCase
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err, res, evaled := runModule(t, dir, searchPath, tc.input)
+			assert.Nil(t, err, "failed to compile: %v", err)
+
+			assertObject(t, tc.output, res)
+			assertObject(t, tc.output, evaled)
+		})
+	}
+}
+
+func TestVM_ModuleErrors(t *testing.T) {
+	t.Parallel()
+
+	dir, searchPath := writeModules(t)
+
+	cases := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{
+			name:  "missing module",
+			input: `import "missing" as m;`,
+			err:   `module "missing.monkey" not found`,
+		},
+		{
+			name:  "import cycle",
+			input: `import "cycle_a" as a;`,
+			err:   "import cycle: " + filepath.Join(dir, "cycle_a.monkey") + " -> " + filepath.Join(dir, "cycle_b.monkey") + " -> " + filepath.Join(dir, "cycle_a.monkey"),
+		},
+		{
+			name:  "syntax error in module",
+			input: `import "bad_syntax" as b;`,
+			err:   "syntax errors in module " + filepath.Join(dir, "bad_syntax.monkey"),
+		},
+		{
+			name:  "unexported selective import",
+			input: `import { square } from "lib/math";`,
+			err:   `module "lib/math" has no export 'square'`,
+		},
+		{
+			name:  "return at the top level of a module",
+			input: `import "returns" as r;`,
+			err:   "'return' outside of function",
+		},
+		{
+			name:  "nested export",
+			input: `import "nested" as n;`,
+			err:   "export must be at the top level of a module",
+		},
+		{
+			name:  "import in a function",
+			input: `let f = fn() { import "counter" as c; }; f()`,
+			err:   "import must be at the top level of a module",
+		},
+	}
+
+	for _, testCase := range cases {
+		tc := testCase
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err, res, evaled := runModule(t, dir, searchPath, tc.input)
+			if err != nil {
+				assert.Contains(t, err.Error(), tc.err)
+			} else if assert.IsType(t, &object.Err{}, res) {
+				assert.Contains(t, res.(*object.Err).Msg, tc.err)
+			}
+
+			if assert.IsType(t, &object.Err{}, evaled) {
+				assert.Contains(t, evaled.(*object.Err).Msg, tc.err)
+			}
+		})
+	}
+}
+
+// assertObject compares results, ignoring where errors were raised.
+func assertObject(t *testing.T, expected, actual object.Object) {
+	t.Helper()
+
+	if err, ok := expected.(*object.Err); ok {
+		actualErr, ok := actual.(*object.Err)
+		if assert.True(t, ok, "expected an error, got %v", actual) {
+			assert.Equal(t, err.Msg, actualErr.Msg)
+		}
+		return
+	}
+
+	assert.Equal(t, expected, actual)
+}
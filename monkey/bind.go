@@ -0,0 +1,211 @@
+package monkey
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/donovandicks/gomonkey/internal/object"
+)
+
+// FIELD_TAG is the struct tag that renames a field for programs. A field
+// tagged `monkey:"-"` is hidden from them.
+const FIELD_TAG = "monkey"
+
+// bindFunc wraps a Go function so that programs can call it. The arguments are
+// converted to the types of its parameters and its results are converted
+// back: a function without results returns null, and one with several returns
+// them as a list. A trailing error result is raised in the program.
+func bindFunc(fn reflect.Value) *object.Builtin {
+	typ := fn.Type()
+
+	numOut := typ.NumOut()
+	fallible := numOut > 0 && typ.Out(numOut-1) == errorType
+	if fallible {
+		numOut -= 1
+	}
+
+	return &object.Builtin{Fn: func(args ...Object) (res Object) {
+		in, err := funcArgs(typ, args)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			if r := recover(); r != nil {
+				res = object.NewErr("Go function panicked: %v", r)
+			}
+		}()
+
+		out := fn.Call(in)
+		if fallible && !out[numOut].IsNil() {
+			return goError(out[numOut].Interface().(error))
+		}
+
+		results := make([]Object, 0, numOut)
+		for _, res := range out[:numOut] {
+			obj, err := toObject(res)
+			if err != nil {
+				return object.NewErrWithKind(object.ERR_TYPE, "%s", err)
+			}
+
+			results = append(results, obj)
+		}
+
+		switch len(results) {
+		case 0:
+			return object.NullObject
+		case 1:
+			return results[0]
+		default:
+			return object.NewListObject(results)
+		}
+	}}
+}
+
+// funcArgs converts the arguments of a call to the parameters of a function of
+// the type.
+func funcArgs(typ reflect.Type, args []Object) ([]reflect.Value, *object.Err) {
+	numIn := typ.NumIn()
+	if typ.IsVariadic() {
+		if len(args) < numIn-1 {
+			return nil, object.NewErrWithKind(object.ERR_TYPE, "wrong number of arguments: want at least %d, got=%d", numIn-1, len(args))
+		}
+	} else if len(args) != numIn {
+		return nil, object.NewErrWithKind(object.ERR_TYPE, "wrong number of arguments: want=%d, got=%d", numIn, len(args))
+	}
+
+	in := make([]reflect.Value, 0, len(args))
+	for i, arg := range args {
+		var param reflect.Type
+		if typ.IsVariadic() && i >= numIn-1 {
+			param = typ.In(numIn - 1).Elem()
+		} else {
+			param = typ.In(i)
+		}
+
+		val, err := fromObject(arg, param)
+		if err != nil {
+			return nil, object.NewErrWithKind(object.ERR_TYPE, "argument %d: %s", i+1, err)
+		}
+
+		in = append(in, val)
+	}
+
+	return in, nil
+}
+
+// goError converts an error returned by a Go function to a Monkey error. An
+// *Error keeps its kind, so that functions can raise a TypeError for example.
+func goError(err error) *object.Err {
+	var monkeyErr *Error
+	if errors.As(err, &monkeyErr) {
+		return object.NewErrWithKind(object.ErrKind(monkeyErr.Kind), "%s", monkeyErr.Message)
+	}
+
+	return object.NewErr("%s", err)
+}
+
+// lookupField finds the exported field of the struct type that programs see
+// under the name.
+func lookupField(typ reflect.Type, name string) (reflect.StructField, bool) {
+	for _, field := range reflect.VisibleFields(typ) {
+		if !field.IsExported() || fieldName(field) == "-" {
+			continue
+		}
+
+		if fieldName(field) == name {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+func fieldName(field reflect.StructField) string {
+	tag, _, _ := strings.Cut(field.Tag.Get(FIELD_TAG), ",")
+	if tag == "" {
+		return field.Name
+	}
+
+	return tag
+}
+
+// structObject exposes a Go struct to programs. Its exported fields and
+// methods are read as properties, and its exported fields can be assigned;
+// methods with pointer receivers modify the struct in place.
+type structObject struct {
+	ptr reflect.Value // a pointer to the struct
+}
+
+func (s *structObject) Type() object.ObjectType { return object.OBJ_INSTANCE }
+func (s *structObject) Inspect() string {
+	typ := s.ptr.Elem().Type()
+
+	fields := []string{}
+	for _, field := range reflect.VisibleFields(typ) {
+		if !field.IsExported() || field.Anonymous || fieldName(field) == "-" {
+			continue
+		}
+
+		val, err := s.field(field)
+		if err != nil {
+			continue
+		}
+
+		fields = append(fields, fmt.Sprintf("%s: %s", fieldName(field), val.Inspect()))
+	}
+
+	return fmt.Sprintf("%s{%s}", typ.Name(), strings.Join(fields, ", "))
+}
+
+// Get returns the named field or method of the struct, or nil if it has none.
+func (s *structObject) Get(key string) Object {
+	if field, ok := lookupField(s.ptr.Elem().Type(), key); ok {
+		val, err := s.field(field)
+		if err != nil {
+			return object.NewErrWithKind(object.ERR_TYPE, "%s", err)
+		}
+
+		return val
+	}
+
+	if method := s.ptr.MethodByName(key); method.IsValid() {
+		return bindFunc(method)
+	}
+
+	return nil
+}
+
+// SetProperty assigns the value, converted to the type of the field, to the
+// named field of the struct.
+func (s *structObject) SetProperty(key string, val Object) Object {
+	field, ok := lookupField(s.ptr.Elem().Type(), key)
+	if !ok {
+		return object.NewErrWithKind(object.ERR_TYPE, "object %s has no field %s", s.Type(), key)
+	}
+
+	dst, err := s.ptr.Elem().FieldByIndexErr(field.Index)
+	if err != nil {
+		return object.NewErrWithKind(object.ERR_TYPE, "cannot assign field %s of a nil embedded struct", key)
+	}
+
+	v, err := fromObject(val, dst.Type())
+	if err != nil {
+		return object.NewErrWithKind(object.ERR_TYPE, "field %s: %s", key, err)
+	}
+
+	dst.Set(v)
+	return val
+}
+
+func (s *structObject) field(field reflect.StructField) (Object, error) {
+	val, err := s.ptr.Elem().FieldByIndexErr(field.Index)
+	if err != nil {
+		// a promoted field of a nil embedded pointer
+		return object.NullObject, nil
+	}
+
+	return toObject(val)
+}
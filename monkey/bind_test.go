@@ -0,0 +1,253 @@
+package monkey_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/donovandicks/gomonkey/monkey"
+	"github.com/stretchr/testify/assert"
+)
+
+type Address struct {
+	City string `monkey:"city"`
+}
+
+type Account struct {
+	Address
+	Owner   string
+	Balance int
+	Tags    []string
+	secret  string
+	Hidden  string `monkey:"-"`
+}
+
+func (a *Account) Deposit(amount int) (int, error) {
+	if amount <= 0 {
+		return 0, fmt.Errorf("invalid deposit amount %d", amount)
+	}
+
+	a.Balance += amount
+	return a.Balance, nil
+}
+
+func (a Account) Describe() string {
+	return fmt.Sprintf("%s has %d", a.Owner, a.Balance)
+}
+
+func TestBind(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		source string
+		output string
+	}{
+		{
+			name:   "typed function",
+			source: `repeat("ab", 3)`,
+			output: "ababab",
+		},
+		{
+			name:   "int arguments accept floats",
+			source: `half(5)`,
+			output: "2.5",
+		},
+		{
+			name:   "slice arguments and results",
+			source: `sum([1, 2, 3])`,
+			output: "6",
+		},
+		{
+			name:   "map arguments",
+			source: `keys({"b": 2, "a": 1})`,
+			output: "[a, b]",
+		},
+		{
+			name:   "variadic functions",
+			source: `join("-", "a", "b", "c")`,
+			output: "a-b-c",
+		},
+		{
+			name:   "multiple results become a list",
+			source: `divmod(7, 2)`,
+			output: "[3, 1]",
+		},
+		{
+			name:   "functions without results return null",
+			source: `noop()`,
+			output: "null",
+		},
+		{
+			name:   "struct fields",
+			source: `account.Owner + " in " + account.city`,
+			output: "ada in London",
+		},
+		{
+			name:   "struct methods",
+			source: `account.Deposit(5); account.Describe()`,
+			output: "ada has 15",
+		},
+		{
+			name:   "struct field assignment",
+			source: `account.Balance = 20; account.Balance += 5; account.city = "Paris"; account.Describe() + " in " + account.city`,
+			output: "ada has 25 in Paris",
+		},
+		{
+			name:   "assigning a field of the wrong type",
+			source: `account.Balance = "lots"`,
+			output: "TypeError: field Balance: cannot convert Monkey value of type STRING to Go type int",
+		},
+		{
+			name:   "assigning a hidden field",
+			source: `account.secret = "x"`,
+			output: "TypeError: object INSTANCE has no field secret",
+		},
+		{
+			name:   "struct slices",
+			source: `(account.Tags)[1]`,
+			output: "vip",
+		},
+		{
+			name:   "structs convert to struct arguments",
+			source: `owner(account)`,
+			output: "ada",
+		},
+		{
+			name:   "maps convert to struct arguments",
+			source: `owner({"Owner": "grace", "city": "Paris"})`,
+			output: "grace",
+		},
+		{
+			name:   "wrong argument types",
+			source: `repeat(3, "ab")`,
+			output: "TypeError: argument 1: cannot convert Monkey value of type INTEGER to Go type string",
+		},
+		{
+			name:   "wrong number of arguments",
+			source: `repeat("ab")`,
+			output: "TypeError: wrong number of arguments: want=2, got=1",
+		},
+		{
+			name:   "integers that overflow the parameter",
+			source: `small(300)`,
+			output: "TypeError: argument 1: cannot convert Monkey value of type INTEGER to Go type int8",
+		},
+		{
+			name:   "Go errors",
+			source: `account.Deposit(-1)`,
+			output: "Error: invalid deposit amount -1",
+		},
+		{
+			name:   "Go errors keep their kind",
+			source: `lookup("missing")`,
+			output: "KeyError: no such user missing",
+		},
+		{
+			name:   "unexported fields are hidden",
+			source: `account.secret`,
+			output: "TypeError: object INSTANCE has no property secret",
+		},
+		{
+			name:   "tagged fields are hidden",
+			source: `account.Hidden`,
+			output: "TypeError: object INSTANCE has no property Hidden",
+		},
+	}
+
+	for _, testCase := range cases {
+		tc := testCase
+
+		for _, backend := range backends {
+			backend := backend
+
+			t.Run(fmt.Sprintf("%s/%s", backend, tc.name), func(t *testing.T) {
+				t.Parallel()
+
+				engine := monkey.NewEngine(monkey.WithBackend(backend))
+				funcs := map[string]any{
+					"repeat": strings.Repeat,
+					"half":   func(n float64) float64 { return n / 2 },
+					"sum": func(nums []int) int {
+						total := 0
+						for _, n := range nums {
+							total += n
+						}
+						return total
+					},
+					"keys": func(m map[string]int) []string {
+						if len(m) == 2 {
+							return []string{"a", "b"}
+						}
+						return nil
+					},
+					"join":   func(sep string, parts ...string) string { return strings.Join(parts, sep) },
+					"divmod": func(a, b int) (int, int) { return a / b, a % b },
+					"noop":   func() {},
+					"small":  func(n int8) int8 { return n },
+					"owner":  func(a Account) string { return a.Owner },
+					"lookup": func(name string) (string, error) {
+						return "", &monkey.Error{Kind: "KeyError", Message: "no such user " + name}
+					},
+				}
+				for name, fn := range funcs {
+					assert.Nil(t, engine.Register(name, fn))
+				}
+
+				prog, err := engine.Compile(tc.source)
+				if !assert.Nil(t, err) {
+					return
+				}
+
+				account := &Account{
+					Address: Address{City: "London"},
+					Owner:   "ada",
+					Balance: 10,
+					Tags:    []string{"new", "vip"},
+				}
+
+				res, err := engine.Run(context.Background(), prog, map[string]any{"account": account})
+				if err != nil {
+					assert.Equal(t, tc.output, err.Error())
+					return
+				}
+
+				assert.Equal(t, tc.output, res.Inspect())
+			})
+		}
+	}
+}
+
+func TestBind_Struct(t *testing.T) {
+	t.Parallel()
+
+	engine := monkey.NewEngine()
+	account := &Account{Owner: "ada", Balance: 10}
+
+	prog, err := engine.Compile(`account.Deposit(32); account`)
+	assert.Nil(t, err)
+
+	res, err := engine.Run(context.Background(), prog, map[string]any{"account": account})
+	assert.Nil(t, err)
+	assert.Equal(t, 42, account.Balance)
+	assert.Equal(t, `Account{city: , Owner: ada, Balance: 42, Tags: null}`, res.Inspect())
+
+	val, err := monkey.FromObject(res)
+	assert.Nil(t, err)
+	assert.Same(t, account, val)
+
+	err = engine.Register("ch", make(chan int))
+	assert.EqualError(t, err, "cannot convert Go value of type chan int to a Monkey value")
+
+	var monkeyErr *monkey.Error
+	prog, err = engine.Compile(`fail()`)
+	assert.Nil(t, err)
+	assert.Nil(t, engine.Register("fail", func() error { return errors.New("boom") }))
+	_, err = engine.Run(context.Background(), prog, nil)
+	if assert.ErrorAs(t, err, &monkeyErr) {
+		assert.Equal(t, "Error", monkeyErr.Kind)
+		assert.Equal(t, "boom", monkeyErr.Message)
+	}
+}
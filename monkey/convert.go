@@ -0,0 +1,406 @@
+package monkey
+
+import (
+	"reflect"
+
+	"github.com/donovandicks/gomonkey/internal/object"
+)
+
+// Object is a value in a Monkey program.
+type Object = object.Object
+
+// Func is a Go function that programs can call. Its arguments and result are
+// converted with FromObject and ToObject, and an error it returns is raised in
+// the program.
+type Func func(args ...any) (any, error)
+
+var (
+	objectType = reflect.TypeOf((*Object)(nil)).Elem()
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// ToObject converts a Go value to the Monkey value it corresponds to.
+//
+// Numbers, strings and bools become the matching Monkey value, slices and
+// arrays become lists, and maps become maps. Functions become builtins that
+// convert their arguments and results, and raise the error they return, if
+// any. Structs become objects whose exported fields and methods are read as
+// properties.
+func ToObject(val any) (Object, error) {
+	if obj, ok := val.(Object); ok {
+		return obj, nil
+	}
+
+	return toObject(reflect.ValueOf(val))
+}
+
+func toObject(val reflect.Value) (Object, error) {
+	if !val.IsValid() {
+		return object.NullObject, nil
+	}
+
+	if val.Type().Implements(objectType) && val.CanInterface() && !isNil(val) {
+		return val.Interface().(Object), nil
+	}
+
+	switch val.Kind() {
+	case reflect.Bool:
+		return object.BoolFromNative(val.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return object.NewIntegerObject(val.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if val.Uint() > uint64(1<<63-1) {
+			return nil, ErrUnsupportedType{typ: val.Type()}
+		}
+
+		return object.NewIntegerObject(int64(val.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return object.NewFloatObject(val.Float()), nil
+	case reflect.String:
+		return object.NewStringObject(val.String()), nil
+	case reflect.Slice, reflect.Array:
+		if val.Kind() == reflect.Slice && val.IsNil() {
+			return object.NullObject, nil
+		}
+
+		elems := make([]Object, 0, val.Len())
+		for i := 0; i < val.Len(); i++ {
+			obj, err := toObject(val.Index(i))
+			if err != nil {
+				return nil, err
+			}
+
+			elems = append(elems, obj)
+		}
+
+		return object.NewListObject(elems), nil
+	case reflect.Map:
+		if val.IsNil() {
+			return object.NullObject, nil
+		}
+
+		pairs := make(map[object.HashKey]object.KVPair, val.Len())
+		iter := val.MapRange()
+		for iter.Next() {
+			key, err := toObject(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+
+			hashable, ok := key.(object.HashableObject)
+			if !ok {
+				return nil, ErrUnsupportedType{typ: val.Type()}
+			}
+
+			obj, err := toObject(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+
+			pairs[hashable.Hash()] = object.NewKVPair(key, obj)
+		}
+
+		return &object.Map{Entries: pairs}, nil
+	case reflect.Func:
+		if val.IsNil() {
+			return object.NullObject, nil
+		}
+
+		return bindFunc(val), nil
+	case reflect.Struct:
+		// copy the struct so that its pointer methods can be called
+		ptr := reflect.New(val.Type())
+		ptr.Elem().Set(val)
+		return &structObject{ptr: ptr}, nil
+	case reflect.Pointer:
+		if val.IsNil() {
+			return object.NullObject, nil
+		}
+
+		if val.Elem().Kind() == reflect.Struct {
+			return &structObject{ptr: val}, nil
+		}
+
+		return toObject(val.Elem())
+	case reflect.Interface:
+		return toObject(val.Elem())
+	default:
+		return nil, ErrUnsupportedType{typ: val.Type()}
+	}
+}
+
+// FromObject converts a Monkey value to the Go value it corresponds to: nil,
+// a bool, an int64, a float64, a string, a []any or a map[string]any. Objects
+// that wrap a Go struct are returned as the pointer to the struct.
+func FromObject(obj Object) (any, error) {
+	return converting{}.toAny(obj)
+}
+
+// fromObject converts a Monkey value to a Go value of the type.
+func fromObject(obj Object, typ reflect.Type) (reflect.Value, error) {
+	return converting{}.toType(obj, typ)
+}
+
+// converting holds the lists and maps being converted to Go values, which
+// cannot contain themselves.
+type converting map[Object]bool
+
+// enter marks the container as being converted, failing if it already is
+// because it contains itself.
+func (c converting) enter(obj Object) error {
+	if c[obj] {
+		return ErrCyclic{obj: obj}
+	}
+
+	c[obj] = true
+	return nil
+}
+
+func (c converting) leave(obj Object) {
+	delete(c, obj)
+}
+
+func (c converting) toAny(obj Object) (any, error) {
+	if obj == nil || obj == object.NullObject {
+		return nil, nil
+	}
+
+	switch obj := obj.(type) {
+	case *object.Boolean:
+		return obj.Value, nil
+	case *object.Integer:
+		return obj.Value, nil
+	case *object.Float:
+		return obj.Value, nil
+	case *object.String:
+		return obj.Value, nil
+	case *object.List:
+		if err := c.enter(obj); err != nil {
+			return nil, err
+		}
+		defer c.leave(obj)
+
+		elems := make([]any, 0, len(obj.Elems))
+		for _, elem := range obj.Elems {
+			val, err := c.toAny(elem)
+			if err != nil {
+				return nil, err
+			}
+
+			elems = append(elems, val)
+		}
+
+		return elems, nil
+	case *object.Map:
+		if err := c.enter(obj); err != nil {
+			return nil, err
+		}
+		defer c.leave(obj)
+
+		entries := make(map[string]any, len(obj.Entries))
+		for _, pair := range obj.Entries {
+			key, ok := pair.Key.(*object.String)
+			if !ok {
+				return nil, ErrUnconvertible{obj: obj}
+			}
+
+			val, err := c.toAny(pair.Value)
+			if err != nil {
+				return nil, err
+			}
+
+			entries[key.Value] = val
+		}
+
+		return entries, nil
+	case *structObject:
+		return obj.ptr.Interface(), nil
+	default:
+		return nil, ErrUnconvertible{obj: obj}
+	}
+}
+
+func (c converting) toType(obj Object, typ reflect.Type) (reflect.Value, error) {
+	if typ == objectType {
+		return reflect.ValueOf(&obj).Elem(), nil
+	}
+
+	if obj == object.NullObject {
+		switch typ.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Slice, reflect.Map, reflect.Func:
+			return reflect.Zero(typ), nil
+		}
+	}
+
+	if st, ok := obj.(*structObject); ok {
+		if st.ptr.Type().AssignableTo(typ) {
+			return st.ptr, nil
+		}
+
+		if st.ptr.Elem().Type().AssignableTo(typ) {
+			return st.ptr.Elem(), nil
+		}
+	}
+
+	fail := ErrUnconvertible{obj: obj, typ: typ}
+	val := reflect.New(typ).Elem()
+
+	switch typ.Kind() {
+	case reflect.Interface:
+		res, err := c.toAny(obj)
+		if err != nil {
+			return val, err
+		}
+
+		if res == nil || !reflect.TypeOf(res).AssignableTo(typ) {
+			return val, fail
+		}
+
+		val.Set(reflect.ValueOf(res))
+	case reflect.Bool:
+		b, ok := obj.(*object.Boolean)
+		if !ok {
+			return val, fail
+		}
+
+		val.SetBool(b.Value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := obj.(*object.Integer)
+		if !ok || val.OverflowInt(i.Value) {
+			return val, fail
+		}
+
+		val.SetInt(i.Value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, ok := obj.(*object.Integer)
+		if !ok || i.Value < 0 || val.OverflowUint(uint64(i.Value)) {
+			return val, fail
+		}
+
+		val.SetUint(uint64(i.Value))
+	case reflect.Float32, reflect.Float64:
+		switch num := obj.(type) {
+		case *object.Float:
+			val.SetFloat(num.Value)
+		case *object.Integer:
+			val.SetFloat(float64(num.Value))
+		default:
+			return val, fail
+		}
+	case reflect.String:
+		s, ok := obj.(*object.String)
+		if !ok {
+			return val, fail
+		}
+
+		val.SetString(s.Value)
+	case reflect.Slice, reflect.Array:
+		list, ok := obj.(*object.List)
+		if !ok {
+			return val, fail
+		}
+
+		if typ.Kind() == reflect.Array && typ.Len() != len(list.Elems) {
+			return val, fail
+		}
+
+		if err := c.enter(list); err != nil {
+			return val, err
+		}
+		defer c.leave(list)
+
+		if typ.Kind() == reflect.Slice {
+			val.Set(reflect.MakeSlice(typ, len(list.Elems), len(list.Elems)))
+		}
+
+		for i, elem := range list.Elems {
+			v, err := c.toType(elem, typ.Elem())
+			if err != nil {
+				return val, err
+			}
+
+			val.Index(i).Set(v)
+		}
+	case reflect.Map:
+		m, ok := obj.(*object.Map)
+		if !ok {
+			return val, fail
+		}
+
+		if err := c.enter(m); err != nil {
+			return val, err
+		}
+		defer c.leave(m)
+
+		val.Set(reflect.MakeMapWithSize(typ, len(m.Entries)))
+		for _, pair := range m.Entries {
+			k, err := c.toType(pair.Key, typ.Key())
+			if err != nil {
+				return val, err
+			}
+
+			v, err := c.toType(pair.Value, typ.Elem())
+			if err != nil {
+				return val, err
+			}
+
+			val.SetMapIndex(k, v)
+		}
+	case reflect.Struct:
+		m, ok := obj.(*object.Map)
+		if !ok {
+			return val, fail
+		}
+
+		if err := c.enter(m); err != nil {
+			return val, err
+		}
+		defer c.leave(m)
+
+		for _, pair := range m.Entries {
+			key, ok := pair.Key.(*object.String)
+			if !ok {
+				return val, fail
+			}
+
+			field, ok := lookupField(typ, key.Value)
+			if !ok {
+				return val, fail
+			}
+
+			v, err := c.toType(pair.Value, field.Type)
+			if err != nil {
+				return val, err
+			}
+
+			dst, err := val.FieldByIndexErr(field.Index)
+			if err != nil {
+				return val, fail
+			}
+
+			dst.Set(v)
+		}
+	case reflect.Pointer:
+		elem, err := c.toType(obj, typ.Elem())
+		if err != nil {
+			return val, err
+		}
+
+		ptr := reflect.New(typ.Elem())
+		ptr.Elem().Set(elem)
+		val.Set(ptr)
+	default:
+		return val, fail
+	}
+
+	return val, nil
+}
+
+func isNil(val reflect.Value) bool {
+	switch val.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func, reflect.Chan:
+		return val.IsNil()
+	default:
+		return false
+	}
+}
@@ -0,0 +1,100 @@
+package monkey_test
+
+import (
+	"context"
+
+	"testing"
+
+	"github.com/donovandicks/gomonkey/monkey"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToObject(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		value any
+		repr  string
+	}{
+		{"nil", nil, "null"},
+		{"bool", true, "true"},
+		{"int", 42, "42"},
+		{"uint8", uint8(7), "7"},
+		{"float", 1.5, "1.5"},
+		{"string", "hi", "hi"},
+		{"slice", []any{1, "a", nil}, "[1, a, null]"},
+		{"map", map[string]any{"k": []any{false}}, "{k:[false]}"},
+		{"func", monkey.Func(func(args ...any) (any, error) { return nil, nil }), "builtin"},
+	}
+
+	for _, testCase := range cases {
+		tc := testCase
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			obj, err := monkey.ToObject(tc.value)
+			if assert.Nil(t, err) {
+				assert.Equal(t, tc.repr, obj.Inspect())
+			}
+		})
+	}
+
+	_, err := monkey.ToObject([]any{make(chan int)})
+	assert.EqualError(t, err, "cannot convert Go value of type chan int to a Monkey value")
+}
+
+func TestFromObject(t *testing.T) {
+	t.Parallel()
+
+	values := []any{
+		nil,
+		true,
+		int64(-3),
+		2.25,
+		"text",
+		[]any{int64(1), []any{"nested"}},
+		map[string]any{"a": int64(1), "b": map[string]any{"c": nil}},
+	}
+
+	for _, val := range values {
+		obj, err := monkey.ToObject(val)
+		assert.Nil(t, err)
+
+		back, err := monkey.FromObject(obj)
+		assert.Nil(t, err)
+		assert.Equal(t, val, back)
+	}
+
+	engine := monkey.NewEngine()
+	prog, err := engine.Compile(`{1: "int key"}`)
+	assert.Nil(t, err)
+
+	obj, err := engine.Run(context.Background(), prog, nil)
+	assert.Nil(t, err)
+
+	_, err = monkey.FromObject(obj)
+	assert.EqualError(t, err, "cannot convert Monkey value of type MAP to a Go value")
+
+	for _, input := range []string{`let a = [0]; a[0] = a; a`, `let m = {}; m["self"] = [m]; m`} {
+		prog, err := engine.Compile(input)
+		assert.Nil(t, err)
+
+		obj, err := engine.Run(context.Background(), prog, nil)
+		assert.Nil(t, err)
+
+		_, err = monkey.FromObject(obj)
+		assert.ErrorContains(t, err, "it contains itself", input)
+	}
+
+	prog, err = engine.Compile(`let b = [1]; [b, b]`)
+	assert.Nil(t, err)
+
+	obj, err = engine.Run(context.Background(), prog, nil)
+	assert.Nil(t, err)
+
+	back, err := monkey.FromObject(obj)
+	assert.Nil(t, err)
+	assert.Equal(t, []any{[]any{int64(1)}, []any{int64(1)}}, back)
+}
@@ -0,0 +1,256 @@
+// Package monkey embeds the Monkey language in Go programs.
+//
+// An Engine compiles source into a Program, which can then be run any number
+// of times with different globals:
+//
+//	engine := monkey.NewEngine()
+//	engine.Register("upper", strings.ToUpper)
+//
+//	prog, err := engine.Compile(`upper(name)`)
+//	...
+//	res, err := engine.Run(ctx, prog, map[string]any{"name": "monkey"})
+package monkey
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/donovandicks/gomonkey/internal/ast"
+	"github.com/donovandicks/gomonkey/internal/compiler"
+	"github.com/donovandicks/gomonkey/internal/interpreter"
+	"github.com/donovandicks/gomonkey/internal/lexer"
+	"github.com/donovandicks/gomonkey/internal/module"
+	"github.com/donovandicks/gomonkey/internal/object"
+	"github.com/donovandicks/gomonkey/internal/parser"
+	"github.com/donovandicks/gomonkey/internal/vm"
+)
+
+// Backend is the means by which an engine runs programs.
+type Backend string
+
+const (
+	BACKEND_INTERPRETER Backend = "interpreter" // walk the syntax tree
+	BACKEND_VM          Backend = "vm"          // compile to bytecode and run it on a VM
+)
+
+type Option func(*Engine)
+
+func WithBackend(backend Backend) Option {
+	return func(e *Engine) { e.backend = backend }
+}
+
+// WithSearchPath sets the directories searched for imported modules, in place
+// of those listed in MONKEY_PATH.
+func WithSearchPath(dirs ...string) Option {
+	return func(e *Engine) { e.searchPath = dirs }
+}
+
+// WithStepLimit stops programs that take more than the number of steps, with
+// an ErrStepLimit. A step is an evaluated node of the syntax tree, or an
+// instruction executed by the VM.
+func WithStepLimit(steps int) Option {
+	return func(e *Engine) { e.maxSteps = steps }
+}
+
+// WithMaxDepth sets the number of nested calls programs may make, beyond which
+// a call raises a RecursionError. The default is 1000.
+func WithMaxDepth(depth int) Option {
+	return func(e *Engine) { e.maxDepth = depth }
+}
+
+// Engine compiles and runs programs. Functions must be registered before the
+// engine starts running programs, after which it may run any number of them
+// concurrently.
+type Engine struct {
+	backend    Backend
+	searchPath []string
+	maxSteps   int
+	maxDepth   int
+	builtins   map[string]any
+}
+
+func NewEngine(opts ...Option) *Engine {
+	e := &Engine{
+		backend:    BACKEND_INTERPRETER,
+		searchPath: module.SearchPath(),
+		maxDepth:   object.MAX_CALL_DEPTH,
+		builtins:   make(map[string]any),
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// Register makes the Go value available to programs under the name. It is
+// converted with ToObject, so functions become callable and structs expose
+// their fields and methods. It is converted again for every run, so that runs
+// do not see each other's changes to it.
+func (e *Engine) Register(name string, val any) error {
+	if _, err := ToObject(val); err != nil {
+		return err
+	}
+
+	e.builtins[name] = val
+	return nil
+}
+
+// Program is a parsed program, ready to be run by the engine that compiled it.
+type Program struct {
+	prog *ast.Program
+
+	mu       sync.Mutex
+	bytecode map[string]*compiledProgram // keyed by the names of the globals
+}
+
+// compiledProgram is a program compiled for the VM, along with the indexes of
+// the globals it expects the host to set.
+type compiledProgram struct {
+	bytecode *compiler.Bytecode
+	globals  map[string]int
+}
+
+// Compile parses the source of a program.
+func (e *Engine) Compile(source string) (*Program, error) {
+	return e.compile("", source)
+}
+
+// CompileFile parses the program in the file. Modules it imports are looked up
+// relative to the file.
+func (e *Engine) CompileFile(path string) (*Program, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.compile(path, string(source))
+}
+
+func (e *Engine) compile(file, source string) (*Program, error) {
+	p := parser.NewParser(lexer.NewFileLexer(file, source))
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		return nil, &SyntaxError{Diagnostics: errs}
+	}
+
+	return &Program{prog: prog, bytecode: make(map[string]*compiledProgram)}, nil
+}
+
+// Run runs the program with the globals, converted with ToObject, and returns
+// the value it produced. An error raised and not caught by the program is
+// returned as an *Error.
+//
+// The program is stopped once the context is done, returning the context's
+// error, or once it exceeds the engine's step limit, returning an
+// ErrStepLimit. Programs see either as an error that they can catch, but that
+// is raised again by every step they take afterwards.
+//
+// A panic while running the program, including one in a registered Go
+// function, is returned as an ErrPanic.
+func (e *Engine) Run(ctx context.Context, prog *Program, globals map[string]any) (res Object, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			res, err = nil, ErrPanic{val: r}
+		}
+	}()
+
+	return e.run(ctx, prog, globals)
+}
+
+func (e *Engine) run(ctx context.Context, prog *Program, globals map[string]any) (Object, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	vals := make(map[string]Object, len(e.builtins)+len(globals))
+	for _, src := range []map[string]any{e.builtins, globals} {
+		for name, val := range src {
+			obj, err := ToObject(val)
+			if err != nil {
+				return nil, err
+			}
+
+			vals[name] = obj
+		}
+	}
+
+	budget := object.NewBudget(ctx, e.maxSteps).WithMaxDepth(e.maxDepth)
+
+	var res Object
+	switch e.backend {
+	case BACKEND_VM:
+		compiled, err := e.compileBytecode(prog, vals)
+		if err != nil {
+			return nil, err
+		}
+
+		values := make([]Object, len(compiled.globals))
+		for name, idx := range compiled.globals {
+			values[idx] = vals[name]
+		}
+
+		res = vm.NewWithGlobals(compiled.bytecode, values).SetBudget(budget).Run()
+	default:
+		env := object.NewEnv().With(vals).SetBudget(budget)
+		env.SetImporter(interpreter.NewImporter(module.NewLoader(e.searchPath...)).WithBudget(budget))
+
+		res = interpreter.Eval(prog.prog, env)
+	}
+
+	if stop := budget.Err(); stop != nil {
+		if stop.Kind == object.ERR_STEP_LIMIT {
+			return nil, ErrStepLimit{steps: e.maxSteps}
+		}
+
+		return nil, ctx.Err()
+	}
+
+	if err, ok := res.(*object.Err); ok {
+		return nil, &Error{Kind: string(err.Kind), Message: err.Msg, Traceback: err.Traceback()}
+	}
+
+	if res == nil {
+		return object.NullObject, nil
+	}
+
+	return res, nil
+}
+
+// compileBytecode compiles the program for the VM, reusing the bytecode from
+// earlier runs with globals of the same names.
+func (e *Engine) compileBytecode(prog *Program, vals map[string]Object) (*compiledProgram, error) {
+	names := make([]string, 0, len(vals))
+	for name := range vals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	key := strings.Join(names, ",")
+
+	prog.mu.Lock()
+	defer prog.mu.Unlock()
+
+	if compiled, ok := prog.bytecode[key]; ok {
+		return compiled, nil
+	}
+
+	c := compiler.NewCompiler().WithLoader(module.NewLoader(e.searchPath...))
+
+	compiled := &compiledProgram{globals: make(map[string]int, len(names))}
+	for _, name := range names {
+		compiled.globals[name] = c.DefineGlobal(name)
+	}
+
+	if err := c.Compile(prog.prog); err != nil {
+		return nil, err
+	}
+
+	compiled.bytecode = c.Bytecode()
+	prog.bytecode[key] = compiled
+	return compiled, nil
+}
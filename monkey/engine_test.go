@@ -0,0 +1,297 @@
+package monkey_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donovandicks/gomonkey/monkey"
+	"github.com/stretchr/testify/assert"
+)
+
+var backends = []monkey.Backend{monkey.BACKEND_INTERPRETER, monkey.BACKEND_VM}
+
+func TestEngine_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		source  string
+		globals map[string]any
+		output  any
+	}{
+		{
+			name:   "no globals",
+			source: "1 + 2",
+			output: int64(3),
+		},
+		{
+			name:    "globals",
+			source:  `if (order["total"] > limit) { "review" } else { "approve" }`,
+			globals: map[string]any{"order": map[string]any{"total": 120}, "limit": 100},
+			output:  "review",
+		},
+		{
+			name:    "registered function",
+			source:  `upper(name) + "!"`,
+			globals: map[string]any{"name": "monkey"},
+			output:  "MONKEY!",
+		},
+		{
+			name:    "globals shadow registered functions",
+			source:  `upper`,
+			globals: map[string]any{"upper": 1},
+			output:  int64(1),
+		},
+		{
+			name:   "list result",
+			source: `[1, "two", [3.5, true]]`,
+			output: []any{int64(1), "two", []any{3.5, true}},
+		},
+		{
+			name:   "registered function errors are catchable",
+			source: `try { fail() } catch (e) { e.message }`,
+			output: "rule failed",
+		},
+		{
+			name:   "registered function panics are catchable",
+			source: `try { boom() } catch (e) { e.message }`,
+			output: "Go function panicked: boom",
+		},
+	}
+
+	for _, testCase := range cases {
+		tc := testCase
+
+		for _, backend := range backends {
+			backend := backend
+
+			t.Run(fmt.Sprintf("%s/%s", backend, tc.name), func(t *testing.T) {
+				t.Parallel()
+
+				engine := monkey.NewEngine(monkey.WithBackend(backend))
+				engine.Register("upper", func(args ...any) (any, error) {
+					return strings.ToUpper(args[0].(string)), nil
+				})
+				engine.Register("fail", func(args ...any) (any, error) {
+					return nil, errors.New("rule failed")
+				})
+				engine.Register("boom", func() { panic("boom") })
+
+				prog, err := engine.Compile(tc.source)
+				if !assert.Nil(t, err) {
+					return
+				}
+
+				res, err := engine.Run(context.Background(), prog, tc.globals)
+				if backend == monkey.BACKEND_VM && strings.Contains(tc.source, "try") {
+					// the compiler does not support try statements
+					assert.Error(t, err)
+					return
+				}
+
+				if !assert.Nil(t, err) {
+					return
+				}
+
+				val, err := monkey.FromObject(res)
+				assert.Nil(t, err)
+				assert.Equal(t, tc.output, val)
+			})
+		}
+	}
+}
+
+func TestEngine_Errors(t *testing.T) {
+	t.Parallel()
+
+	for _, backend := range backends {
+		engine := monkey.NewEngine(monkey.WithBackend(backend))
+
+		_, err := engine.Compile("let x = ;")
+		var syntaxErr *monkey.SyntaxError
+		if assert.ErrorAs(t, err, &syntaxErr) {
+			assert.Equal(t, "1:9: expected an expression, got ; instead", syntaxErr.Error())
+			assert.Len(t, syntaxErr.Diagnostics, 1)
+		}
+
+		prog, err := engine.Compile("[1, 2][x]")
+		assert.Nil(t, err)
+
+		_, err = engine.Run(context.Background(), prog, map[string]any{"x": 5})
+		var runErr *monkey.Error
+		if assert.ErrorAs(t, err, &runErr, "backend %s", backend) {
+			assert.Equal(t, "IndexError", runErr.Kind)
+			assert.Equal(t, "index out of bounds: 5", runErr.Message)
+			assert.Contains(t, runErr.Traceback, "1:1 in <main>")
+		}
+
+		_, err = engine.Run(context.Background(), prog, map[string]any{"x": make(chan int)})
+		assert.EqualError(t, err, "cannot convert Go value of type chan int to a Monkey value")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = engine.Run(ctx, prog, nil)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		prog, err = engine.Compile("let f = fn(a, b) { a }; f(1)")
+		assert.Nil(t, err)
+
+		_, err = engine.Run(context.Background(), prog, nil)
+		if assert.ErrorAs(t, err, &runErr, "backend %s", backend) {
+			assert.Equal(t, "TypeError", runErr.Kind)
+			assert.Equal(t, "wrong number of arguments: want=2, got=1", runErr.Message)
+		}
+	}
+}
+
+func TestEngine_Panics(t *testing.T) {
+	t.Parallel()
+
+	for _, backend := range backends {
+		engine := monkey.NewEngine(monkey.WithBackend(backend))
+		engine.Register("boom", func() { panic("boom") })
+
+		prog, err := engine.Compile("boom()")
+		assert.Nil(t, err)
+
+		_, err = engine.Run(context.Background(), prog, nil)
+		var runErr *monkey.Error
+		if assert.ErrorAs(t, err, &runErr, "backend %s", backend) {
+			assert.Equal(t, "Go function panicked: boom", runErr.Message)
+		}
+	}
+}
+
+func TestEngine_Limits(t *testing.T) {
+	t.Parallel()
+
+	for _, backend := range backends {
+		engine := monkey.NewEngine(monkey.WithBackend(backend), monkey.WithStepLimit(10000))
+
+		prog, err := engine.Compile(`while (true) {}`)
+		assert.Nil(t, err)
+
+		_, err = engine.Run(context.Background(), prog, nil)
+		var stepErr monkey.ErrStepLimit
+		assert.ErrorAs(t, err, &stepErr)
+		assert.EqualError(t, err, "program exceeded the step limit of 10000")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		_, err = monkey.NewEngine(monkey.WithBackend(backend)).Run(ctx, prog, nil)
+		cancel()
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		prog, err = engine.Compile(`let n = 0; while (n < 10) { n = n + 1 } n`)
+		assert.Nil(t, err)
+
+		res, err := engine.Run(context.Background(), prog, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, "10", res.Inspect())
+	}
+
+	for _, backend := range backends {
+		engine := monkey.NewEngine(monkey.WithBackend(backend), monkey.WithMaxDepth(10))
+
+		prog, err := engine.Compile(`let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } }; f(depth)`)
+		assert.Nil(t, err)
+
+		_, err = engine.Run(context.Background(), prog, map[string]any{"depth": 9})
+		assert.Nil(t, err)
+
+		_, err = engine.Run(context.Background(), prog, map[string]any{"depth": 10})
+		var monkeyErr *monkey.Error
+		if assert.ErrorAs(t, err, &monkeyErr) {
+			assert.Equal(t, "RecursionError", monkeyErr.Kind)
+			assert.Equal(t, "maximum recursion depth exceeded", monkeyErr.Message)
+		}
+	}
+
+	// a program that catches the error is still stopped
+	engine := monkey.NewEngine(monkey.WithStepLimit(100))
+	prog, err := engine.Compile(`try { while (true) {} } catch (e) {} "done"`)
+	assert.Nil(t, err)
+
+	_, err = engine.Run(context.Background(), prog, nil)
+	assert.ErrorAs(t, err, &monkey.ErrStepLimit{})
+}
+
+func TestEngine_CompileFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "rates.monkey"), []byte(`export let rate = 2;`), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "main.monkey"), []byte(`import { rate } from "rates"; amount * rate`), 0o644))
+
+	for _, backend := range backends {
+		engine := monkey.NewEngine(monkey.WithBackend(backend))
+
+		prog, err := engine.CompileFile(filepath.Join(dir, "main.monkey"))
+		assert.Nil(t, err)
+
+		res, err := engine.Run(context.Background(), prog, map[string]any{"amount": 21})
+		assert.Nil(t, err)
+		assert.Equal(t, "42", res.Inspect())
+	}
+}
+
+func TestEngine_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	for _, backend := range backends {
+		engine := monkey.NewEngine(monkey.WithBackend(backend))
+
+		prog, err := engine.Compile(`let f = fn(n) { if (n < 2) { n } else { f(n - 1) + f(n - 2) } }; f(n)`)
+		assert.Nil(t, err)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				res, err := engine.Run(context.Background(), prog, map[string]any{"n": 10})
+				assert.Nil(t, err)
+				assert.Equal(t, "55", res.Inspect())
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+func TestEngine_RegisteredValues(t *testing.T) {
+	t.Parallel()
+
+	for _, backend := range backends {
+		engine := monkey.NewEngine(monkey.WithBackend(backend))
+		assert.Nil(t, engine.Register("cfg", map[string]any{"n": 0, "tags": []any{"a"}}))
+
+		prog, err := engine.Compile(`cfg["n"] += 1; cfg["tags"][0] += "!"; str(cfg)`)
+		assert.Nil(t, err)
+
+		for i := 0; i < 3; i++ {
+			res, err := engine.Run(context.Background(), prog, nil)
+			assert.Nil(t, err, "backend %s", backend)
+			assert.Equal(t, "{n:1, tags:[a!]}", res.Inspect(), "backend %s", backend)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				res, err := engine.Run(context.Background(), prog, nil)
+				assert.Nil(t, err)
+				assert.Equal(t, "{n:1, tags:[a!]}", res.Inspect())
+			}()
+		}
+		wg.Wait()
+	}
+}
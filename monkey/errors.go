@@ -0,0 +1,87 @@
+package monkey
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/donovandicks/gomonkey/internal/diagnostic"
+)
+
+// Diagnostic describes a problem found in the source of a program.
+type Diagnostic = diagnostic.Diagnostic
+
+// SyntaxError reports the problems found while parsing a program.
+type SyntaxError struct {
+	Diagnostics []Diagnostic
+}
+
+func (e *SyntaxError) Error() string {
+	msgs := make([]string, 0, len(e.Diagnostics))
+	for _, diag := range e.Diagnostics {
+		msgs = append(msgs, diag.Error())
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+// Error is an error raised by a program that the program did not catch.
+type Error struct {
+	Kind      string
+	Message   string
+	Traceback string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Kind, e.Message)
+}
+
+// ErrStepLimit is returned when a program takes more steps than the engine
+// allows.
+type ErrStepLimit struct {
+	steps int
+}
+
+func (e ErrStepLimit) Error() string {
+	return fmt.Sprintf("program exceeded the step limit of %d", e.steps)
+}
+
+// ErrPanic is returned when running a program panics.
+type ErrPanic struct {
+	val any
+}
+
+func (e ErrPanic) Error() string {
+	return fmt.Sprintf("program panicked: %v", e.val)
+}
+
+type ErrUnsupportedType struct {
+	typ reflect.Type
+}
+
+func (e ErrUnsupportedType) Error() string {
+	return fmt.Sprintf("cannot convert Go value of type %s to a Monkey value", e.typ)
+}
+
+type ErrUnconvertible struct {
+	obj Object
+	typ reflect.Type // the Go type the value was converted to, if any
+}
+
+func (e ErrUnconvertible) Error() string {
+	if e.typ == nil {
+		return fmt.Sprintf("cannot convert Monkey value of type %s to a Go value", e.obj.Type())
+	}
+
+	return fmt.Sprintf("cannot convert Monkey value of type %s to Go type %s", e.obj.Type(), e.typ)
+}
+
+// ErrCyclic is returned when converting a Monkey list or map that contains
+// itself, which has no Go value.
+type ErrCyclic struct {
+	obj Object
+}
+
+func (e ErrCyclic) Error() string {
+	return fmt.Sprintf("cannot convert Monkey value of type %s to a Go value: it contains itself", e.obj.Type())
+}